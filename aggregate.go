@@ -0,0 +1,552 @@
+package mingodb
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	bolt "go.etcd.io/bbolt"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Pipeline is a sequence of aggregation stages. Each stage is a
+// document with a single key, the name of the stage, whose value
+// is the stage's argument:
+//
+//	mingodb.Pipeline{
+//		{{Key: "$match", Value: bson.M{"age": bson.M{"$gte": 18}}}},
+//		{{Key: "$sort", Value: bson.D{{Key: "age", Value: -1}}}},
+//		{{Key: "$limit", Value: 10}},
+//	}
+//
+// See PipelineBuilder, or the functions that return a stage such as
+// MatchStage, for building pipelines without writing BSON:
+//
+//	mingodb.Pipeline{
+//		mingodb.MatchStage(mingodb.Filter{}.Gte("age", 18).Build()),
+//		mingodb.SortStage(map[string]int{"age": -1}),
+//		mingodb.LimitStage(10),
+//	}
+type Pipeline = []bson.D
+
+// Aggregate runs the pipeline against the documents in the collection
+// and returns a cursor over the resulting documents.
+//
+// The supported stages are $match, $sort, $skip, $limit, $project,
+// $addFields, $replaceRoot, $group, $bucket, $facet, $lookup, $unwind,
+// $sample and $count. Stages are run in memory one after the other,
+// apart from a leading $match, which filters the documents as the collection
+// is scanned, and a $sample at the start or following it, which
+// samples them as they're scanned.
+func (c *Collection) Aggregate(ctx context.Context, pipeline Pipeline) (_ *MultiResult, err error) {
+	ctx, end := c.startOperation(ctx, "aggregate", nil, nil)
+	defer func() { end(err) }()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var docs []map[string]interface{}
+	err = c.read(func(tx *bolt.Tx) error {
+		b, err := c.bucket(tx)
+		if err != nil {
+			return err
+		}
+
+		// Does the pipeline start with a $match? If so, only
+		// collect the documents that match it.
+		filter := map[string]interface{}{}
+		stages := pipeline
+		if len(stages) > 0 && len(stages[0]) == 1 && stages[0][0].Key == "$match" {
+			if filter, err = stageDocument(stages[0][0].Value); err != nil {
+				return fmt.Errorf("stage 0 ($match): %w", err)
+			}
+			stages = stages[1:]
+		}
+		filter = c.visible(filter)
+
+		// Is the next stage a $sample? If so, sample the documents
+		// as they're scanned rather than collecting them all.
+		var res *reservoir
+		if len(stages) > 0 && len(stages[0]) == 1 && stages[0][0].Key == "$sample" {
+			n, err := sampleSize(stages[0][0].Value)
+			if err != nil {
+				return fmt.Errorf("stage %d ($sample): %w", len(pipeline)-len(stages), err)
+			}
+			r, err := newSampleRand()
+			if err != nil {
+				return err
+			}
+			res = newReservoir(n, r)
+			stages = stages[1:]
+		}
+
+		err = scanMatches(ctx, b, filter, func(k, v []byte, doc map[string]interface{}) (bool, error) {
+			if res != nil {
+				res.add(doc)
+			} else {
+				docs = append(docs, doc)
+			}
+			return true, nil
+		})
+		if err != nil {
+			return err
+		}
+		if res != nil {
+			docs = res.sample()
+		}
+
+		docs, err = runPipeline(ctx, c.db, tx, docs, stages, len(pipeline)-len(stages))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	data := make([][]byte, len(docs))
+	for i, doc := range docs {
+		if data[i], err = bson.Marshal(doc); err != nil {
+			return nil, err
+		}
+	}
+	return &MultiResult{data: data, ResultCount: len(data), TotalMatched: len(data)}, nil
+}
+
+// runPipeline runs each stage of the pipeline against docs in turn,
+// within tx, one of db's transactions. offset is the index of the first stage within the whole
+// pipeline, which is used in error messages.
+func runPipeline(ctx context.Context, db *Database, tx *bolt.Tx, docs []map[string]interface{}, pipeline Pipeline, offset int) ([]map[string]interface{}, error) {
+	for i, stage := range pipeline {
+		// Has the aggregation been cancelled?
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		if len(stage) != 1 {
+			return nil, fmt.Errorf("%w: stage %d must have exactly one key", ErrInvalidPipeline, offset+i)
+		}
+		name, arg := stage[0].Key, stage[0].Value
+
+		var err error
+		switch name {
+		case "$match":
+			docs, err = matchStage(docs, arg)
+		case "$sort":
+			docs, err = sortStage(docs, arg)
+		case "$skip":
+			docs, err = skipStage(docs, arg)
+		case "$limit":
+			docs, err = limitStage(docs, arg)
+		case "$project":
+			docs, err = projectStage(docs, arg)
+		case "$addFields":
+			docs, err = addFieldsStage(docs, arg)
+		case "$replaceRoot":
+			docs, err = replaceRootStage(docs, arg)
+		case "$group":
+			docs, err = groupStage(docs, arg)
+		case "$bucket":
+			docs, err = bucketStage(docs, arg)
+		case "$facet":
+			docs, err = facetStage(ctx, db, tx, docs, arg)
+		case "$lookup":
+			docs, err = lookupStage(ctx, db, tx, docs, arg)
+		case "$unwind":
+			docs, err = unwindStage(docs, arg)
+		case "$sample":
+			docs, err = sampleStage(docs, arg)
+		case "$count":
+			docs, err = countStage(docs, arg)
+		default:
+			err = fmt.Errorf("%w: unknown stage", ErrInvalidPipeline)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("stage %d (%s): %w", offset+i, name, err)
+		}
+	}
+	return docs, nil
+}
+
+// matchStage keeps the documents that match the filter.
+func matchStage(docs []map[string]interface{}, arg interface{}) ([]map[string]interface{}, error) {
+	filter, err := stageDocument(arg)
+	if err != nil {
+		return nil, err
+	}
+
+	out := docs[:0]
+	for _, doc := range docs {
+		ok, err := matchesFilter(doc, filter)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			out = append(out, doc)
+		}
+	}
+	return out, nil
+}
+
+// sortStage sorts the documents by the fields in the argument, in
+// order. Use a bson.D rather than a map to sort by more than one
+// field, as maps are unordered.
+func sortStage(docs []map[string]interface{}, arg interface{}) ([]map[string]interface{}, error) {
+	d, err := stageFields(arg)
+	if err != nil {
+		return nil, err
+	}
+	if len(d) == 0 {
+		return nil, fmt.Errorf("%w: $sort requires at least one field", ErrInvalidPipeline)
+	}
+
+	fields := make([]SortField, len(d))
+	for i, e := range d {
+		dir, ok := toInt(e.Value)
+		if !ok || (dir != 1 && dir != -1) {
+			return nil, fmt.Errorf("%w: sort direction for %s must be 1 or -1", ErrInvalidPipeline, e.Key)
+		}
+		fields[i] = SortField{Field: e.Key, Dir: int(dir)}
+	}
+
+	matches := make([]match, len(docs))
+	for i, doc := range docs {
+		matches[i].doc = doc
+	}
+	sortMatches(matches, fields)
+	for i, m := range matches {
+		docs[i] = m.doc
+	}
+	return docs, nil
+}
+
+// skipStage skips the given number of documents.
+func skipStage(docs []map[string]interface{}, arg interface{}) ([]map[string]interface{}, error) {
+	n, ok := toInt(arg)
+	if !ok || n < 0 {
+		return nil, fmt.Errorf("%w: $skip must be a non-negative integer", ErrInvalidPipeline)
+	}
+	if int(n) >= len(docs) {
+		return nil, nil
+	}
+	return docs[n:], nil
+}
+
+// limitStage keeps at most the given number of documents.
+func limitStage(docs []map[string]interface{}, arg interface{}) ([]map[string]interface{}, error) {
+	n, ok := toInt(arg)
+	if !ok || n <= 0 {
+		return nil, fmt.Errorf("%w: $limit must be a positive integer", ErrInvalidPipeline)
+	}
+	if int(n) < len(docs) {
+		docs = docs[:n]
+	}
+	return docs, nil
+}
+
+// countStage replaces the documents with a single document whose
+// field, named by the argument, is the number of documents:
+//
+//	{"$count": "total"}
+//
+// Like MongoDB, it outputs no document if there are no documents.
+func countStage(docs []map[string]interface{}, arg interface{}) ([]map[string]interface{}, error) {
+	field, ok := arg.(string)
+	if !ok || field == "" || strings.HasPrefix(field, "$") || strings.Contains(field, ".") {
+		return nil, fmt.Errorf("%w: $count must be a field name without a leading $ or dots", ErrInvalidPipeline)
+	}
+	if len(docs) == 0 {
+		return nil, nil
+	}
+	return []map[string]interface{}{{field: countValue(len(docs))}}, nil
+}
+
+// projectStage applies a projection to each document. The projection
+// follows the same rules as FindOptions.Projection, but its values
+// may also be booleans, or expressions that compute a field:
+//
+//	{"$project": {"fullName": {"$concat": ["$firstName", " ", "$lastName"]}, "age": 1}}
+//
+// Computed fields are included alongside the fields listed with 1, and
+// can't be mixed with exclusions other than the _id's.
+func projectStage(docs []map[string]interface{}, arg interface{}) ([]map[string]interface{}, error) {
+	d, err := stageDocument(arg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Values other than booleans and numbers are expressions that
+	// compute the field (see evalExpression).
+	proj := make(map[string]int, len(d))
+	computed := make(map[string]interface{})
+	for k, v := range d {
+		switch v := v.(type) {
+		case bool:
+			if v {
+				proj[k] = 1
+			} else {
+				proj[k] = 0
+			}
+		default:
+			f, ok := toFloat(v)
+			if !ok {
+				computed[k] = v
+			} else if f != 0 {
+				proj[k] = 1
+			} else {
+				proj[k] = 0
+			}
+		}
+	}
+	include, err := validateProjection(proj)
+	if err != nil {
+		return nil, err
+	}
+
+	// Computed fields are included fields, so they can't be mixed
+	// with exclusions.
+	if len(computed) > 0 {
+		for k, v := range proj {
+			if v == 0 && k != "_id" {
+				return nil, fmt.Errorf("%w: can't compute fields while excluding %s", ErrInvalidProjection, k)
+			}
+		}
+		include = true
+	}
+
+	for i, doc := range docs {
+		out := projectDocument(doc, proj, include)
+		for k, expr := range computed {
+			v, ok, err := evalExpression(doc, expr)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", k, err)
+			}
+			if !ok {
+				continue
+			}
+			if err := setPath(out, k, v); err != nil {
+				return nil, err
+			}
+		}
+		docs[i] = out
+	}
+	return docs, nil
+}
+
+// addFieldsStage adds computed fields to each document, keeping its
+// other fields. The fields' values are expressions, as in projectStage:
+//
+//	{"$addFields": {"ageInDays": {"$multiply": ["$ageInYears", 365]}}}
+//
+// Every expression is evaluated against the document as it was before
+// the stage, and a field that already exists is replaced.
+func addFieldsStage(docs []map[string]interface{}, arg interface{}) ([]map[string]interface{}, error) {
+	fields, err := stageDocument(arg)
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("%w: $addFields requires at least one field", ErrInvalidPipeline)
+	}
+
+	for i, doc := range docs {
+		out := doc
+		for k, expr := range fields {
+			v, ok, err := evalExpression(doc, expr)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", k, err)
+			}
+			if !ok {
+				continue
+			}
+			// Copy the document rather than modifying it, as
+			// $unwind's copies share its embedded documents.
+			out = withPath(out, k, v)
+		}
+		docs[i] = out
+	}
+	return docs, nil
+}
+
+// replaceRootStage replaces each document with the document that the
+// newRoot expression evaluates to, such as an embedded document:
+//
+//	{"$replaceRoot": {"newRoot": "$address"}}
+//
+// or {"newRoot": {"$mergeObjects": ["$address", {"name": "$name"}]}}.
+// Returns ErrInvalidReplaceRoot if the expression doesn't evaluate to
+// a document for one of the documents.
+func replaceRootStage(docs []map[string]interface{}, arg interface{}) ([]map[string]interface{}, error) {
+	d, err := stageDocument(arg)
+	if err != nil {
+		return nil, err
+	}
+	expr, ok := d["newRoot"]
+	if !ok || len(d) != 1 {
+		return nil, fmt.Errorf("%w: $replaceRoot takes only newRoot", ErrInvalidPipeline)
+	}
+
+	for i, doc := range docs {
+		v, ok, err := evalExpression(doc, expr)
+		if err != nil {
+			return nil, err
+		}
+		root, isDoc := v.(map[string]interface{})
+		if !ok || !isDoc {
+			return nil, fmt.Errorf("%w: newRoot evaluated to %s", ErrInvalidReplaceRoot, typeName(v, ok))
+		}
+		docs[i] = root
+	}
+	return docs, nil
+}
+
+// typeName describes the type of a value for error messages. exists
+// is false for a missing field.
+func typeName(v interface{}, exists bool) string {
+	switch {
+	case !exists:
+		return "missing"
+	case v == nil:
+		return "null"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// stageDocument converts a stage's argument into a map with the same
+// Go types as a decoded document. The argument may be a bson.D, a
+// bson.M or anything else accepted as a document.
+func stageDocument(arg interface{}) (map[string]interface{}, error) {
+	if m, err := toDocument(arg); err == nil {
+		arg = m
+	}
+	b, err := bson.Marshal(arg)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPipeline, err)
+	}
+	var m map[string]interface{}
+	if err := bson.Unmarshal(b, &m); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPipeline, err)
+	}
+	return m, nil
+}
+
+// stageFields is like stageDocument but keeps the order of the
+// argument's fields.
+func stageFields(arg interface{}) (bson.D, error) {
+	if d, ok := arg.(bson.D); ok {
+		return d, nil
+	}
+	if m, err := toDocument(arg); err == nil {
+		arg = m
+	}
+	b, err := bson.Marshal(arg)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPipeline, err)
+	}
+	var d bson.D
+	if err := bson.Unmarshal(b, &d); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPipeline, err)
+	}
+	return d, nil
+}
+
+// PipelineBuilder builds a Pipeline one stage at a time:
+//
+//	pipeline := mingodb.NewPipelineBuilder().
+//		Match(map[string]interface{}{"age": map[string]interface{}{"$gte": 18}}).
+//		Sort(mingodb.SortField{Field: "age", Dir: -1}).
+//		Limit(10).
+//		Build()
+type PipelineBuilder struct {
+	stages Pipeline
+}
+
+// NewPipelineBuilder returns an empty PipelineBuilder.
+func NewPipelineBuilder() *PipelineBuilder {
+	return &PipelineBuilder{}
+}
+
+// stage appends a stage to the pipeline.
+func (pb *PipelineBuilder) stage(name string, arg interface{}) *PipelineBuilder {
+	pb.stages = append(pb.stages, bson.D{{Key: name, Value: arg}})
+	return pb
+}
+
+// Match adds a $match stage, which keeps the documents that match
+// the filter. The filter follows the same rules as Find.
+func (pb *PipelineBuilder) Match(filter interface{}) *PipelineBuilder {
+	return pb.stage("$match", filter)
+}
+
+// Sort adds a $sort stage, which sorts the documents by the given
+// fields, in order.
+func (pb *PipelineBuilder) Sort(fields ...SortField) *PipelineBuilder {
+	d := make(bson.D, len(fields))
+	for i, f := range fields {
+		d[i] = bson.E{Key: f.Field, Value: f.Dir}
+	}
+	return pb.stage("$sort", d)
+}
+
+// Skip adds a $skip stage, which skips the first n documents.
+func (pb *PipelineBuilder) Skip(n int) *PipelineBuilder {
+	return pb.stage("$skip", n)
+}
+
+// Limit adds a $limit stage, which keeps at most n documents.
+func (pb *PipelineBuilder) Limit(n int) *PipelineBuilder {
+	return pb.stage("$limit", n)
+}
+
+// Project adds a $project stage, which selects the fields of each
+// document. The projection follows the same rules as
+// FindOptions.Projection, and can also compute fields with expressions
+// such as {"$concat": ["$firstName", " ", "$lastName"]}.
+func (pb *PipelineBuilder) Project(projection interface{}) *PipelineBuilder {
+	return pb.stage("$project", projection)
+}
+
+// AddFields adds an $addFields stage, which adds fields computed with
+// expressions to each document, such as
+// {"ageInDays": {"$multiply": ["$ageInYears", 365]}}, keeping its
+// other fields.
+func (pb *PipelineBuilder) AddFields(fields interface{}) *PipelineBuilder {
+	return pb.stage("$addFields", fields)
+}
+
+// ReplaceRoot adds a $replaceRoot stage, which replaces each document
+// with the document that newRoot evaluates to, such as "$address".
+func (pb *PipelineBuilder) ReplaceRoot(newRoot interface{}) *PipelineBuilder {
+	return pb.stage("$replaceRoot", map[string]interface{}{"newRoot": newRoot})
+}
+
+// Sample adds a $sample stage, which keeps n documents chosen at
+// random, in random order.
+func (pb *PipelineBuilder) Sample(n int) *PipelineBuilder {
+	return pb.stage("$sample", map[string]interface{}{"size": n})
+}
+
+// Bucket adds a $bucket stage, which groups the documents into buckets
+// by the value of groupBy, such as "$price", between each pair of
+// boundaries (see BucketStage).
+func (pb *PipelineBuilder) Bucket(groupBy interface{}, boundaries []interface{}, opts ...BucketOptions) *PipelineBuilder {
+	stage := BucketStage(groupBy, boundaries, opts...)
+	return pb.stage(stage[0].Key, stage[0].Value)
+}
+
+// Facet adds a $facet stage, which runs each of the pipelines against
+// the documents and outputs a single document with their results under
+// their names.
+func (pb *PipelineBuilder) Facet(facets map[string]Pipeline) *PipelineBuilder {
+	return pb.stage("$facet", facets)
+}
+
+// Count adds a $count stage, which replaces the documents with a
+// single document whose field is the number of documents, or with no
+// document if there are none.
+func (pb *PipelineBuilder) Count(field string) *PipelineBuilder {
+	return pb.stage("$count", field)
+}
+
+// Build returns the pipeline.
+func (pb *PipelineBuilder) Build() Pipeline {
+	return append(Pipeline(nil), pb.stages...)
+}
@@ -0,0 +1,234 @@
+package mingodb_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/korrbit/mingodb"
+	"github.com/korrbit/mingodb/testutil"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// aggregate runs the pipeline and returns the resulting documents.
+func aggregate(t *testing.T, c *mingodb.Collection, pipeline mingodb.Pipeline) []map[string]interface{} {
+	t.Helper()
+	res, err := c.Aggregate(context.Background(), pipeline)
+	if err != nil {
+		t.Fatalf("Aggregate: %v", err)
+	}
+	docs := []map[string]interface{}{}
+	for res.Next() {
+		var doc map[string]interface{}
+		if err := res.Decode(&doc); err != nil {
+			t.Fatalf("Decode: %v", err)
+		}
+		docs = append(docs, doc)
+	}
+	return docs
+}
+
+func TestAggregate(t *testing.T) {
+	c := people(t)
+	tests := []struct {
+		name     string
+		pipeline mingodb.Pipeline
+		expected []map[string]interface{}
+	}{
+		{
+			name: "builder",
+			pipeline: mingodb.NewPipelineBuilder().
+				Match(map[string]interface{}{"age": map[string]interface{}{"$gte": 25}}).
+				Sort(mingodb.SortField{Field: "age", Dir: -1}).
+				Skip(1).
+				Limit(1).
+				Project(map[string]interface{}{"name": 1, "_id": 0}).
+				Build(),
+			expected: []map[string]interface{}{{"name": "Alice"}},
+		},
+		{
+			name: "stages",
+			pipeline: mingodb.Pipeline{
+				mingodb.MatchStage(map[string]interface{}{"city": "Paris"}),
+				mingodb.SortStage(map[string]int{"age": 1}),
+				mingodb.ProjectStage(map[string]interface{}{"age": 0, "city": 0, mingodb.VersionField: 0}),
+			},
+			expected: []map[string]interface{}{
+				{"_id": int32(1), "name": "Alice"},
+				{"_id": int32(3), "name": "Carol"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := aggregate(t, c, tt.pipeline); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("got %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestAggregateUnknownStage(t *testing.T) {
+	c := people(t)
+	_, err := c.Aggregate(context.Background(), mingodb.Pipeline{bson.D{{Key: "$nope", Value: 1}}})
+	if !errors.Is(err, mingodb.ErrInvalidPipeline) {
+		t.Errorf("Aggregate returned %v, expected ErrInvalidPipeline", err)
+	}
+}
+
+func TestAddFieldsStage(t *testing.T) {
+	c := people(t)
+	got := aggregate(t, c, mingodb.Pipeline{
+		mingodb.MatchStage(map[string]interface{}{"_id": 1}),
+		mingodb.AddFieldsStage(map[string]interface{}{
+			"ageInDays": map[string]interface{}{"$multiply": []interface{}{"$age", 365}},
+			// Expressions see the document as it was before the stage.
+			"age":        map[string]interface{}{"$add": []interface{}{"$age", 1}},
+			"oldAge":     "$age",
+			"place.city": "$city",
+			"missing":    "$missing",
+		}),
+	})
+	expected := []map[string]interface{}{{
+		"_id":                int32(1),
+		"name":               "Alice",
+		"age":                int32(31),
+		"city":               "Paris",
+		"ageInDays":          int32(30 * 365),
+		"oldAge":             int32(30),
+		"place":              map[string]interface{}{"city": "Paris"},
+		mingodb.VersionField: int64(1),
+	}}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %v, expected %v", got, expected)
+	}
+
+	_, err := c.Aggregate(context.Background(), mingodb.Pipeline{mingodb.AddFieldsStage(map[string]interface{}{})})
+	if !errors.Is(err, mingodb.ErrInvalidPipeline) {
+		t.Errorf("Aggregate returned %v, expected ErrInvalidPipeline for no fields", err)
+	}
+}
+
+func TestAddFieldsAfterUnwind(t *testing.T) {
+	c := testutil.NewTestDB(t).CollectionMust("items")
+	testutil.SeedCollection(t, c, map[string]interface{}{
+		"_id":   1,
+		"tags":  []interface{}{"a", "b"},
+		"owner": map[string]interface{}{"name": "Ada"},
+	})
+	// Each copy made by $unwind gets its own field.
+	got := aggregate(t, c, mingodb.Pipeline{
+		mingodb.UnwindStage("$tags"),
+		mingodb.AddFieldsStage(map[string]interface{}{"owner.tag": "$tags"}),
+		mingodb.ProjectStage(map[string]interface{}{"_id": 0, "owner": 1}),
+	})
+	expected := []map[string]interface{}{
+		{"owner": map[string]interface{}{"name": "Ada", "tag": "a"}},
+		{"owner": map[string]interface{}{"name": "Ada", "tag": "b"}},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %v, expected %v", got, expected)
+	}
+}
+
+func TestReplaceRootStage(t *testing.T) {
+	c := testutil.NewTestDB(t).CollectionMust("items")
+	testutil.SeedCollection(t, c,
+		map[string]interface{}{"_id": 1, "name": "Ada", "address": map[string]interface{}{"city": "London"}},
+		map[string]interface{}{"_id": 2, "name": "Bob", "address": map[string]interface{}{"city": "Paris", "zip": "75001"}},
+	)
+
+	got := aggregate(t, c, mingodb.Pipeline{mingodb.ReplaceRootStage("$address")})
+	expected := []map[string]interface{}{
+		{"city": "London"},
+		{"city": "Paris", "zip": "75001"},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %v, expected %v", got, expected)
+	}
+
+	got = aggregate(t, c, mingodb.Pipeline{
+		mingodb.MatchStage(map[string]interface{}{"_id": 2}),
+		mingodb.ReplaceRootStage(map[string]interface{}{"$mergeObjects": []interface{}{
+			map[string]interface{}{"zip": "none"},
+			"$address",
+			map[string]interface{}{"name": "$name"},
+		}}),
+	})
+	expected = []map[string]interface{}{{"city": "Paris", "zip": "75001", "name": "Bob"}}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %v, expected %v", got, expected)
+	}
+}
+
+func TestReplaceRootStageErrors(t *testing.T) {
+	c := testutil.NewTestDB(t).CollectionMust("items")
+	testutil.SeedCollection(t, c, map[string]interface{}{"_id": 1, "name": "Ada", "address": nil})
+	tests := []struct {
+		name  string
+		stage bson.D
+		err   error
+	}{
+		{"Null", mingodb.ReplaceRootStage("$address"), mingodb.ErrInvalidReplaceRoot},
+		{"Missing", mingodb.ReplaceRootStage("$missing"), mingodb.ErrInvalidReplaceRoot},
+		{"String", mingodb.ReplaceRootStage("$name"), mingodb.ErrInvalidReplaceRoot},
+		{"NoNewRoot", bson.D{{Key: "$replaceRoot", Value: bson.D{{Key: "root", Value: "$address"}}}}, mingodb.ErrInvalidPipeline},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := c.Aggregate(context.Background(), mingodb.Pipeline{tt.stage}); !errors.Is(err, tt.err) {
+				t.Errorf("got %v, expected %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestCountStage(t *testing.T) {
+	c := people(t)
+	tests := []struct {
+		name     string
+		pipeline mingodb.Pipeline
+		expected []map[string]interface{}
+	}{
+		{
+			name:     "All",
+			pipeline: mingodb.Pipeline{mingodb.CountStage("total")},
+			expected: []map[string]interface{}{{"total": int32(3)}},
+		},
+		{
+			name: "AfterMatch",
+			pipeline: mingodb.Pipeline{
+				mingodb.MatchStage(map[string]interface{}{"city": "Paris"}),
+				mingodb.CountStage("parisians"),
+			},
+			expected: []map[string]interface{}{{"parisians": int32(2)}},
+		},
+		{
+			// Like MongoDB, counting no documents outputs no document.
+			name: "None",
+			pipeline: mingodb.Pipeline{
+				mingodb.MatchStage(map[string]interface{}{"city": "Rome"}),
+				mingodb.CountStage("total"),
+			},
+			expected: []map[string]interface{}{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := aggregate(t, c, tt.pipeline); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("got %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCountStageInvalidField(t *testing.T) {
+	c := people(t)
+	for _, arg := range []interface{}{"", "$total", "a.b", 1} {
+		_, err := c.Aggregate(context.Background(), mingodb.Pipeline{{{Key: "$count", Value: arg}}})
+		if !errors.Is(err, mingodb.ErrInvalidPipeline) {
+			t.Errorf("$count %v returned %v, expected ErrInvalidPipeline", arg, err)
+		}
+	}
+}
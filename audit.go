@@ -0,0 +1,118 @@
+package mingodb
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// AuditEntry is a line written by the middleware returned by
+// NewAuditMiddleware.
+type AuditEntry struct {
+	Timestamp   time.Time     `json:"timestamp"`
+	User        string        `json:"user"`
+	Collection  string        `json:"collection"`
+	Operation   string        `json:"operation"` // Such as "insertOne" or "deleteMany"
+	Filter      interface{}   `json:"filter,omitempty"`
+	Update      interface{}   `json:"update,omitempty"` // The update or replacement
+	DocumentIDs []interface{} `json:"documentIDs"`      // _ids of the documents written
+}
+
+// auditOperations are the names of the operations that are audited.
+var auditOperations = map[string]bool{
+	"insertOne":        true,
+	"insertMany":       true,
+	"updateOne":        true,
+	"updateMany":       true,
+	"upsertOne":        true,
+	"replaceOne":       true,
+	"findOneAndUpdate": true,
+	"findOneAndDelete": true,
+	"deleteOne":        true,
+	"deleteMany":       true,
+	"hardDelete":       true,
+	"bulkWrite":        true,
+	"import":           true,
+	"optimisticUpdate": true,
+	"rollback":         true,
+	"expire":           true,
+}
+
+// NewAuditMiddleware returns middleware that writes an AuditEntry to w,
+// as a line of JSON, for each of the collection's successful writes.
+// The entry's user is returned by userFn, which is passed the write's
+// context; userFn can be nil.
+//
+// The filter and update are copied when the write starts, so that
+// they're logged as they were passed. Lines are written once the
+// write has been committed, so an error writing one can't be
+// reported and is ignored. w is never written to concurrently.
+func NewAuditMiddleware(w io.Writer, userFn func(ctx context.Context) string) Middleware {
+	return &auditMiddleware{w: w, userFn: userFn}
+}
+
+// auditMiddleware is the middleware returned by NewAuditMiddleware.
+type auditMiddleware struct {
+	BaseMiddleware
+
+	mu     sync.Mutex // Guards w
+	w      io.Writer
+	userFn func(ctx context.Context) string
+}
+
+// auditKey is the context key of the entry for a write.
+type auditKey struct{}
+
+// StartOperation starts the entry for a write.
+func (m *auditMiddleware) StartOperation(ctx context.Context, c *Collection, op string) context.Context {
+	if !auditOperations[op] {
+		return ctx
+	}
+	e := &AuditEntry{Collection: c.name, Operation: op, DocumentIDs: []interface{}{}}
+	if o := operationFrom(ctx); o != nil {
+		e.Filter = copyValue(o.filter)
+		e.Update = copyValue(o.update)
+	}
+	return context.WithValue(ctx, auditKey{}, e)
+}
+
+// EndOperation writes the entry for a successful write.
+func (m *auditMiddleware) EndOperation(ctx context.Context, err error) {
+	e, ok := ctx.Value(auditKey{}).(*AuditEntry)
+	if !ok || err != nil {
+		return
+	}
+	e.Timestamp = time.Now().UTC()
+	if m.userFn != nil {
+		e.User = m.userFn(ctx)
+	}
+	if o := operationFrom(ctx); o != nil {
+		e.DocumentIDs = append(e.DocumentIDs, o.ids...)
+	}
+	line, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.w.Write(append(line, '\n'))
+}
+
+// copyValue returns a deep copy of a filter or update. Values that
+// aren't documents, such as a bare _id, are returned as they are.
+func copyValue(v interface{}) interface{} {
+	if v == nil || isIDFilter(v) {
+		return v
+	}
+	m, err := toDocument(v)
+	if err != nil {
+		return v
+	}
+	if m, err = normalizeDocument(m); err != nil {
+		return v
+	}
+	return m
+}
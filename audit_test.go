@@ -0,0 +1,87 @@
+package mingodb_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/korrbit/mingodb"
+	"github.com/korrbit/mingodb/testutil"
+)
+
+// userKey is the context key of the user in TestAuditMiddleware.
+type userKey struct{}
+
+func TestAuditMiddleware(t *testing.T) {
+	ctx := context.WithValue(context.Background(), userKey{}, "alice")
+	var buf bytes.Buffer
+	c := testutil.NewTestDB(t).CollectionMust("items")
+	c.Use(mingodb.NewAuditMiddleware(&buf, func(ctx context.Context) string {
+		user, _ := ctx.Value(userKey{}).(string)
+		return user
+	}))
+
+	start := time.Now().UTC()
+	if _, err := c.InsertMany(ctx, []interface{}{
+		map[string]interface{}{"_id": 1, "n": 1},
+		map[string]interface{}{"_id": 2, "n": 2},
+	}); err != nil {
+		t.Fatalf("InsertMany: %v", err)
+	}
+	if _, err := c.UpdateMany(ctx, map[string]interface{}{"n": map[string]interface{}{"$gt": 1}}, map[string]interface{}{"$inc": map[string]interface{}{"n": 1}}); err != nil {
+		t.Fatalf("UpdateMany: %v", err)
+	}
+	if _, err := c.DeleteOne(ctx, 1); err != nil {
+		t.Fatalf("DeleteOne: %v", err)
+	}
+	// Reads and failed writes aren't audited.
+	if _, err := c.Find(ctx, nil); err != nil {
+		t.Fatalf("Find: %v", err)
+	}
+	if _, err := c.InsertOne(ctx, map[string]interface{}{"_id": 2}); err == nil {
+		t.Fatal("InsertOne of a duplicate _id succeeded")
+	}
+
+	var entries []mingodb.AuditEntry
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var e mingodb.AuditEntry
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			t.Fatalf("line %q: %v", line, err)
+		}
+		if e.Timestamp.Before(start) || e.Timestamp.After(time.Now()) {
+			t.Errorf("line %q has timestamp %v", line, e.Timestamp)
+		}
+		e.Timestamp = time.Time{}
+		entries = append(entries, e)
+	}
+	expected := []mingodb.AuditEntry{
+		{
+			User:        "alice",
+			Collection:  "items",
+			Operation:   "insertMany",
+			DocumentIDs: []interface{}{1.0, 2.0},
+		},
+		{
+			User:        "alice",
+			Collection:  "items",
+			Operation:   "updateMany",
+			Filter:      map[string]interface{}{"n": map[string]interface{}{"$gt": 1.0}},
+			Update:      map[string]interface{}{"$inc": map[string]interface{}{"n": 1.0}},
+			DocumentIDs: []interface{}{2.0},
+		},
+		{
+			User:        "alice",
+			Collection:  "items",
+			Operation:   "deleteOne",
+			Filter:      1.0,
+			DocumentIDs: []interface{}{1.0},
+		},
+	}
+	if !reflect.DeepEqual(entries, expected) {
+		t.Errorf("got entries %+v, expected %+v", entries, expected)
+	}
+}
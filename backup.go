@@ -0,0 +1,184 @@
+package mingodb
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Backup writes a consistent copy of the whole database to w, which
+// can be restored with Restore. The copy is written from a read
+// transaction, so writes can carry on while it's written, although
+// writes that need to grow the file block until it's done (see
+// Snapshot). Once ctx is cancelled, writing stops and Backup returns
+// ctx's error.
+func (db *Database) Backup(ctx context.Context, w io.Writer) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return db.view(func(tx *bolt.Tx) error {
+		_, err := tx.WriteTo(ctxWriter{ctx, w})
+		return err
+	})
+}
+
+// BackupToFile writes a copy of the database to the file at path (see
+// Backup). The file is replaced if it exists, and removed if the
+// backup fails.
+func (db *Database) BackupToFile(ctx context.Context, path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	err = db.Backup(ctx, f)
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(path)
+	}
+	return err
+}
+
+// Restore replaces the whole database with a copy written by Backup.
+// The copy is written to a temporary file next to the database's file,
+// and checked, before the database is closed, the file replaced and the
+// database reopened. If the copy can't be read, ErrInvalidBackup is
+// returned and the database is left as it was.
+//
+// Restore must not be called while the database is being used by
+// other goroutines. Collections, including their middleware, carry on
+// working once it has returned, but transactions and snapshots started
+// before it must be closed first. Returns ErrReadOnly if the database
+// was opened by OpenReadOnly.
+func (db *Database) Restore(ctx context.Context, r io.Reader) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if db.readOnly {
+		return ErrReadOnly
+	}
+
+	// Write the copy next to the database, so that it can replace it
+	// with a rename.
+	tmp, err := writeRestoreFile(ctx, db.Path, r)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp)
+
+	// Can the copy be opened?
+	check, err := bolt.Open(tmp, db.mode, &bolt.Options{Timeout: db.options.Timeout, ReadOnly: true})
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidBackup, err)
+	}
+	if err := check.Close(); err != nil {
+		return err
+	}
+
+	interval, err := db.replaceFile(tmp)
+	if interval > 0 {
+		db.StartTTLWorker(interval)
+	}
+	return err
+}
+
+// replaceFile closes the database, replaces its file with the file
+// at path and reopens it, keeping its codec. The TTL worker is
+// stopped, and its interval returned so that it can be restarted, or
+// 0 if it wasn't running.
+//
+// If the file can't be replaced, the database is reopened as it was.
+// If it can't be reopened, it's left closed: every method then returns
+// ErrDatabaseClosed, and it has to be opened again with Open.
+func (db *Database) replaceFile(path string) (time.Duration, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	var interval time.Duration
+	if db.ttl != nil {
+		interval = db.ttl.interval
+		db.stopTTLWorker()
+	}
+
+	codec, hasCodec := codecs.Load(db.db)
+	if err := db.db.Close(); err != nil {
+		return 0, boltError(err)
+	}
+	codecs.Delete(db.db)
+	forgetSchemas(db.db)
+	reopen := func() error {
+		b, err := bolt.Open(db.Path, db.mode, db.options)
+		if err != nil {
+			return fmt.Errorf("%w: %v; the database is closed and must be opened again", ErrOpeningDatabase, err)
+		}
+		db.db = b
+		if hasCodec {
+			codecs.Store(b, codec)
+		}
+		return nil
+	}
+
+	if err := os.Rename(path, db.Path); err != nil {
+		if rerr := reopen(); rerr != nil {
+			return 0, rerr
+		}
+		return interval, err
+	}
+	if err := reopen(); err != nil {
+		return 0, err
+	}
+	return interval, nil
+}
+
+// RestoreFromFile replaces the database with the copy in the file at
+// path (see Restore).
+func (db *Database) RestoreFromFile(ctx context.Context, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return db.Restore(ctx, f)
+}
+
+// writeRestoreFile writes r to a new temporary file in the same
+// directory as path and returns the file's name.
+func writeRestoreFile(ctx context.Context, path string, r io.Reader) (string, error) {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".restore-*")
+	if err != nil {
+		return "", err
+	}
+	_, err = io.Copy(ctxWriter{ctx, f}, r)
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// ctxWriter is a writer that fails once its context is cancelled.
+type ctxWriter struct {
+	ctx context.Context
+	w   io.Writer
+}
+
+func (w ctxWriter) Write(p []byte) (int, error) {
+	if err := w.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return w.w.Write(p)
+}
@@ -0,0 +1,94 @@
+package mingodb_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/korrbit/mingodb"
+	"github.com/korrbit/mingodb/testutil"
+)
+
+func TestBackupAndRestore(t *testing.T) {
+	ctx := context.Background()
+	db := testutil.NewTestDB(t)
+	c := db.CollectionMust("items")
+	testutil.SeedCollection(t, c, map[string]interface{}{"_id": 1})
+
+	var buf bytes.Buffer
+	if err := db.Backup(ctx, &buf); err != nil {
+		t.Fatalf("Backup: %v", err)
+	}
+	if _, err := c.InsertOne(ctx, map[string]interface{}{"_id": 2}); err != nil {
+		t.Fatalf("InsertOne: %v", err)
+	}
+	db.CollectionMust("other")
+
+	if err := db.Restore(ctx, &buf); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	// The collection carries on working with the restored data.
+	testutil.AssertDocumentCount(t, c, nil, 1)
+	testutil.AssertDocumentExists(t, c, map[string]interface{}{"_id": 1})
+	if exists, err := db.CollectionExists(ctx, "other"); err != nil || exists {
+		t.Errorf("CollectionExists returned %v, %v, expected the restore to remove it", exists, err)
+	}
+	if _, err := c.InsertOne(ctx, map[string]interface{}{"_id": 3}); err != nil {
+		t.Errorf("InsertOne after Restore: %v", err)
+	}
+}
+
+func TestBackupToFile(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "backup.db")
+	db := testutil.NewTestDB(t)
+	c := db.CollectionMust("items")
+	testutil.SeedCollection(t, c, map[string]interface{}{"_id": 1})
+
+	if err := db.BackupToFile(ctx, path); err != nil {
+		t.Fatalf("BackupToFile: %v", err)
+	}
+	if _, err := c.DeleteOne(ctx, 1); err != nil {
+		t.Fatalf("DeleteOne: %v", err)
+	}
+	if err := db.RestoreFromFile(ctx, path); err != nil {
+		t.Fatalf("RestoreFromFile: %v", err)
+	}
+	testutil.AssertDocumentExists(t, c, map[string]interface{}{"_id": 1})
+
+	// The backup can also be opened as a database.
+	backup, err := mingodb.OpenReadOnly(path)
+	if err != nil {
+		t.Fatalf("OpenReadOnly: %v", err)
+	}
+	defer backup.Close()
+	testutil.AssertDocumentExists(t, backup.CollectionMust("items"), map[string]interface{}{"_id": 1})
+}
+
+func TestRestoreInvalidBackup(t *testing.T) {
+	ctx := context.Background()
+	db := testutil.NewTestDB(t)
+	c := db.CollectionMust("items")
+	testutil.SeedCollection(t, c, map[string]interface{}{"_id": 1})
+
+	if err := db.Restore(ctx, strings.NewReader("not a database")); !errors.Is(err, mingodb.ErrInvalidBackup) {
+		t.Errorf("Restore returned %v, expected ErrInvalidBackup", err)
+	}
+	testutil.AssertDocumentExists(t, c, map[string]interface{}{"_id": 1})
+}
+
+func TestBackupCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	db := testutil.NewTestDB(t)
+	var buf bytes.Buffer
+	if err := db.Backup(ctx, &buf); !errors.Is(err, context.Canceled) {
+		t.Errorf("Backup returned %v, expected context.Canceled", err)
+	}
+	if err := db.Restore(ctx, &buf); !errors.Is(err, context.Canceled) {
+		t.Errorf("Restore returned %v, expected context.Canceled", err)
+	}
+}
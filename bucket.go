@@ -0,0 +1,127 @@
+package mingodb
+
+import (
+	"fmt"
+	"sort"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// bucketStage groups the documents into buckets by the value of the
+// groupBy expression, and computes each of the output accumulators
+// for every bucket, as $group does:
+//
+//	{"$bucket": {
+//		"groupBy":    "$price",
+//		"boundaries": [0, 10, 50, 100],
+//		"default":    "Other",
+//		"output":     {"count": {"$sum": 1}},
+//	}}
+//
+// A bucket's _id is its lower boundary, which is inclusive, while its
+// upper boundary is exclusive. Documents whose value is outside the
+// boundaries go into a bucket whose _id is default, or are an error
+// if there's no default. The output defaults to {"count": {"$sum": 1}}.
+// Buckets without documents are left out, and the default bucket is
+// returned last.
+func bucketStage(docs []map[string]interface{}, arg interface{}) ([]map[string]interface{}, error) {
+	spec, err := stageDocument(arg)
+	if err != nil {
+		return nil, err
+	}
+	for k := range spec {
+		switch k {
+		case "groupBy", "boundaries", "default", "output":
+		default:
+			return nil, fmt.Errorf("%w: unknown $bucket field %s", ErrInvalidPipeline, k)
+		}
+	}
+	groupBy, ok := spec["groupBy"]
+	if !ok {
+		return nil, fmt.Errorf("%w: $bucket requires groupBy", ErrInvalidPipeline)
+	}
+	boundaries, err := bucketBoundaries(spec["boundaries"])
+	if err != nil {
+		return nil, err
+	}
+	def, hasDefault := spec["default"]
+
+	output := map[string]interface{}{"count": map[string]interface{}{"$sum": int32(1)}}
+	if o, ok := spec["output"]; ok {
+		if output, ok = o.(map[string]interface{}); !ok {
+			return nil, fmt.Errorf("%w: $bucket output must be a document", ErrInvalidPipeline)
+		}
+		if _, ok := output["_id"]; ok {
+			return nil, fmt.Errorf("%w: $bucket output can't have an _id", ErrInvalidPipeline)
+		}
+	}
+	accs, err := parseAccumulators(output)
+	if err != nil {
+		return nil, err
+	}
+
+	// Assign each document to a bucket. The last bucket is the
+	// default one.
+	buckets := make([]*groupState, len(boundaries))
+	for _, doc := range docs {
+		v, _, err := evalExpression(doc, groupBy)
+		if err != nil {
+			return nil, err
+		}
+		// Which boundary is the last one at or below the value?
+		i := sort.Search(len(boundaries), func(i int) bool {
+			return compareValues(boundaries[i], v) > 0
+		}) - 1
+		if i < 0 || i == len(boundaries)-1 || typeRank(v) != typeRank(boundaries[0]) {
+			if !hasDefault {
+				return nil, fmt.Errorf("%w: $bucket groupBy value %v is outside the boundaries and there's no default", ErrInvalidPipeline, v)
+			}
+			i = len(boundaries) - 1
+		}
+
+		b := buckets[i]
+		if b == nil {
+			b = &groupState{id: boundaries[i], states: make([]accumulatorState, len(accs))}
+			if i == len(boundaries)-1 {
+				b.id = def
+			}
+			buckets[i] = b
+		}
+		for j, acc := range accs {
+			if err := accumulate(&b.states[j], acc, doc); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	var out []map[string]interface{}
+	for _, b := range buckets {
+		if b == nil {
+			continue
+		}
+		doc := map[string]interface{}{"_id": b.id}
+		for j, acc := range accs {
+			doc[acc.field] = accumulatorResult(b.states[j], acc)
+		}
+		out = append(out, doc)
+	}
+	return out, nil
+}
+
+// bucketBoundaries returns a $bucket stage's boundaries, which must
+// be at least two values of the same type in ascending order.
+func bucketBoundaries(arg interface{}) ([]interface{}, error) {
+	boundaries, ok := arg.(primitive.A)
+	if !ok || len(boundaries) < 2 {
+		return nil, fmt.Errorf("%w: $bucket boundaries must be an array of at least 2 values", ErrInvalidPipeline)
+	}
+	for i := 1; i < len(boundaries); i++ {
+		if typeRank(boundaries[i]) != typeRank(boundaries[0]) {
+			return nil, fmt.Errorf("%w: $bucket boundaries must all be of the same type", ErrInvalidPipeline)
+		}
+		if compareValues(boundaries[i-1], boundaries[i]) >= 0 {
+			return nil, fmt.Errorf("%w: $bucket boundaries must be sorted in ascending order", ErrInvalidPipeline)
+		}
+	}
+	return boundaries, nil
+}
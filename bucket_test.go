@@ -0,0 +1,115 @@
+package mingodb_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/korrbit/mingodb"
+	"github.com/korrbit/mingodb/testutil"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// products returns a collection of products with various prices.
+func products(t *testing.T) *mingodb.Collection {
+	t.Helper()
+	c := testutil.NewTestDB(t).CollectionMust("products")
+	testutil.SeedCollection(t, c,
+		map[string]interface{}{"_id": 1, "price": 5},
+		map[string]interface{}{"_id": 2, "price": 10},
+		map[string]interface{}{"_id": 3, "price": 20},
+		map[string]interface{}{"_id": 4, "price": 60},
+		map[string]interface{}{"_id": 5, "price": 150},
+		map[string]interface{}{"_id": 6, "price": "n/a"},
+		map[string]interface{}{"_id": 7},
+	)
+	return c
+}
+
+func TestBucketStage(t *testing.T) {
+	c := products(t)
+	boundaries := []interface{}{0, 10, 50, 100}
+	tests := []struct {
+		name     string
+		stage    bson.D
+		expected []map[string]interface{}
+	}{
+		{
+			name: "Output",
+			stage: mingodb.BucketStage("$price", boundaries, mingodb.BucketOptions{
+				Default: "Other",
+				Output: map[string]interface{}{
+					"count": map[string]interface{}{"$sum": 1},
+					"total": map[string]interface{}{"$sum": "$price"},
+				},
+			}),
+			expected: []map[string]interface{}{
+				{"_id": int32(0), "count": int32(1), "total": int32(5)},
+				{"_id": int32(10), "count": int32(2), "total": int32(30)},
+				{"_id": int32(50), "count": int32(1), "total": int32(60)},
+				{"_id": "Other", "count": int32(3), "total": int32(150)},
+			},
+		},
+		{
+			name: "DefaultOutput",
+			stage: mingodb.BucketStage("$price", boundaries, mingodb.BucketOptions{
+				Default: "Other",
+			}),
+			expected: []map[string]interface{}{
+				{"_id": int32(0), "count": int32(1)},
+				{"_id": int32(10), "count": int32(2)},
+				{"_id": int32(50), "count": int32(1)},
+				{"_id": "Other", "count": int32(3)},
+			},
+		},
+		{
+			name: "EmptyBucketsLeftOut",
+			stage: mingodb.BucketStage("$price", []interface{}{0, 8, 9, 100}, mingodb.BucketOptions{
+				Default: -1,
+			}),
+			expected: []map[string]interface{}{
+				{"_id": int32(0), "count": int32(1)},
+				{"_id": int32(9), "count": int32(3)},
+				{"_id": int32(-1), "count": int32(3)},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := aggregate(t, c, mingodb.Pipeline{tt.stage}); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("got %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestBucketStageErrors(t *testing.T) {
+	c := products(t)
+	tests := []struct {
+		name  string
+		stage bson.D
+	}{
+		{"NoDefault", mingodb.BucketStage("$price", []interface{}{0, 100})},
+		{"NoGroupBy", bson.D{{Key: "$bucket", Value: bson.D{{Key: "boundaries", Value: []interface{}{0, 1}}}}}},
+		{"OneBoundary", mingodb.BucketStage("$price", []interface{}{0}, mingodb.BucketOptions{Default: "Other"})},
+		{"Unsorted", mingodb.BucketStage("$price", []interface{}{10, 0}, mingodb.BucketOptions{Default: "Other"})},
+		{"MixedTypes", mingodb.BucketStage("$price", []interface{}{0, "a"}, mingodb.BucketOptions{Default: "Other"})},
+		{"OutputID", mingodb.BucketStage("$price", []interface{}{0, 100}, mingodb.BucketOptions{
+			Default: "Other",
+			Output:  map[string]interface{}{"_id": 1},
+		})},
+		{"UnknownField", bson.D{{Key: "$bucket", Value: bson.D{
+			{Key: "groupBy", Value: "$price"},
+			{Key: "boundaries", Value: []interface{}{0, 100}},
+			{Key: "buckets", Value: 2},
+		}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := c.Aggregate(context.Background(), mingodb.Pipeline{tt.stage}); !errors.Is(err, mingodb.ErrInvalidPipeline) {
+				t.Errorf("got %v, expected ErrInvalidPipeline", err)
+			}
+		})
+	}
+}
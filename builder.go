@@ -0,0 +1,397 @@
+package mingodb
+
+import "context"
+
+// Filter builds a filter document one condition at a time:
+//
+//	filter := mingodb.Filter{}.Eq("status", "active").Gt("age", 18).Build()
+//
+// Conditions on the same field are combined, so Gte("age", 18) and
+// Lt("age", 65) build {"age": {"$gte": 18, "$lt": 65}}. Conditions
+// that can't be combined, such as two equalities on the same field,
+// are put in an $and.
+//
+// Each method returns a new Filter, so a Filter can be extended in
+// different ways without affecting the others.
+type Filter struct {
+	conds []filterCond
+}
+
+// filterCond is a condition added to a Filter.
+type filterCond struct {
+	field string
+	op    string      // The operator, or "" for an equality
+	value interface{} // The operator's argument
+}
+
+// with returns a copy of f with the condition added.
+func (f Filter) with(field, op string, value interface{}) *Filter {
+	conds := make([]filterCond, len(f.conds), len(f.conds)+1)
+	copy(conds, f.conds)
+	return &Filter{conds: append(conds, filterCond{field, op, value})}
+}
+
+// Eq matches documents whose field equals val.
+func (f Filter) Eq(field string, val interface{}) *Filter {
+	return f.with(field, "", val)
+}
+
+// Ne matches documents whose field doesn't equal val, including
+// documents without the field.
+func (f Filter) Ne(field string, val interface{}) *Filter {
+	return f.with(field, "$ne", val)
+}
+
+// Gt matches documents whose field is greater than val.
+func (f Filter) Gt(field string, val interface{}) *Filter {
+	return f.with(field, "$gt", val)
+}
+
+// Gte matches documents whose field is greater than or equal to val.
+func (f Filter) Gte(field string, val interface{}) *Filter {
+	return f.with(field, "$gte", val)
+}
+
+// Lt matches documents whose field is less than val.
+func (f Filter) Lt(field string, val interface{}) *Filter {
+	return f.with(field, "$lt", val)
+}
+
+// Lte matches documents whose field is less than or equal to val.
+func (f Filter) Lte(field string, val interface{}) *Filter {
+	return f.with(field, "$lte", val)
+}
+
+// In matches documents whose field equals one of vals.
+func (f Filter) In(field string, vals ...interface{}) *Filter {
+	return f.with(field, "$in", append([]interface{}{}, vals...))
+}
+
+// Nin matches documents whose field equals none of vals.
+func (f Filter) Nin(field string, vals ...interface{}) *Filter {
+	return f.with(field, "$nin", append([]interface{}{}, vals...))
+}
+
+// Exists matches documents that have the field if exists is true, or
+// that don't if it's false.
+func (f Filter) Exists(field string, exists bool) *Filter {
+	return f.with(field, "$exists", exists)
+}
+
+// Regex matches documents whose field matches the regular expression.
+// options are the $regex options, such as "i", and can be empty.
+func (f Filter) Regex(field, pattern, options string) *Filter {
+	cond := map[string]interface{}{"$regex": pattern}
+	if options != "" {
+		cond["$options"] = options
+	}
+	return f.with(field, "$regex", cond)
+}
+
+// And matches documents that match every one of the filters.
+func (f Filter) And(filters ...*Filter) *Filter {
+	return f.with("$and", "", buildFilters(filters))
+}
+
+// Or matches documents that match at least one of the filters.
+func (f Filter) Or(filters ...*Filter) *Filter {
+	return f.with("$or", "", buildFilters(filters))
+}
+
+// Nor matches documents that match none of the filters.
+func (f Filter) Nor(filters ...*Filter) *Filter {
+	return f.with("$nor", "", buildFilters(filters))
+}
+
+// Not matches documents whose field doesn't match cond's conditions
+// on the same field. For example, Not("age", Filter{}.Gt("age", 65))
+// builds {"age": {"$not": {"$gt": 65}}}.
+func (f Filter) Not(field string, cond *Filter) *Filter {
+	var v interface{} = map[string]interface{}{}
+	if cond != nil {
+		if c, ok := cond.Build().(map[string]interface{})[field]; ok {
+			v = c
+		}
+	}
+	return f.with(field, "$not", v)
+}
+
+// Build returns the filter as a map[string]interface{}, which can be
+// passed to Find and the other methods that take a filter.
+func (f Filter) Build() interface{} {
+	m := make(map[string]interface{}, len(f.conds))
+	ops := make(map[string]map[string]interface{}) // The fields' operators
+	var and []interface{}
+	for _, c := range f.conds {
+		// Is it a logical operator? Further ones are put in the $and.
+		if c.field == "$and" || c.field == "$or" || c.field == "$nor" {
+			if _, ok := m[c.field]; ok {
+				and = append(and, map[string]interface{}{c.field: c.value})
+			} else {
+				m[c.field] = c.value
+			}
+			continue
+		}
+
+		// Can the condition be added to the field's conditions?
+		// It can if both are operators, and not the same operators.
+		v := condValue(c)
+		if _, ok := m[c.field]; !ok {
+			m[c.field] = v
+			if c.op != "" {
+				ops[c.field] = v.(map[string]interface{})
+			}
+			continue
+		}
+		if fieldOps, ok := ops[c.field]; ok && c.op != "" && !hasCondOps(fieldOps, c) {
+			for k, v := range v.(map[string]interface{}) {
+				fieldOps[k] = v
+			}
+			continue
+		}
+		and = append(and, map[string]interface{}{c.field: v})
+	}
+	if len(and) > 0 {
+		if existing, ok := m["$and"].([]interface{}); ok {
+			and = append(append([]interface{}{}, existing...), and...)
+		}
+		m["$and"] = and
+	}
+	return m
+}
+
+// condValue returns the condition's value within a filter document.
+func condValue(c filterCond) interface{} {
+	switch c.op {
+	case "":
+		return c.value
+	case "$regex":
+		ops := make(map[string]interface{}, 2)
+		for k, v := range c.value.(map[string]interface{}) {
+			ops[k] = v
+		}
+		return ops
+	}
+	return map[string]interface{}{c.op: c.value}
+}
+
+// hasCondOps reports whether ops already has one of the condition's
+// operators.
+func hasCondOps(ops map[string]interface{}, c filterCond) bool {
+	for k := range condValue(c).(map[string]interface{}) {
+		if _, ok := ops[k]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// buildFilters builds each of the filters.
+func buildFilters(filters []*Filter) []interface{} {
+	built := make([]interface{}, 0, len(filters))
+	for _, f := range filters {
+		if f != nil {
+			built = append(built, f.Build())
+		}
+	}
+	return built
+}
+
+// Update builds an update document one operator at a time:
+//
+//	update := mingodb.Update{}.Set("name", "Alice").Inc("count", 1).Build()
+//
+// builds {"$set": {"name": "Alice"}, "$inc": {"count": 1}}. Setting the
+// same field with the same operator again replaces its value, except
+// for Push and AddToSet, whose values are all added using $each.
+// Using a field with two operators, such as Set and Unset, builds an
+// update that writes reject with ErrInvalidUpdate.
+//
+// Like Filter, each method returns a new Update.
+type Update struct {
+	ops []updateOp
+}
+
+// updateOp is an operator added to an Update.
+type updateOp struct {
+	op, field string
+	value     interface{}
+}
+
+// with returns a copy of u with the operator added.
+func (u Update) with(op, field string, value interface{}) *Update {
+	ops := make([]updateOp, len(u.ops), len(u.ops)+1)
+	copy(ops, u.ops)
+	return &Update{ops: append(ops, updateOp{op, field, value})}
+}
+
+// Set sets the field to val.
+func (u Update) Set(field string, val interface{}) *Update {
+	return u.with("$set", field, val)
+}
+
+// Unset removes the field.
+func (u Update) Unset(field string) *Update {
+	return u.with("$unset", field, "")
+}
+
+// Inc adds delta to the field.
+func (u Update) Inc(field string, delta interface{}) *Update {
+	return u.with("$inc", field, delta)
+}
+
+// Mul multiplies the field by factor.
+func (u Update) Mul(field string, factor interface{}) *Update {
+	return u.with("$mul", field, factor)
+}
+
+// Push appends val to the array field.
+func (u Update) Push(field string, val interface{}) *Update {
+	return u.with("$push", field, val)
+}
+
+// Pull removes the elements of the array field that match val, which
+// can be a value or a condition such as {"$lt": 50}.
+func (u Update) Pull(field string, val interface{}) *Update {
+	return u.with("$pull", field, val)
+}
+
+// AddToSet appends val to the array field unless it already contains
+// it.
+func (u Update) AddToSet(field string, val interface{}) *Update {
+	return u.with("$addToSet", field, val)
+}
+
+// Rename renames oldField to newField.
+func (u Update) Rename(oldField, newField string) *Update {
+	return u.with("$rename", oldField, newField)
+}
+
+// CurrentDate sets the field to the current date.
+func (u Update) CurrentDate(field string) *Update {
+	return u.with("$currentDate", field, true)
+}
+
+// Build returns the update as a map[string]interface{}, which can be
+// passed to UpdateOne and the other methods that take an update.
+func (u Update) Build() interface{} {
+	m := make(map[string]interface{})
+	each := make(map[updateOp][]interface{}) // The values of the $each arrays, by op and field
+	for _, o := range u.ops {
+		fields, ok := m[o.op].(map[string]interface{})
+		if !ok {
+			fields = make(map[string]interface{})
+			m[o.op] = fields
+		}
+
+		// Is it another value to add to the same array?
+		existing, ok := fields[o.field]
+		if !ok || (o.op != "$push" && o.op != "$addToSet") {
+			fields[o.field] = o.value
+			continue
+		}
+		k := updateOp{op: o.op, field: o.field}
+		if _, ok := each[k]; !ok {
+			each[k] = []interface{}{existing}
+		}
+		each[k] = append(each[k], o.value)
+		fields[o.field] = map[string]interface{}{"$each": each[k]}
+	}
+	return m
+}
+
+// Query builds a query of a collection, then runs it:
+//
+//	res, err := c.Query().Filter(mingodb.Filter{}.Eq("active", true)).Sort("name", 1).Limit(10).All(ctx)
+//
+// Unlike Filter and Update, the methods that build a Query modify it
+// and return it.
+type Query struct {
+	c      *Collection
+	filter *Filter
+	opts   FindOptions
+}
+
+// Query returns a new query of the collection, which matches every
+// document.
+func (c *Collection) Query() *Query {
+	return &Query{c: c}
+}
+
+// Filter sets the query's filter.
+func (q *Query) Filter(f *Filter) *Query {
+	q.filter = f
+	return q
+}
+
+// Project sets the query's projection (see FindOptions.Projection).
+func (q *Query) Project(proj map[string]int) *Query {
+	q.opts.Projection = proj
+	return q
+}
+
+// Sort adds a field to sort the results by, after any added before
+// it. dir is 1 for ascending order or -1 for descending order.
+func (q *Query) Sort(field string, dir int) *Query {
+	q.opts.Sort = append(q.opts.Sort, SortField{Field: field, Dir: dir})
+	return q
+}
+
+// Limit sets the maximum number of documents to return, or 0 for no
+// limit.
+func (q *Query) Limit(n int) *Query {
+	q.opts.Limit = n
+	return q
+}
+
+// Skip sets the number of matching documents to skip.
+func (q *Query) Skip(n int) *Query {
+	q.opts.Skip = n
+	return q
+}
+
+// built returns the query's filter document, or nil if it has none.
+func (q *Query) built() interface{} {
+	if q.filter == nil {
+		return nil
+	}
+	return q.filter.Build()
+}
+
+// One unmarshals the first matching document into result. Returns
+// ErrNoDocuments if no document matches.
+func (q *Query) One(ctx context.Context, result interface{}) error {
+	res, err := q.c.FindOne(ctx, q.built(), q.opts)
+	if err != nil {
+		return err
+	}
+	return res.Decode(result)
+}
+
+// All returns the matching documents.
+func (q *Query) All(ctx context.Context) (*MultiResult, error) {
+	return q.c.Find(ctx, q.built(), q.opts)
+}
+
+// Count returns the number of matching documents, after skipping and
+// limiting them. Sort and Project don't affect the count.
+func (q *Query) Count(ctx context.Context) (int, error) {
+	n, err := q.c.CountDocuments(ctx, q.built())
+	if err != nil {
+		return 0, err
+	}
+	if n -= q.opts.Skip; n < 0 {
+		n = 0
+	}
+	if q.opts.Limit > 0 && n > q.opts.Limit {
+		n = q.opts.Limit
+	}
+	return n, nil
+}
+
+// Distinct returns the distinct values of the field in the matching
+// documents (see Collection.Distinct). Skip, Limit, Sort and Project
+// don't affect the values.
+func (q *Query) Distinct(ctx context.Context, field string) ([]interface{}, error) {
+	return q.c.Distinct(ctx, field, q.built())
+}
@@ -0,0 +1,223 @@
+package mingodb_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/korrbit/mingodb"
+	"github.com/korrbit/mingodb/testutil"
+)
+
+// doc is shorthand for a document in the builder tests.
+type doc = map[string]interface{}
+
+func TestFilterBuilder(t *testing.T) {
+	f := mingodb.Filter{}
+	tests := []struct {
+		name     string
+		filter   *mingodb.Filter
+		expected interface{}
+	}{
+		{"Empty", &f, doc{}},
+		{"Eq", f.Eq("status", "active"), doc{"status": "active"}},
+		{"Comparisons", f.Gt("a", 1).Gte("b", 2).Lt("c", 3).Lte("d", 4).Ne("e", 5), doc{
+			"a": doc{"$gt": 1},
+			"b": doc{"$gte": 2},
+			"c": doc{"$lt": 3},
+			"d": doc{"$lte": 4},
+			"e": doc{"$ne": 5},
+		}},
+		{"SameField", f.Gte("age", 18).Lt("age", 65), doc{"age": doc{"$gte": 18, "$lt": 65}}},
+		{"SameOperator", f.Gt("age", 18).Gt("age", 21), doc{
+			"age":  doc{"$gt": 18},
+			"$and": []interface{}{doc{"age": doc{"$gt": 21}}},
+		}},
+		{"SameEquality", f.Eq("tag", "a").Eq("tag", "b"), doc{
+			"tag":  "a",
+			"$and": []interface{}{doc{"tag": "b"}},
+		}},
+		{"InAndNin", f.In("a", 1, 2).Nin("b", 3), doc{
+			"a": doc{"$in": []interface{}{1, 2}},
+			"b": doc{"$nin": []interface{}{3}},
+		}},
+		{"Exists", f.Exists("a", false), doc{"a": doc{"$exists": false}}},
+		{"Regex", f.Regex("name", "^a", "i").Regex("email", "@", ""), doc{
+			"name":  doc{"$regex": "^a", "$options": "i"},
+			"email": doc{"$regex": "@"},
+		}},
+		{"Or", f.Eq("a", 1).Or(f.Eq("b", 2), f.Eq("c", 3)), doc{
+			"a":   1,
+			"$or": []interface{}{doc{"b": 2}, doc{"c": 3}},
+		}},
+		{"TwoOrs", f.Or(f.Eq("a", 1)).Or(f.Eq("b", 2)), doc{
+			"$or":  []interface{}{doc{"a": 1}},
+			"$and": []interface{}{doc{"$or": []interface{}{doc{"b": 2}}}},
+		}},
+		{"AndAndNor", f.And(f.Eq("a", 1)).Nor(f.Eq("b", 2)), doc{
+			"$and": []interface{}{doc{"a": 1}},
+			"$nor": []interface{}{doc{"b": 2}},
+		}},
+		{"Not", f.Not("age", f.Gt("age", 65)), doc{"age": doc{"$not": doc{"$gt": 65}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if built := tt.filter.Build(); !reflect.DeepEqual(built, tt.expected) {
+				t.Errorf("got %v, expected %v", built, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFilterBuilderCopies(t *testing.T) {
+	base := mingodb.Filter{}.Eq("a", 1)
+	b := base.Eq("b", 2)
+	c := base.Eq("c", 3)
+	if built, expected := b.Build(), (doc{"a": 1, "b": 2}); !reflect.DeepEqual(built, expected) {
+		t.Errorf("got %v, expected %v", built, expected)
+	}
+	if built, expected := c.Build(), (doc{"a": 1, "c": 3}); !reflect.DeepEqual(built, expected) {
+		t.Errorf("got %v, expected %v", built, expected)
+	}
+}
+
+func TestFilterBuilderFind(t *testing.T) {
+	c := people(t)
+	f := mingodb.Filter{}.Eq("city", "Paris").Gt("age", 30).Build()
+	if ids, expected := findIDs(t, c, f), []int32{3}; !reflect.DeepEqual(ids, expected) {
+		t.Errorf("got _ids %v, expected %v", ids, expected)
+	}
+}
+
+func TestUpdateBuilder(t *testing.T) {
+	u := mingodb.Update{}
+	tests := []struct {
+		name     string
+		update   *mingodb.Update
+		expected interface{}
+	}{
+		{"SetAndInc", u.Set("name", "Alice").Inc("count", 1), doc{
+			"$set": doc{"name": "Alice"},
+			"$inc": doc{"count": 1},
+		}},
+		{"SameOperator", u.Set("a", 1).Set("b", 2).Set("a", 3), doc{"$set": doc{"a": 3, "b": 2}}},
+		{"Operators", u.Unset("a").Mul("b", 2).Pull("c", 1).Rename("d", "e").CurrentDate("f"), doc{
+			"$unset":       doc{"a": ""},
+			"$mul":         doc{"b": 2},
+			"$pull":        doc{"c": 1},
+			"$rename":      doc{"d": "e"},
+			"$currentDate": doc{"f": true},
+		}},
+		{"Push", u.Push("tags", "a"), doc{"$push": doc{"tags": "a"}}},
+		{"PushEach", u.Push("tags", "a").Push("tags", "b").Push("tags", "c"), doc{
+			"$push": doc{"tags": doc{"$each": []interface{}{"a", "b", "c"}}},
+		}},
+		{"AddToSetEach", u.AddToSet("tags", "a").AddToSet("tags", "b"), doc{
+			"$addToSet": doc{"tags": doc{"$each": []interface{}{"a", "b"}}},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if built := tt.update.Build(); !reflect.DeepEqual(built, tt.expected) {
+				t.Errorf("got %v, expected %v", built, tt.expected)
+			}
+		})
+	}
+}
+
+func TestUpdateBuilderUpdateOne(t *testing.T) {
+	ctx := context.Background()
+	c := people(t)
+	update := mingodb.Update{}.Set("city", "Lyon").Inc("age", 1).Push("tags", "a").Push("tags", "b").Build()
+	if _, err := c.UpdateOne(ctx, doc{"_id": 1}, update); err != nil {
+		t.Fatalf("UpdateOne: %v", err)
+	}
+	testutil.AssertDocumentExists(t, c, doc{
+		"_id":  1,
+		"name": "Alice",
+		"age":  31,
+		"city": "Lyon",
+		"tags": []interface{}{"a", "b"},
+	})
+}
+
+func TestUpdateBuilderConflict(t *testing.T) {
+	c := people(t)
+	update := mingodb.Update{}.Set("city", "Lyon").Unset("city").Build()
+	_, err := c.UpdateOne(context.Background(), doc{"_id": 1}, update)
+	if !errors.Is(err, mingodb.ErrInvalidUpdate) {
+		t.Errorf("UpdateOne returned %v, expected ErrInvalidUpdate", err)
+	}
+	testutil.AssertDocumentExists(t, c, doc{"_id": 1, "city": "Paris"})
+}
+
+func TestQuery(t *testing.T) {
+	ctx := context.Background()
+	c := people(t)
+	adults := mingodb.Filter{}.Gte("age", 30)
+
+	res, err := c.Query().Filter(adults).Sort("age", -1).All(ctx)
+	if err != nil {
+		t.Fatalf("All: %v", err)
+	}
+	if ids, expected := resultIDs(t, res), []int32{3, 1}; !reflect.DeepEqual(ids, expected) {
+		t.Errorf("All returned _ids %v, expected %v", ids, expected)
+	}
+	res, err = c.Query().Sort("city", 1).Sort("age", -1).Skip(1).Limit(1).All(ctx)
+	if err != nil {
+		t.Fatalf("All: %v", err)
+	}
+	if ids, expected := resultIDs(t, res), []int32{3}; !reflect.DeepEqual(ids, expected) {
+		t.Errorf("All returned _ids %v, expected %v", ids, expected)
+	}
+
+	var person map[string]interface{}
+	if err := c.Query().Filter(adults).Sort("age", 1).Project(map[string]int{"name": 1, "_id": 0}).One(ctx, &person); err != nil {
+		t.Fatalf("One: %v", err)
+	}
+	if expected := (doc{"name": "Alice"}); !reflect.DeepEqual(person, expected) {
+		t.Errorf("One returned %v, expected %v", person, expected)
+	}
+	err = c.Query().Filter(mingodb.Filter{}.Eq("city", "Rome")).One(ctx, &person)
+	if !errors.Is(err, mingodb.ErrNoDocuments) {
+		t.Errorf("One returned %v, expected ErrNoDocuments", err)
+	}
+}
+
+func TestQueryCountAndDistinct(t *testing.T) {
+	ctx := context.Background()
+	c := people(t)
+	tests := []struct {
+		name     string
+		query    *mingodb.Query
+		expected int
+	}{
+		{"All", c.Query(), 3},
+		{"Filter", c.Query().Filter(mingodb.Filter{}.Eq("city", "Paris")), 2},
+		{"Skip", c.Query().Skip(1), 2},
+		{"SkipAll", c.Query().Skip(5), 0},
+		{"Limit", c.Query().Skip(1).Limit(1), 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n, err := tt.query.Count(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n != tt.expected {
+				t.Errorf("got %d, expected %d", n, tt.expected)
+			}
+		})
+	}
+
+	values, err := c.Query().Filter(mingodb.Filter{}.Lt("age", 35)).Distinct(ctx, "city")
+	if err != nil {
+		t.Fatalf("Distinct: %v", err)
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i].(string) < values[j].(string) })
+	if expected := []interface{}{"London", "Paris"}; !reflect.DeepEqual(values, expected) {
+		t.Errorf("Distinct returned %v, expected %v", values, expected)
+	}
+}
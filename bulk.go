@@ -0,0 +1,147 @@
+package mingodb
+
+import (
+	"context"
+	"fmt"
+)
+
+// WriteOperation is a single write in a BulkWrite. It's implemented
+// by InsertOneOperation, UpdateOneOperation, UpdateManyOperation,
+// DeleteOneOperation and DeleteManyOperation.
+type WriteOperation interface {
+	// apply runs the operation against the collection and adds
+	// its outcome to res.
+	apply(ctx context.Context, c *Collection, res *BulkWriteResult) error
+}
+
+// InsertOneOperation inserts a single document.
+type InsertOneOperation struct {
+	Document interface{}
+}
+
+func (op InsertOneOperation) apply(ctx context.Context, c *Collection, res *BulkWriteResult) error {
+	if _, err := c.InsertOne(ctx, op.Document); err != nil {
+		return err
+	}
+	res.InsertedCount++
+	return nil
+}
+
+// UpdateOneOperation updates the first document that matches Filter.
+type UpdateOneOperation struct {
+	Filter interface{}
+	Update interface{}
+}
+
+func (op UpdateOneOperation) apply(ctx context.Context, c *Collection, res *BulkWriteResult) error {
+	r, err := c.UpdateOne(ctx, op.Filter, op.Update)
+	if err != nil {
+		return err
+	}
+	res.MatchedCount += r.MatchedCount
+	res.ModifiedCount += r.UpdateCount
+	return nil
+}
+
+// UpdateManyOperation updates every document that matches Filter.
+type UpdateManyOperation struct {
+	Filter interface{}
+	Update interface{}
+}
+
+func (op UpdateManyOperation) apply(ctx context.Context, c *Collection, res *BulkWriteResult) error {
+	r, err := c.UpdateMany(ctx, op.Filter, op.Update)
+	if err != nil {
+		return err
+	}
+	res.MatchedCount += r.MatchedCount
+	res.ModifiedCount += r.UpdateCount
+	return nil
+}
+
+// DeleteOneOperation deletes the first document that matches Filter.
+type DeleteOneOperation struct {
+	Filter interface{}
+}
+
+func (op DeleteOneOperation) apply(ctx context.Context, c *Collection, res *BulkWriteResult) error {
+	r, err := c.DeleteOne(ctx, op.Filter)
+	if err != nil {
+		return err
+	}
+	res.DeletedCount += r.DeleteCount
+	return nil
+}
+
+// DeleteManyOperation deletes every document that matches Filter.
+type DeleteManyOperation struct {
+	Filter interface{}
+}
+
+func (op DeleteManyOperation) apply(ctx context.Context, c *Collection, res *BulkWriteResult) error {
+	r, err := c.DeleteMany(ctx, op.Filter)
+	if err != nil {
+		return err
+	}
+	res.DeletedCount += r.DeleteCount
+	return nil
+}
+
+// BulkWriteOptions configures BulkWrite.
+type BulkWriteOptions struct {
+	// Ordered stops the bulk write at the first failed operation.
+	// If false, every operation is attempted. Operations are
+	// ordered when no options are passed.
+	Ordered bool
+}
+
+// BulkWriteError is the error returned by a single operation
+// in a BulkWrite.
+type BulkWriteError struct {
+	Index int   // Index of the operation in the bulk write
+	Err   error // Error returned by the operation
+}
+
+func (e BulkWriteError) Error() string {
+	return fmt.Sprintf("operation %d: %v", e.Index, e.Err)
+}
+
+func (e BulkWriteError) Unwrap() error {
+	return e.Err
+}
+
+// BulkWrite runs multiple write operations against the collection.
+//
+// Each operation runs in its own transaction, so a failed operation
+// is rolled back without affecting the others. In ordered mode (the
+// default) the first failure stops the remaining operations; otherwise
+// every operation is attempted. Errors from failed operations are
+// collected in the result's WriteErrors and ErrBulkWrite is returned.
+func (c *Collection) BulkWrite(ctx context.Context, ops []WriteOperation, opts ...BulkWriteOptions) (_ *BulkWriteResult, err error) {
+	ctx, end := c.startOperation(ctx, "bulkWrite", nil, nil)
+	defer func() { end(err) }()
+	ordered := true
+	for _, opt := range opts {
+		ordered = opt.Ordered
+	}
+
+	res := &BulkWriteResult{}
+	for i, op := range ops {
+		// Has the bulk write been cancelled?
+		if err := ctx.Err(); err != nil {
+			return res, err
+		}
+
+		if err := op.apply(ctx, c, res); err != nil {
+			res.WriteErrors = append(res.WriteErrors, BulkWriteError{Index: i, Err: err})
+			if ordered {
+				break
+			}
+		}
+	}
+
+	if len(res.WriteErrors) > 0 {
+		return res, fmt.Errorf("%w: %d of %d operations failed", ErrBulkWrite, len(res.WriteErrors), len(ops))
+	}
+	return res, nil
+}
@@ -0,0 +1,63 @@
+package mingodb_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/korrbit/mingodb"
+	"github.com/korrbit/mingodb/testutil"
+)
+
+func TestBulkWrite(t *testing.T) {
+	c := people(t)
+	res, err := c.BulkWrite(context.Background(), []mingodb.WriteOperation{
+		mingodb.InsertOneOperation{Document: map[string]interface{}{"_id": 4, "city": "Rome"}},
+		mingodb.UpdateOneOperation{Filter: map[string]interface{}{"_id": 4}, Update: map[string]interface{}{"$set": map[string]interface{}{"name": "Dave"}}},
+		mingodb.UpdateManyOperation{Filter: map[string]interface{}{"city": "Paris"}, Update: map[string]interface{}{"$set": map[string]interface{}{"country": "France"}}},
+		mingodb.DeleteOneOperation{Filter: map[string]interface{}{"_id": 2}},
+		mingodb.DeleteManyOperation{Filter: map[string]interface{}{"city": "Rome"}},
+	})
+	if err != nil {
+		t.Fatalf("BulkWrite: %v", err)
+	}
+	expected := mingodb.BulkWriteResult{InsertedCount: 1, MatchedCount: 3, ModifiedCount: 3, DeletedCount: 2}
+	if !reflect.DeepEqual(*res, expected) {
+		t.Errorf("got %+v, expected %+v", *res, expected)
+	}
+	testutil.AssertDocumentCount(t, c, map[string]interface{}{"country": "France"}, 2)
+	testutil.AssertDocumentCount(t, c, nil, 2)
+}
+
+func TestBulkWriteErrors(t *testing.T) {
+	ops := []mingodb.WriteOperation{
+		mingodb.InsertOneOperation{Document: map[string]interface{}{"_id": 4}},
+		mingodb.InsertOneOperation{Document: map[string]interface{}{"_id": 1}},
+		mingodb.InsertOneOperation{Document: map[string]interface{}{"_id": 5}},
+	}
+	tests := []struct {
+		name     string
+		opts     []mingodb.BulkWriteOptions
+		inserted int
+	}{
+		{"ordered", nil, 1},
+		{"unordered", []mingodb.BulkWriteOptions{{Ordered: false}}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := people(t)
+			res, err := c.BulkWrite(context.Background(), ops, tt.opts...)
+			if !errors.Is(err, mingodb.ErrBulkWrite) {
+				t.Fatalf("BulkWrite returned %v, expected ErrBulkWrite", err)
+			}
+			if res.InsertedCount != tt.inserted {
+				t.Errorf("inserted %d documents, expected %d", res.InsertedCount, tt.inserted)
+			}
+			if len(res.WriteErrors) != 1 || res.WriteErrors[0].Index != 1 || !errors.Is(res.WriteErrors[0], mingodb.ErrDuplicateKey) {
+				t.Errorf("got write errors %v, expected a duplicate key at index 1", res.WriteErrors)
+			}
+			testutil.AssertDocumentCount(t, c, nil, 3+tt.inserted)
+		})
+	}
+}
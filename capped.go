@@ -0,0 +1,105 @@
+package mingodb
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// capKey is the key of the cap in a collection's meta bucket.
+var capKey = []byte("cap")
+
+// SetCap caps the collection at maxDocuments documents: once it's
+// full, inserting a document first deletes the oldest document. If
+// the collection already has more documents, the oldest are deleted
+// straight away. A maxDocuments of 0 or less removes the cap.
+//
+// The oldest document is the one with the lowest key, which for
+// ObjectIDs, ULIDs and auto-incrementing _ids is the one inserted
+// first. The cap is stored in the database, so it applies to every
+// Collection with the same name, including after the database is
+// reopened.
+func (c *Collection) SetCap(maxDocuments int) error {
+	return c.write(func(tx *bolt.Tx) error {
+		if maxDocuments <= 0 {
+			if _, err := c.bucket(tx); err != nil {
+				return err
+			}
+			return dropMeta(tx, c.name)
+		}
+
+		w, err := c.writeTx(context.Background(), tx)
+		if err != nil {
+			return err
+		}
+		b, err := tx.CreateBucketIfNotExists([]byte(metaBucketName(c.name)))
+		if err != nil {
+			return err
+		}
+		v := make([]byte, 8)
+		binary.BigEndian.PutUint64(v, uint64(maxDocuments))
+		if err := b.Put(capKey, v); err != nil {
+			return err
+		}
+		return w.trim(maxDocuments)
+	})
+}
+
+// metaBucketName returns the name of the bucket that holds a
+// collection's settings, such as its cap.
+func metaBucketName(collection string) string {
+	return "__meta_" + collection
+}
+
+// collectionCap returns the collection's cap, or 0 if it isn't capped.
+func collectionCap(tx *bolt.Tx, collection string) int {
+	b := tx.Bucket([]byte(metaBucketName(collection)))
+	if b == nil {
+		return 0
+	}
+	v := b.Get(capKey)
+	if len(v) != 8 {
+		return 0
+	}
+	return int(binary.BigEndian.Uint64(v))
+}
+
+// makeRoom deletes the oldest documents of a capped collection so
+// that a new document can be inserted.
+func (w *writeTx) makeRoom() error {
+	max := collectionCap(w.tx, w.c.name)
+	if max == 0 {
+		return nil
+	}
+	return w.trim(max - 1)
+}
+
+// trim deletes the oldest documents until there are at most n left.
+func (w *writeTx) trim(n int) error {
+	for count := countKeys(w.tx, w.b); count > n; count-- {
+		k, _ := w.b.Cursor().First()
+		if err := w.delete(append([]byte(nil), k...)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dropMeta deletes the collection's settings, if it has any.
+func dropMeta(tx *bolt.Tx, collection string) error {
+	err := tx.DeleteBucket([]byte(metaBucketName(collection)))
+	if errors.Is(err, bolt.ErrBucketNotFound) {
+		return nil
+	}
+	return err
+}
+
+// copyMeta gives the dst collection the src collection's settings.
+func copyMeta(tx *bolt.Tx, src, dst string) error {
+	if tx.Bucket([]byte(metaBucketName(src))) == nil {
+		return nil
+	}
+	return copyBucket(context.Background(), tx, metaBucketName(src), metaBucketName(dst))
+}
@@ -0,0 +1,91 @@
+package mingodb_test
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/korrbit/mingodb"
+	"github.com/korrbit/mingodb/testutil"
+)
+
+func TestSetCap(t *testing.T) {
+	ctx := context.Background()
+	c := testutil.NewTestDB(t).CollectionMust("log")
+	testutil.SeedCollection(t, c,
+		map[string]interface{}{"_id": 1},
+		map[string]interface{}{"_id": 2},
+		map[string]interface{}{"_id": 3},
+	)
+
+	// Capping a fuller collection deletes the oldest documents.
+	if err := c.SetCap(2); err != nil {
+		t.Fatalf("SetCap: %v", err)
+	}
+	if ids, expected := findIDs(t, c, nil), []int32{2, 3}; !reflect.DeepEqual(ids, expected) {
+		t.Errorf("got _ids %v after SetCap, expected %v", ids, expected)
+	}
+
+	for _, id := range []int{4, 5} {
+		if _, err := c.InsertOne(ctx, map[string]interface{}{"_id": id}); err != nil {
+			t.Fatalf("InsertOne: %v", err)
+		}
+	}
+	if ids, expected := findIDs(t, c, nil), []int32{4, 5}; !reflect.DeepEqual(ids, expected) {
+		t.Errorf("got _ids %v after inserting, expected %v", ids, expected)
+	}
+	stats, err := c.Stats(ctx)
+	if err != nil {
+		t.Fatalf("Stats: %v", err)
+	}
+	if !stats.IsCapped || stats.Cap != 2 {
+		t.Errorf("got IsCapped %v and Cap %d, expected true and 2", stats.IsCapped, stats.Cap)
+	}
+
+	// A cap of 0 removes it.
+	if err := c.SetCap(0); err != nil {
+		t.Fatalf("SetCap: %v", err)
+	}
+	if _, err := c.InsertOne(ctx, map[string]interface{}{"_id": 6}); err != nil {
+		t.Fatalf("InsertOne: %v", err)
+	}
+	testutil.AssertDocumentCount(t, c, nil, 3)
+	if stats, err = c.Stats(ctx); err != nil {
+		t.Fatalf("Stats: %v", err)
+	}
+	if stats.IsCapped || stats.Cap != 0 {
+		t.Errorf("got IsCapped %v and Cap %d, expected false and 0", stats.IsCapped, stats.Cap)
+	}
+}
+
+func TestSetCapPersists(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "test.db")
+	db, err := mingodb.Open(path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if err := db.CollectionMust("log").SetCap(1); err != nil {
+		t.Fatalf("SetCap: %v", err)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	db, err = mingodb.Open(path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer db.Close()
+	c := db.CollectionMust("log")
+	if _, err := c.InsertMany(ctx, []interface{}{
+		map[string]interface{}{"_id": 1},
+		map[string]interface{}{"_id": 2},
+	}); err != nil {
+		t.Fatalf("InsertMany: %v", err)
+	}
+	if ids, expected := findIDs(t, c, nil), []int32{2}; !reflect.DeepEqual(ids, expected) {
+		t.Errorf("got _ids %v after reopening, expected %v", ids, expected)
+	}
+}
@@ -0,0 +1,170 @@
+package mingodb
+
+import (
+	"encoding/binary"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/vmihailenco/msgpack/v5"
+	bolt "go.etcd.io/bbolt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Codec converts documents to and from the bytes stored in the
+// database. A database's codec is set when it's opened (see
+// OpenOptions.Codec) and must be the same every time it's opened.
+//
+// Marshal is passed a document as a map[string]interface{} whose
+// values have the same Go types as a decoded BSON document, such as
+// primitive.A and primitive.ObjectID. Unmarshal's v is a pointer to a
+// map[string]interface{}. Values are converted back to BSON types
+// after decoding, but a codec that can't represent a type, such as
+// a date, may return it as a different type.
+//
+// Index keys and _ids are always encoded as BSON, whatever the codec.
+type Codec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// BSONCodec stores documents as BSON. It's the default.
+type BSONCodec struct{}
+
+// Marshal encodes v as BSON.
+func (BSONCodec) Marshal(v interface{}) ([]byte, error) {
+	return bson.Marshal(v)
+}
+
+// Unmarshal decodes BSON into v.
+func (BSONCodec) Unmarshal(data []byte, v interface{}) error {
+	return bson.Unmarshal(data, v)
+}
+
+// JSONCodec stores documents as human-readable JSON.
+//
+// Documents are encoded as MongoDB's relaxed Extended JSON rather than
+// with encoding/json, which can't tell an ObjectID or a date from a
+// string when it's decoded. Numbers, strings, booleans, arrays and
+// embedded documents are plain JSON, while other types are documents
+// such as {"$oid": "..."} and {"$date": "..."}.
+type JSONCodec struct{}
+
+// Marshal encodes v as relaxed Extended JSON.
+func (JSONCodec) Marshal(v interface{}) ([]byte, error) {
+	return bson.MarshalExtJSON(v, false, false)
+}
+
+// Unmarshal decodes Extended JSON into v.
+func (JSONCodec) Unmarshal(data []byte, v interface{}) error {
+	return bson.UnmarshalExtJSON(data, false, v)
+}
+
+// MsgpackCodec stores documents as MessagePack, which is usually
+// smaller than BSON.
+//
+// ObjectIDs and dates are stored as the MessagePack extension types
+// msgpackObjectIDExt and msgpackDateTimeExt, which are registered with
+// the msgpack package. Other BSON-specific types, such as
+// primitive.Binary, are stored as maps and decoded as documents.
+type MsgpackCodec struct{}
+
+// Marshal encodes v as MessagePack.
+func (MsgpackCodec) Marshal(v interface{}) ([]byte, error) {
+	return msgpack.Marshal(v)
+}
+
+// Unmarshal decodes MessagePack into v.
+func (MsgpackCodec) Unmarshal(data []byte, v interface{}) error {
+	return msgpack.Unmarshal(data, v)
+}
+
+// The MessagePack extension types used by MsgpackCodec.
+const (
+	msgpackObjectIDExt int8 = 66
+	msgpackDateTimeExt int8 = 67
+)
+
+func init() {
+	msgpack.RegisterExtEncoder(msgpackObjectIDExt, primitive.ObjectID{}, func(_ *msgpack.Encoder, v reflect.Value) ([]byte, error) {
+		id := v.Interface().(primitive.ObjectID)
+		return id[:], nil
+	})
+	msgpack.RegisterExtDecoder(msgpackObjectIDExt, primitive.ObjectID{}, func(d *msgpack.Decoder, v reflect.Value, n int) error {
+		var id primitive.ObjectID
+		if n != len(id) {
+			return fmt.Errorf("invalid ObjectID length %d", n)
+		}
+		if err := d.ReadFull(id[:]); err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(id))
+		return nil
+	})
+	msgpack.RegisterExtEncoder(msgpackDateTimeExt, primitive.DateTime(0), func(_ *msgpack.Encoder, v reflect.Value) ([]byte, error) {
+		b := make([]byte, 8)
+		binary.BigEndian.PutUint64(b, uint64(v.Interface().(primitive.DateTime)))
+		return b, nil
+	})
+	msgpack.RegisterExtDecoder(msgpackDateTimeExt, primitive.DateTime(0), func(d *msgpack.Decoder, v reflect.Value, n int) error {
+		if n != 8 {
+			return fmt.Errorf("invalid date length %d", n)
+		}
+		b := make([]byte, 8)
+		if err := d.ReadFull(b); err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(primitive.DateTime(binary.BigEndian.Uint64(b))))
+		return nil
+	})
+}
+
+// codecs maps each open database that doesn't store BSON to its
+// codec, so that documents can be converted given only a transaction.
+var codecs sync.Map // *bolt.DB -> Codec
+
+// storedCodec returns the codec of tx's database, or nil if its
+// documents are stored as BSON.
+func storedCodec(tx *bolt.Tx) Codec {
+	if c, ok := codecs.Load(tx.DB()); ok {
+		return c.(Codec)
+	}
+	return nil
+}
+
+// encodeDocument converts a document's BSON into the bytes stored
+// in the collection's bucket.
+func encodeDocument(tx *bolt.Tx, data []byte) ([]byte, error) {
+	codec := storedCodec(tx)
+	if codec == nil {
+		return data, nil
+	}
+	var doc map[string]interface{}
+	if err := bson.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	return codec.Marshal(doc)
+}
+
+// decodeDocument converts a document stored in a collection's bucket
+// into BSON, and returns both the BSON and the decoded document.
+func decodeDocument(tx *bolt.Tx, v []byte) ([]byte, map[string]interface{}, error) {
+	data := v
+	if codec := storedCodec(tx); codec != nil {
+		var m map[string]interface{}
+		if err := codec.Unmarshal(v, &m); err != nil {
+			return nil, nil, err
+		}
+		var err error
+		if data, err = bson.Marshal(m); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	var doc map[string]interface{}
+	if err := bson.Unmarshal(data, &doc); err != nil {
+		return nil, nil, err
+	}
+	return data, doc, nil
+}
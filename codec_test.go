@@ -0,0 +1,89 @@
+package mingodb_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/korrbit/mingodb"
+	"github.com/korrbit/mingodb/testutil"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestCodecs(t *testing.T) {
+	type address struct {
+		City string `bson:"city"`
+	}
+	type doc struct {
+		ID      primitive.ObjectID `bson:"_id"`
+		Name    string             `bson:"name"`
+		Age     int32              `bson:"age"`
+		Score   float64            `bson:"score"`
+		At      time.Time          `bson:"at"`
+		Tags    []string           `bson:"tags"`
+		Address address            `bson:"address"`
+	}
+	ctx := context.Background()
+	in := doc{
+		ID:      primitive.NewObjectID(),
+		Name:    "Alice",
+		Age:     30,
+		Score:   1.5,
+		At:      time.Date(2024, 1, 2, 3, 4, 5, 6e6, time.UTC),
+		Tags:    []string{"a", "b"},
+		Address: address{City: "Paris"},
+	}
+
+	for name, codec := range map[string]mingodb.Codec{
+		"bson":    mingodb.BSONCodec{},
+		"json":    mingodb.JSONCodec{},
+		"msgpack": mingodb.MsgpackCodec{},
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "test.db")
+			db, err := mingodb.OpenWithOptions(path, &mingodb.OpenOptions{Codec: codec})
+			if err != nil {
+				t.Fatalf("OpenWithOptions: %v", err)
+			}
+			c := db.CollectionMust("people")
+			if _, err := c.CreateIndex(ctx, "name"); err != nil {
+				t.Fatalf("CreateIndex: %v", err)
+			}
+			if _, err := c.InsertOne(ctx, in); err != nil {
+				t.Fatalf("InsertOne: %v", err)
+			}
+			if err := db.Close(); err != nil {
+				t.Fatalf("Close: %v", err)
+			}
+
+			if name == "json" {
+				data, err := os.ReadFile(path)
+				if err != nil {
+					t.Fatalf("ReadFile: %v", err)
+				}
+				if !bytes.Contains(data, []byte(`"name":"Alice"`)) {
+					t.Error("database file doesn't contain the document as JSON")
+				}
+			}
+
+			db, err = mingodb.OpenWithOptions(path, &mingodb.OpenOptions{Codec: codec})
+			if err != nil {
+				t.Fatalf("OpenWithOptions: %v", err)
+			}
+			defer db.Close()
+			c = db.CollectionMust("people")
+			var out doc
+			if err := c.GetByIDInto(ctx, in.ID, &out); err != nil {
+				t.Fatalf("GetByIDInto: %v", err)
+			}
+			if !reflect.DeepEqual(out, in) {
+				t.Errorf("got %+v, expected %+v", out, in)
+			}
+			testutil.AssertDocumentExists(t, c, map[string]interface{}{"name": "Alice", "at": in.At, "address.city": "Paris"})
+		})
+	}
+}
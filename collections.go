@@ -0,0 +1,238 @@
+package mingodb
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// isInternalBucket reports whether a top-level bucket holds the
+// database's own data, such as indexes, rather than a collection.
+// Collections can't use these names (see Database.Collection).
+func isInternalBucket(name string) bool {
+	if name == indexesBucket || name == indexEntriesBucket {
+		return true
+	}
+	for _, prefix := range []string{"__seq_", "__schema_", "__meta_", "__history_"} {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// ListCollections returns the names of the collections in the
+// database, in sorted order.
+func (db *Database) ListCollections(ctx context.Context) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	names := []string{}
+	err := db.view(func(tx *bolt.Tx) error {
+		return tx.ForEach(func(name []byte, _ *bolt.Bucket) error {
+			if !isInternalBucket(string(name)) {
+				names = append(names, string(name))
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return names, nil
+}
+
+// CollectionExists reports whether the database has a collection
+// with the specified name.
+func (db *Database) CollectionExists(ctx context.Context, name string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	if name == "" || isInternalBucket(name) {
+		return false, nil
+	}
+
+	var exists bool
+	err := db.view(func(tx *bolt.Tx) error {
+		exists = tx.Bucket([]byte(name)) != nil
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	return exists, nil
+}
+
+// RenameCollection renames a collection, along with its indexes.
+// Returns ErrCollectionAlreadyExists if a collection named newName
+// already exists.
+func (db *Database) RenameCollection(ctx context.Context, oldName, newName string) error {
+	if newName == "" {
+		return ErrEmptyBucketName
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return db.update(func(tx *bolt.Tx) error {
+		if err := copyCollection(ctx, tx, oldName, newName, true); err != nil {
+			return err
+		}
+		old := &Collection{db: db, name: oldName}
+		if err := old.dropIndexes(tx); err != nil {
+			return err
+		}
+		if err := dropSequence(tx, oldName); err != nil {
+			return err
+		}
+		if err := dropSchema(tx, oldName); err != nil {
+			return err
+		}
+		if err := dropMeta(tx, oldName); err != nil {
+			return err
+		}
+		if err := moveHistory(ctx, tx, oldName, newName); err != nil {
+			return err
+		}
+		return tx.DeleteBucket([]byte(oldName))
+	})
+}
+
+// CopyCollection copies the documents in the src collection into a
+// new collection named dst, in a single transaction. Returns
+// ErrCollectionAlreadyExists if dst already exists.
+//
+// The copy has no indexes unless CopyOptions.IncludeIndexes is set.
+func (db *Database) CopyCollection(ctx context.Context, src, dst string, opts ...CopyOptions) error {
+	if dst == "" {
+		return ErrEmptyBucketName
+	}
+	o := mergeCopyOptions(opts)
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return db.update(func(tx *bolt.Tx) error {
+		return copyCollection(ctx, tx, src, dst, o.IncludeIndexes)
+	})
+}
+
+// copyCollection copies the src collection's documents and, if
+// indexes is true, its indexes into a new collection named dst.
+func copyCollection(ctx context.Context, tx *bolt.Tx, src, dst string, indexes bool) error {
+	if isInternalBucket(src) || tx.Bucket([]byte(src)) == nil {
+		return fmt.Errorf("%s: %w", src, ErrCollectionNotFound)
+	}
+	if isInternalBucket(dst) || tx.Bucket([]byte(dst)) != nil {
+		return fmt.Errorf("%s: %w", dst, ErrCollectionAlreadyExists)
+	}
+	if err := copyBucket(ctx, tx, src, dst); err != nil {
+		return err
+	}
+	if err := copySequence(tx, src, dst); err != nil {
+		return err
+	}
+	if err := copySchema(tx, src, dst); err != nil {
+		return err
+	}
+	if err := copyMeta(tx, src, dst); err != nil {
+		return err
+	}
+
+	if !indexes {
+		return nil
+	}
+
+	// Copy the index entries, then the definitions.
+	srcIndexes, err := loadIndexes(tx, src)
+	if err != nil || len(srcIndexes) == 0 {
+		return err
+	}
+	entries, err := createIndexEntries(tx, dst)
+	if err != nil {
+		return err
+	}
+	for _, idx := range srcIndexes {
+		to, err := entries.CreateBucket([]byte(idx.Name))
+		if err != nil {
+			return err
+		}
+		if err := copyKeys(ctx, idx.b, to); err != nil {
+			return err
+		}
+	}
+	meta, err := (&Collection{name: dst}).indexMeta(tx)
+	if err != nil {
+		return err
+	}
+	return tx.Bucket([]byte(indexesBucket)).Bucket([]byte(src)).ForEach(func(k, v []byte) error {
+		return meta.Put(append([]byte(nil), k...), append([]byte(nil), v...))
+	})
+}
+
+// copyBucket creates the top-level bucket dst and copies every key
+// in the top-level bucket src into it.
+func copyBucket(ctx context.Context, tx *bolt.Tx, src, dst string) error {
+	to, err := tx.CreateBucket([]byte(dst))
+	if err != nil {
+		return err
+	}
+	return copyKeys(ctx, tx.Bucket([]byte(src)), to)
+}
+
+// copyKeys copies every key in the bucket from into the bucket to.
+func copyKeys(ctx context.Context, from, to *bolt.Bucket) error {
+	return from.ForEach(func(k, v []byte) error {
+		// Has the operation been cancelled?
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		return to.Put(append([]byte(nil), k...), append([]byte(nil), v...))
+	})
+}
+
+// DropDatabase deletes every collection in the database, along with
+// their indexes, in a single transaction. The database stays open.
+func (db *Database) DropDatabase(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return db.update(func(tx *bolt.Tx) error {
+		// Buckets can't be deleted while iterating over them, so
+		// collect their names first.
+		var names [][]byte
+		err := tx.ForEach(func(name []byte, _ *bolt.Bucket) error {
+			names = append(names, append([]byte(nil), name...))
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, name := range names {
+			if err := tx.DeleteBucket(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// DropAndClose closes the database and deletes its file.
+func (db *Database) DropAndClose(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := db.Close(); err != nil {
+		return err
+	}
+	// Close has already deleted an in-memory database's file.
+	if db.memory {
+		return nil
+	}
+	return os.Remove(db.Path)
+}
@@ -0,0 +1,171 @@
+package mingodb_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/korrbit/mingodb"
+	"github.com/korrbit/mingodb/testutil"
+)
+
+func TestListCollectionsHidesInternalBuckets(t *testing.T) {
+	ctx := context.Background()
+	db := testutil.NewTestDB(t)
+	users := db.CollectionMust("users")
+	if _, err := users.CreateIndex(ctx, "email"); err != nil {
+		t.Fatalf("CreateIndex: %v", err)
+	}
+	users.EnableVersioning()
+	testutil.SeedCollection(t, users, map[string]interface{}{"_id": 1, "email": "a@example.com"})
+	if _, err := users.UpdateOne(ctx, map[string]interface{}{"_id": 1}, map[string]interface{}{"$set": map[string]interface{}{"email": "b@example.com"}}); err != nil {
+		t.Fatalf("UpdateOne: %v", err)
+	}
+	db.CollectionMust("posts")
+
+	names, err := db.ListCollections(ctx)
+	if err != nil {
+		t.Fatalf("ListCollections: %v", err)
+	}
+	if expected := []string{"posts", "users"}; !reflect.DeepEqual(names, expected) {
+		t.Errorf("got collections %v, expected %v", names, expected)
+	}
+}
+
+func TestReservedCollectionNames(t *testing.T) {
+	ctx := context.Background()
+	db := testutil.NewTestDB(t)
+	for _, name := range []string{"__indexes", "__idx", "__seq_x", "__schema_x", "__meta_x", "__history_x"} {
+		if _, err := db.Collection(name); !errors.Is(err, mingodb.ErrInvalidCollectionName) {
+			t.Errorf("Collection(%q) returned %v, expected ErrInvalidCollectionName", name, err)
+		}
+	}
+
+	tx, err := db.BeginTx(ctx)
+	if err != nil {
+		t.Fatalf("BeginTx: %v", err)
+	}
+	defer tx.Rollback()
+	if _, err := tx.Collection("__indexes"); !errors.Is(err, mingodb.ErrInvalidCollectionName) {
+		t.Errorf("Transaction.Collection returned %v, expected ErrInvalidCollectionName", err)
+	}
+}
+
+func TestRenameCollection(t *testing.T) {
+	ctx := context.Background()
+	db := testutil.NewTestDB(t)
+	users := db.CollectionMust("users")
+	if _, err := users.CreateIndex(ctx, "email", mingodb.IndexOptions{Unique: true}); err != nil {
+		t.Fatalf("CreateIndex: %v", err)
+	}
+	testutil.SeedCollection(t, users, map[string]interface{}{"_id": 1, "email": "a@example.com"})
+	db.CollectionMust("taken")
+
+	if err := db.RenameCollection(ctx, "users", "taken"); !errors.Is(err, mingodb.ErrCollectionAlreadyExists) {
+		t.Errorf("RenameCollection returned %v, expected ErrCollectionAlreadyExists", err)
+	}
+	if err := db.RenameCollection(ctx, "missing", "other"); !errors.Is(err, mingodb.ErrCollectionNotFound) {
+		t.Errorf("RenameCollection returned %v, expected ErrCollectionNotFound", err)
+	}
+	if err := db.RenameCollection(ctx, "users", "people"); err != nil {
+		t.Fatalf("RenameCollection: %v", err)
+	}
+
+	names, err := db.ListCollections(ctx)
+	if err != nil {
+		t.Fatalf("ListCollections: %v", err)
+	}
+	if expected := []string{"people", "taken"}; !reflect.DeepEqual(names, expected) {
+		t.Errorf("got collections %v, expected %v", names, expected)
+	}
+	people := db.CollectionMust("people")
+	testutil.AssertDocumentExists(t, people, map[string]interface{}{"_id": 1, "email": "a@example.com"})
+	if _, err := people.InsertOne(ctx, map[string]interface{}{"email": "a@example.com"}); !errors.Is(err, mingodb.ErrDuplicateKey) {
+		t.Errorf("InsertOne returned %v, expected the unique index to be renamed too", err)
+	}
+}
+
+func TestDropDatabase(t *testing.T) {
+	ctx := context.Background()
+	db := testutil.NewTestDB(t)
+	users := db.CollectionMust("users")
+	if _, err := users.CreateIndex(ctx, "email"); err != nil {
+		t.Fatalf("CreateIndex: %v", err)
+	}
+	testutil.SeedCollection(t, users, map[string]interface{}{"_id": 1, "email": "a@example.com"})
+	db.CollectionMust("posts")
+
+	if err := db.DropDatabase(ctx); err != nil {
+		t.Fatalf("DropDatabase: %v", err)
+	}
+	names, err := db.ListCollections(ctx)
+	if err != nil {
+		t.Fatalf("ListCollections: %v", err)
+	}
+	if len(names) != 0 {
+		t.Errorf("got collections %v, expected none", names)
+	}
+
+	// The database is still open, and the collections start afresh.
+	users = db.CollectionMust("users")
+	testutil.SeedCollection(t, users, map[string]interface{}{"_id": 1})
+	indexes, err := users.ListIndexes(ctx)
+	if err != nil {
+		t.Fatalf("ListIndexes: %v", err)
+	}
+	if len(indexes) != 0 {
+		t.Errorf("got indexes %v, expected none", indexes)
+	}
+}
+
+func TestDropAndClose(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	db, err := mingodb.Open(path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if err := db.DropAndClose(context.Background()); err != nil {
+		t.Fatalf("DropAndClose: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("database file still exists: %v", err)
+	}
+}
+
+func TestCopyCollection(t *testing.T) {
+	ctx := context.Background()
+	db := testutil.NewTestDB(t)
+	src := db.CollectionMust("src")
+	if _, err := src.CreateIndex(ctx, "n"); err != nil {
+		t.Fatalf("CreateIndex: %v", err)
+	}
+	testutil.SeedCollection(t, src,
+		map[string]interface{}{"_id": 1, "n": 1},
+		map[string]interface{}{"_id": 2, "n": 2},
+	)
+
+	if err := db.CopyCollection(ctx, "src", "dst"); err != nil {
+		t.Fatalf("CopyCollection: %v", err)
+	}
+	if err := db.CopyCollection(ctx, "src", "dst"); !errors.Is(err, mingodb.ErrCollectionAlreadyExists) {
+		t.Errorf("CopyCollection returned %v, expected ErrCollectionAlreadyExists", err)
+	}
+
+	// The copy is independent of the source, and has no indexes.
+	dst := db.CollectionMust("dst")
+	if _, err := dst.DeleteOne(ctx, 1); err != nil {
+		t.Fatalf("DeleteOne: %v", err)
+	}
+	testutil.AssertDocumentCount(t, src, nil, 2)
+	testutil.AssertDocumentCount(t, dst, nil, 1)
+	indexes, err := dst.ListIndexes(ctx)
+	if err != nil {
+		t.Fatalf("ListIndexes: %v", err)
+	}
+	if len(indexes) != 0 {
+		t.Errorf("got indexes %v, expected none", indexes)
+	}
+}
@@ -0,0 +1,204 @@
+package mingodb
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// utf8BOM is the byte order mark that some programs, such as Excel,
+// write at the start of UTF-8 CSV files.
+const utf8BOM = "\ufeff"
+
+// ExportCSV writes the documents returned by Find with the options
+// to w as CSV: a header row of the fields, then a row for each
+// document with the fields' values in the same order. Fields can use
+// dot-notation, such as "address.city". Missing and null values are
+// written as empty cells, and embedded documents and arrays as JSON.
+func (c *Collection) ExportCSV(ctx context.Context, w io.Writer, fields []string, opts ...FindOptions) error {
+	res, err := c.Find(ctx, nil, opts...)
+	if err != nil {
+		return err
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(fields); err != nil {
+		return err
+	}
+	row := make([]string, len(fields))
+	for _, data := range res.data {
+		// Has the export been cancelled?
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		var doc map[string]interface{}
+		if err := bson.Unmarshal(data, &doc); err != nil {
+			return err
+		}
+		for i, f := range fields {
+			v, _ := lookupPath(doc, f)
+			if row[i], err = csvCell(v); err != nil {
+				return fmt.Errorf("field %q: %w", f, err)
+			}
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// csvCell formats a value as a CSV cell.
+func csvCell(v interface{}) (string, error) {
+	switch v := jsonValue(v).(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int32:
+		return strconv.FormatInt(int64(v), 10), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64), nil
+	default:
+		b, err := json.Marshal(v)
+		return string(b), err
+	}
+}
+
+// ImportCSV inserts a document for each row of the CSV read from r,
+// with InsertOne, and returns the number of documents inserted. The
+// first row is the header, whose columns are the documents' fields;
+// a column can use dot-notation, such as "address.city", to set an
+// embedded document's field. Values are strings unless
+// CSVImportOptions.Schema gives their column a type.
+//
+// The CSV must be UTF-8, optionally starting with a byte order mark.
+// If a row can't be read or inserted, ImportCSV stops and returns the
+// number of documents already inserted, along with the error.
+func (c *Collection) ImportCSV(ctx context.Context, r io.Reader, opts ...CSVImportOptions) (int, error) {
+	o := mergeCSVImportOptions(opts)
+	for col, typ := range o.Schema {
+		if !csvTypes[typ] {
+			return 0, fmt.Errorf("unknown type %q for column %q", typ, col)
+		}
+	}
+
+	cr := csv.NewReader(r)
+	header, err := cr.Read()
+	if err == io.EOF {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+	if len(header) > 0 {
+		header[0] = strings.TrimPrefix(header[0], utf8BOM)
+	}
+	for _, col := range header {
+		if !utf8.ValidString(col) {
+			return 0, fmt.Errorf("%w: row 1: invalid UTF-8", ErrInvalidDocument)
+		}
+	}
+
+	var n int
+	for line := 2; ; line++ {
+		// Has the import been cancelled?
+		if err := ctx.Err(); err != nil {
+			return n, err
+		}
+
+		record, err := cr.Read()
+		if err == io.EOF {
+			return n, nil
+		} else if err != nil {
+			return n, err
+		}
+		doc, err := csvDocument(header, record, o.Schema)
+		if err != nil {
+			return n, fmt.Errorf("row %d: %w", line, err)
+		}
+		if _, err := c.InsertOne(ctx, doc); err != nil {
+			return n, fmt.Errorf("row %d: %w", line, err)
+		}
+		n++
+	}
+}
+
+// csvDocument builds the document for a CSV row.
+func csvDocument(header, record []string, schema map[string]string) (map[string]interface{}, error) {
+	doc := make(map[string]interface{}, len(header))
+	for i, col := range header {
+		s := record[i]
+		if !utf8.ValidString(s) {
+			return nil, fmt.Errorf("%w: column %q: invalid UTF-8", ErrInvalidDocument, col)
+		}
+		typ := schema[col]
+		if s == "" && typ != "" && typ != "string" {
+			continue
+		}
+		v, err := csvValue(typ, s)
+		if err != nil {
+			return nil, fmt.Errorf("%w: column %q: %v", ErrInvalidDocument, col, err)
+		}
+		if err := setPath(doc, col, v); err != nil {
+			return nil, err
+		}
+	}
+	return doc, nil
+}
+
+// csvTypes are the types of CSVImportOptions.Schema.
+var csvTypes = map[string]bool{
+	"string":   true,
+	"int":      true,
+	"double":   true,
+	"bool":     true,
+	"date":     true,
+	"objectId": true,
+}
+
+// csvValue converts a CSV cell to the type, which is one of the types
+// of CSVImportOptions.Schema or "", which is the same as "string".
+func csvValue(typ, s string) (interface{}, error) {
+	switch typ {
+	case "", "string":
+		return s, nil
+	case "int":
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		if n >= math.MinInt32 && n <= math.MaxInt32 {
+			return int32(n), nil
+		}
+		return n, nil
+	case "double":
+		return strconv.ParseFloat(s, 64)
+	case "bool":
+		return strconv.ParseBool(s)
+	case "date":
+		t, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return nil, err
+		}
+		return primitive.NewDateTimeFromTime(t), nil
+	case "objectId":
+		return primitive.ObjectIDFromHex(s)
+	}
+	return nil, fmt.Errorf("unknown type %q", typ)
+}
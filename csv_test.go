@@ -0,0 +1,92 @@
+package mingodb_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/korrbit/mingodb"
+	"github.com/korrbit/mingodb/testutil"
+)
+
+func TestExportCSV(t *testing.T) {
+	c := testutil.NewTestDB(t).CollectionMust("items")
+	testutil.SeedCollection(t, c,
+		map[string]interface{}{"_id": 1, "name": "Smith, \"Al\"", "n": 1.5, "address": map[string]interface{}{"city": "Zürich"}},
+		map[string]interface{}{"_id": 2, "name": "Bob", "tags": []interface{}{"a", "b"}, "ok": true},
+	)
+
+	var buf bytes.Buffer
+	fields := []string{"_id", "name", "n", "address.city", "tags", "ok"}
+	if err := c.ExportCSV(context.Background(), &buf, fields, mingodb.FindOptions{Sort: []mingodb.SortField{{Field: "_id", Dir: 1}}}); err != nil {
+		t.Fatalf("ExportCSV: %v", err)
+	}
+	expected := "_id,name,n,address.city,tags,ok\n" +
+		"1,\"Smith, \"\"Al\"\"\",1.5,Zürich,,\n" +
+		"2,Bob,,,\"[\"\"a\"\",\"\"b\"\"]\",true\n"
+	if buf.String() != expected {
+		t.Errorf("got %q, expected %q", buf.String(), expected)
+	}
+}
+
+func TestImportCSV(t *testing.T) {
+	ctx := context.Background()
+	c := testutil.NewTestDB(t).CollectionMust("items")
+	input := "\ufeffname,age,score,active,joined,address.city,code\n" +
+		"\"Smith, \"\"Al\"\"\",30,1.5,true,2024-01-02T03:04:05Z,Zürich,007\n" +
+		"Bob,,,false,,,\n"
+	n, err := c.ImportCSV(ctx, strings.NewReader(input), mingodb.CSVImportOptions{Schema: map[string]string{
+		"age":    "int",
+		"score":  "double",
+		"active": "bool",
+		"joined": "date",
+	}})
+	if err != nil {
+		t.Fatalf("ImportCSV: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("imported %d documents, expected 2", n)
+	}
+	testutil.AssertDocumentExists(t, c, map[string]interface{}{
+		"name":         "Smith, \"Al\"",
+		"age":          30,
+		"score":        1.5,
+		"active":       true,
+		"joined":       time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		"address.city": "Zürich",
+		"code":         "007",
+	})
+	// Empty typed values are left out, but empty strings are kept.
+	testutil.AssertDocumentExists(t, c, map[string]interface{}{
+		"name":   "Bob",
+		"age":    map[string]interface{}{"$exists": false},
+		"active": false,
+		"code":   "",
+	})
+}
+
+func TestImportCSVErrors(t *testing.T) {
+	ctx := context.Background()
+	c := testutil.NewTestDB(t).CollectionMust("items")
+
+	if _, err := c.ImportCSV(ctx, strings.NewReader("a\n1\n"), mingodb.CSVImportOptions{Schema: map[string]string{"a": "uuid"}}); err == nil {
+		t.Error("ImportCSV with an unknown type succeeded")
+	}
+
+	// The rows before the invalid one are inserted.
+	n, err := c.ImportCSV(ctx, strings.NewReader("a\n1\nx\n2\n"), mingodb.CSVImportOptions{Schema: map[string]string{"a": "int"}})
+	if !errors.Is(err, mingodb.ErrInvalidDocument) || !strings.Contains(err.Error(), "row 3") {
+		t.Errorf("ImportCSV returned %v, expected ErrInvalidDocument for row 3", err)
+	}
+	if n != 1 {
+		t.Errorf("imported %d documents, expected 1", n)
+	}
+	testutil.AssertDocumentCount(t, c, nil, 1)
+
+	if _, err := c.ImportCSV(ctx, strings.NewReader("a\n\xff\n")); !errors.Is(err, mingodb.ErrInvalidDocument) {
+		t.Errorf("ImportCSV returned %v, expected ErrInvalidDocument for invalid UTF-8", err)
+	}
+}
@@ -3,8 +3,38 @@ package mingodb
 import "errors"
 
 var (
-	ErrOpeningDatabase = errors.New("unable to open the database")
-	ErrEmptyBucketName = errors.New("bucket name cannot be empty")
-	ErrCreatingBucket  = errors.New("unable to create bucket")
-	ErrInvalidType     = errors.New("invalid type, expected struct/map")
+	ErrOpeningDatabase   = errors.New("unable to open the database")
+	ErrEmptyBucketName   = errors.New("bucket name cannot be empty")
+	ErrCreatingBucket    = errors.New("unable to create bucket")
+	ErrInvalidType       = errors.New("invalid type, expected struct/map")
+	ErrNoCurrentDocument = errors.New("cursor has no current document")
+	ErrInvalidUpdate     = errors.New("invalid update document")
+	ErrInvalidProjection = errors.New("invalid projection, cannot mix inclusion and exclusion")
+	ErrBulkWrite         = errors.New("bulk write failed")
+	ErrTypeMismatch      = errors.New("type mismatch")
+	ErrInvalidRegex      = errors.New("invalid regular expression")
+	ErrInvalidPipeline   = errors.New("invalid aggregation pipeline")
+	ErrInvalidIndex      = errors.New("invalid index")
+	ErrIndexExists       = errors.New("index already exists with different options")
+	ErrIndexNotFound     = errors.New("index not found")
+	ErrTxDone            = errors.New("transaction has already been committed or rolled back")
+	ErrTxAborted         = errors.New("transaction aborted")
+	ErrVersionConflict   = errors.New("document version conflict")
+	ErrPathTraversal     = errors.New("cannot traverse into a non-document value")
+	ErrInvalidSchema     = errors.New("invalid JSON schema")
+	ErrInvalidBackup     = errors.New("invalid backup")
+	ErrInvalidMigration  = errors.New("invalid migration")
+
+	ErrNoDocuments             = errors.New("no documents in result")
+	ErrDuplicateKey            = errors.New("duplicate key")
+	ErrInvalidDocument         = errors.New("invalid document")
+	ErrInvalidFilter           = errors.New("invalid filter")
+	ErrCollectionNotFound      = errors.New("collection not found")
+	ErrCollectionAlreadyExists = errors.New("collection already exists")
+	ErrInvalidCollectionName   = errors.New("collection name is reserved")
+	ErrDatabaseClosed          = errors.New("database is closed")
+	ErrReadOnly                = errors.New("database is read-only")
+	ErrValidationFailed        = errors.New("document validation failed")
+	ErrSchemaValidation        = errors.New("document does not match the collection's JSON schema")
+	ErrInvalidReplaceRoot      = errors.New("replacement root must be a document")
 )
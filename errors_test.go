@@ -0,0 +1,46 @@
+package mingodb_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/korrbit/mingodb"
+)
+
+func TestErrNoDocuments(t *testing.T) {
+	c := people(t)
+	if _, err := c.GetByID(context.Background(), 4); !errors.Is(err, mingodb.ErrNoDocuments) {
+		t.Errorf("GetByID returned %v, expected ErrNoDocuments", err)
+	}
+}
+
+func TestErrDatabaseClosed(t *testing.T) {
+	ctx := context.Background()
+	db, err := mingodb.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	c := db.CollectionMust("users")
+	if err := db.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	if _, err := c.InsertOne(ctx, map[string]interface{}{"_id": 1}); !errors.Is(err, mingodb.ErrDatabaseClosed) {
+		t.Errorf("InsertOne returned %v, expected ErrDatabaseClosed", err)
+	}
+	if _, err := c.Find(ctx, nil); !errors.Is(err, mingodb.ErrDatabaseClosed) {
+		t.Errorf("Find returned %v, expected ErrDatabaseClosed", err)
+	}
+}
+
+func TestErrCollectionNotFound(t *testing.T) {
+	c := people(t)
+	if err := c.Drop(); err != nil {
+		t.Fatalf("Drop: %v", err)
+	}
+	if err := c.Drop(); !errors.Is(err, mingodb.ErrCollectionNotFound) {
+		t.Errorf("Drop returned %v, expected ErrCollectionNotFound", err)
+	}
+}
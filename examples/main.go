@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
 	mingodb "github.com/korrbit/mingodb"
@@ -8,6 +9,7 @@ import (
 
 func main() {
 	dbPath := "./test.db"
+	ctx := context.Background()
 
 	db, err := mingodb.Open(dbPath)
 	if err != nil {
@@ -22,7 +24,7 @@ func main() {
 	}
 
 	// Insert a new document.
-	id, err := c.InsertOne(map[string]interface{}{
+	id, err := c.InsertOne(ctx, map[string]interface{}{
 		"name": "John Doe",
 		"age":  30,
 	})
@@ -32,7 +34,7 @@ func main() {
 	log.Println("Inserted document with id:", id)
 
 	// Retrieve the inserted document.
-	doc, err := c.GetByID(id)
+	doc, err := c.GetByID(ctx, id)
 	if err != nil {
 		log.Panic(err)
 	}
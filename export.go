@@ -0,0 +1,200 @@
+package mingodb
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	bolt "go.etcd.io/bbolt"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// maxImportDocumentSize is the largest BSON document Import reads,
+// which is MongoDB's limit.
+const maxImportDocumentSize = 16 * 1024 * 1024
+
+// Export writes the collection's documents to w, in _id order, and
+// returns the number of documents written. ExportOptions choose the
+// format and which documents are exported; by default every document
+// is written as a line of JSON. The documents can be read back with
+// Import.
+func (c *Collection) Export(ctx context.Context, w io.Writer, opts ...ExportOptions) (_ int, err error) {
+	o := mergeExportOptions(opts)
+	ctx, end := c.startOperation(ctx, "export", o.Filter, nil)
+	defer func() { end(err) }()
+	if o.Format != ExportJSON && o.Format != ExportBSON {
+		return 0, fmt.Errorf("unknown export format %d", o.Format)
+	}
+
+	res, err := c.Find(ctx, o.Filter)
+	if err != nil {
+		return 0, err
+	}
+	bw := bufio.NewWriter(w)
+	var n int
+	for _, data := range res.data {
+		// Has the export been cancelled?
+		if err := ctx.Err(); err != nil {
+			return n, err
+		}
+
+		// Was the document created before Since?
+		if !o.Since.IsZero() {
+			id, ok := bson.Raw(data).Lookup("_id").ObjectIDOK()
+			if !ok || !id.Timestamp().After(o.Since) {
+				continue
+			}
+		}
+
+		if o.Format == ExportBSON {
+			_, err = bw.Write(data)
+		} else {
+			var line []byte
+			if line, err = bson.MarshalExtJSON(bson.Raw(data), false, false); err == nil {
+				_, err = bw.Write(append(line, '\n'))
+			}
+		}
+		if err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, bw.Flush()
+}
+
+// Import inserts the documents written by Export into the collection
+// and returns the number of documents inserted. ImportOptions choose
+// the format, which must be the format the documents were exported in,
+// and whether documents already in the collection are replaced.
+//
+// The documents are read before any is inserted, then inserted in a
+// single transaction, so if any document is invalid, none of the
+// documents are inserted. The collection's middleware is called as it
+// is for InsertMany.
+func (c *Collection) Import(ctx context.Context, r io.Reader, opts ...ImportOptions) (_ int, err error) {
+	o := mergeImportOptions(opts)
+	ctx, end := c.startOperation(ctx, "import", nil, nil)
+	defer func() { end(err) }()
+
+	var docs []interface{}
+	switch o.Format {
+	case ExportJSON:
+		docs, err = readJSONDocuments(r)
+	case ExportBSON:
+		docs, err = readBSONDocuments(r)
+	default:
+		err = fmt.Errorf("unknown export format %d", o.Format)
+	}
+	if err != nil {
+		return 0, err
+	}
+	for i, doc := range docs {
+		if err := c.beforeInsert(ctx, doc); err != nil {
+			return 0, fmt.Errorf("document %d: %w", i, err)
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	ids := make([]InsertID, len(docs))
+	err = c.write(func(tx *bolt.Tx) error {
+		w, err := c.writeTx(ctx, tx)
+		if err != nil {
+			return err
+		}
+		for i, doc := range docs {
+			// Has the import been cancelled?
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			id, bid, bdoc, err := prepareDocument(doc, w.newID, w.key)
+			if err != nil {
+				return fmt.Errorf("document %d: %w", i, err)
+			}
+
+			// Is it replacing a document?
+			if o.Replace && w.b.Get(bid) != nil {
+				_, err = w.replace(bid, bdoc)
+			} else {
+				err = w.insert(id, bid, bdoc)
+			}
+			if err != nil {
+				return fmt.Errorf("document %d: %w", i, err)
+			}
+			ids[i] = id
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	for i, doc := range docs {
+		c.afterInsert(ctx, doc, ids[i])
+	}
+	return len(docs), nil
+}
+
+// readJSONDocuments reads documents written as lines of Extended
+// JSON. Blank lines are skipped.
+func readJSONDocuments(r io.Reader) ([]interface{}, error) {
+	var docs []interface{}
+	br := bufio.NewReader(r)
+	for line := 1; ; line++ {
+		b, err := br.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		if b = bytes.TrimSpace(b); len(b) > 0 {
+			var m map[string]interface{}
+			if err := bson.UnmarshalExtJSON(b, false, &m); err != nil {
+				return nil, fmt.Errorf("%w: line %d: %v", ErrInvalidDocument, line, err)
+			}
+			docs = append(docs, m)
+		}
+		if err == io.EOF {
+			return docs, nil
+		}
+	}
+}
+
+// readBSONDocuments reads documents written as BSON one after the
+// other.
+func readBSONDocuments(r io.Reader) ([]interface{}, error) {
+	var docs []interface{}
+	br := bufio.NewReader(r)
+	for {
+		// Each document starts with its length, including the length
+		// itself.
+		var size [4]byte
+		if _, err := io.ReadFull(br, size[:]); err == io.EOF {
+			return docs, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("%w: document %d: %v", ErrInvalidDocument, len(docs), err)
+		}
+		n := binary.LittleEndian.Uint32(size[:])
+		if n < 5 || n > maxImportDocumentSize {
+			return nil, fmt.Errorf("%w: document %d has invalid length %d", ErrInvalidDocument, len(docs), n)
+		}
+		data := make([]byte, n)
+		copy(data, size[:])
+		if _, err := io.ReadFull(br, data[4:]); err != nil {
+			if errors.Is(err, io.EOF) {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, fmt.Errorf("%w: document %d: %v", ErrInvalidDocument, len(docs), err)
+		}
+
+		var m map[string]interface{}
+		if err := bson.Unmarshal(data, &m); err != nil {
+			return nil, fmt.Errorf("%w: document %d: %v", ErrInvalidDocument, len(docs), err)
+		}
+		docs = append(docs, m)
+	}
+}
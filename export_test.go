@@ -0,0 +1,115 @@
+package mingodb_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/korrbit/mingodb"
+	"github.com/korrbit/mingodb/testutil"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestExportAndImport(t *testing.T) {
+	ctx := context.Background()
+	for _, format := range []mingodb.ExportFormat{mingodb.ExportJSON, mingodb.ExportBSON} {
+		c := people(t)
+		var buf bytes.Buffer
+		n, err := c.Export(ctx, &buf, mingodb.ExportOptions{
+			Format: format,
+			Filter: map[string]interface{}{"city": "Paris"},
+		})
+		if err != nil {
+			t.Fatalf("format %d: Export: %v", format, err)
+		}
+		if n != 2 {
+			t.Errorf("format %d: exported %d documents, expected 2", format, n)
+		}
+		if format == mingodb.ExportJSON && strings.Count(buf.String(), "\n") != 2 {
+			t.Errorf("exported %q, expected 2 lines", buf.String())
+		}
+
+		dst := c.Database().CollectionMust("copy")
+		if n, err = dst.Import(ctx, &buf, mingodb.ImportOptions{Format: format}); err != nil {
+			t.Fatalf("format %d: Import: %v", format, err)
+		}
+		if n != 2 {
+			t.Errorf("format %d: imported %d documents, expected 2", format, n)
+		}
+		if ids, expected := findIDs(t, dst, nil), []int32{1, 3}; !reflect.DeepEqual(ids, expected) {
+			t.Errorf("format %d: got _ids %v, expected %v", format, ids, expected)
+		}
+		testutil.AssertDocumentExists(t, dst, map[string]interface{}{"_id": 3, "name": "Carol", "age": 35, "city": "Paris"})
+	}
+}
+
+func TestExportSince(t *testing.T) {
+	ctx := context.Background()
+	since := time.Now().Add(-time.Hour)
+	older := primitive.NewObjectIDFromTimestamp(since.Add(-time.Hour))
+	newer := primitive.NewObjectIDFromTimestamp(since.Add(time.Minute))
+	c := testutil.NewTestDB(t).CollectionMust("items")
+	testutil.SeedCollection(t, c,
+		map[string]interface{}{"_id": older},
+		map[string]interface{}{"_id": newer},
+		map[string]interface{}{"_id": 1},
+	)
+
+	var buf bytes.Buffer
+	n, err := c.Export(ctx, &buf, mingodb.ExportOptions{Since: since})
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	if n != 1 || !strings.Contains(buf.String(), newer.Hex()) {
+		t.Errorf("exported %d documents %q, expected only %s", n, buf.String(), newer.Hex())
+	}
+}
+
+func TestImportReplace(t *testing.T) {
+	ctx := context.Background()
+	c := testutil.NewTestDB(t).CollectionMust("items")
+	testutil.SeedCollection(t, c, map[string]interface{}{"_id": 1, "n": 1})
+	input := `{"_id": 2, "n": 2}` + "\n\n" + `{"_id": 1, "n": 3}` + "\n"
+
+	// Without Replace, nothing is imported.
+	if _, err := c.Import(ctx, strings.NewReader(input)); !errors.Is(err, mingodb.ErrDuplicateKey) {
+		t.Errorf("Import returned %v, expected ErrDuplicateKey", err)
+	}
+	testutil.AssertDocumentCount(t, c, nil, 1)
+
+	n, err := c.Import(ctx, strings.NewReader(input), mingodb.ImportOptions{Replace: true})
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("imported %d documents, expected 2", n)
+	}
+	testutil.AssertDocumentExists(t, c, map[string]interface{}{"_id": 1, "n": 3})
+	testutil.AssertDocumentExists(t, c, map[string]interface{}{"_id": 2, "n": 2})
+}
+
+func TestImportInvalid(t *testing.T) {
+	ctx := context.Background()
+	c := testutil.NewTestDB(t).CollectionMust("items")
+	tests := []struct {
+		name  string
+		input string
+		opts  mingodb.ImportOptions
+	}{
+		{"JSON", "{\"_id\": 1}\nnot json\n", mingodb.ImportOptions{}},
+		{"BSONLength", "\x01\x00\x00\x00", mingodb.ImportOptions{Format: mingodb.ExportBSON}},
+		{"BSONTruncated", "\x10\x00\x00\x00\x00", mingodb.ImportOptions{Format: mingodb.ExportBSON}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := c.Import(ctx, strings.NewReader(tt.input), tt.opts); !errors.Is(err, mingodb.ErrInvalidDocument) {
+				t.Errorf("got %v, expected ErrInvalidDocument", err)
+			}
+			testutil.AssertDocumentCount(t, c, nil, 0)
+		})
+	}
+}
@@ -0,0 +1,317 @@
+package mingodb
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// evalExpression evaluates an aggregation expression against doc.
+//
+// A string that starts with "$" is a field path, such as "$address.city",
+// and evaluates to the value of that field. A document with a single
+// operator, such as {"$concat": ["$first", " ", "$last"]}, evaluates
+// to the operator's result (see evalExpressionOperator). The values
+// of any other document or an array are evaluated in turn. Anything
+// else is a literal value. The second return value is false if the
+// expression is a path to a field that doesn't exist.
+func evalExpression(doc map[string]interface{}, expr interface{}) (interface{}, bool, error) {
+	switch e := expr.(type) {
+	case string:
+		// Is it a field path?
+		if strings.HasPrefix(e, "$") {
+			v, ok := lookupPath(doc, e[1:])
+			return v, ok, nil
+		}
+		return e, true, nil
+	case map[string]interface{}:
+		// Is it an operator?
+		if len(e) == 1 {
+			for op, arg := range e {
+				if strings.HasPrefix(op, "$") {
+					v, err := evalExpressionOperator(doc, op, arg)
+					return v, true, err
+				}
+			}
+		}
+
+		out := make(map[string]interface{}, len(e))
+		for k, v := range e {
+			if strings.HasPrefix(k, "$") {
+				return nil, false, fmt.Errorf("%w: unknown expression operator %s", ErrInvalidPipeline, k)
+			}
+			val, ok, err := evalExpression(doc, v)
+			if err != nil {
+				return nil, false, err
+			}
+			if ok {
+				out[k] = val
+			}
+		}
+		return out, true, nil
+	case primitive.A:
+		out := make(primitive.A, len(e))
+		for i, v := range e {
+			val, _, err := evalExpression(doc, v)
+			if err != nil {
+				return nil, false, err
+			}
+			out[i] = val
+		}
+		return out, true, nil
+	}
+	return expr, true, nil
+}
+
+// evalExpressionOperator evaluates an expression operator against
+// doc. The supported operators are:
+//
+//	{"$literal": value}             value, without evaluating it
+//	{"$concat": [expr, ...]}        the strings joined together
+//	{"$add": [expr, ...]}           the sum of numbers, or a date plus milliseconds
+//	{"$subtract": [expr1, expr2]}   expr1 - expr2, for numbers and dates
+//	{"$multiply": [expr, ...]}      the product of numbers
+//	{"$divide": [expr1, expr2]}     expr1 / expr2, as a double
+//	{"$toLower": expr}              the string in lowercase
+//	{"$toUpper": expr}              the string in uppercase
+//	{"$toString": expr}             the value converted to a string
+//	{"$toInt": expr}                the value converted to an int32
+//	{"$mergeObjects": [expr, ...]}  the documents' fields, with later ones replacing earlier ones
+//
+// Apart from $toLower and $toUpper, which treat them as "", and
+// $mergeObjects, which ignores them, operators evaluate to null if any
+// of their arguments is null or missing.
+func evalExpressionOperator(doc map[string]interface{}, op string, arg interface{}) (interface{}, error) {
+	if op == "$literal" {
+		return arg, nil
+	}
+	args, err := evalOperands(doc, arg)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op {
+	case "$concat":
+		var sb strings.Builder
+		for _, a := range args {
+			if a == nil {
+				return nil, nil
+			}
+			s, ok := a.(string)
+			if !ok {
+				return nil, fmt.Errorf("%w: $concat only supports strings, not %T", ErrInvalidPipeline, a)
+			}
+			sb.WriteString(s)
+		}
+		return sb.String(), nil
+	case "$add":
+		return addValues(args)
+	case "$subtract":
+		if len(args) != 2 {
+			return nil, fmt.Errorf("%w: $subtract takes exactly 2 arguments", ErrInvalidPipeline)
+		}
+		return subtractValues(args[0], args[1])
+	case "$multiply":
+		var product interface{} = int32(1)
+		for _, a := range args {
+			if a == nil {
+				return nil, nil
+			}
+			if _, ok := toFloat(a); !ok {
+				return nil, fmt.Errorf("%w: $multiply only supports numbers, not %T", ErrInvalidPipeline, a)
+			}
+			product = multiplyNumbers(product, a)
+		}
+		return product, nil
+	case "$divide":
+		if len(args) != 2 {
+			return nil, fmt.Errorf("%w: $divide takes exactly 2 arguments", ErrInvalidPipeline)
+		}
+		if args[0] == nil || args[1] == nil {
+			return nil, nil
+		}
+		a, aok := toFloat(args[0])
+		b, bok := toFloat(args[1])
+		if !aok || !bok {
+			return nil, fmt.Errorf("%w: $divide only supports numbers", ErrInvalidPipeline)
+		}
+		if b == 0 {
+			return nil, fmt.Errorf("%w: $divide by zero", ErrInvalidPipeline)
+		}
+		return a / b, nil
+	case "$toLower", "$toUpper", "$toString", "$toInt":
+		if len(args) != 1 {
+			return nil, fmt.Errorf("%w: %s takes exactly 1 argument", ErrInvalidPipeline, op)
+		}
+		if op == "$toInt" {
+			return toInt32(args[0])
+		}
+		if args[0] == nil {
+			if op == "$toString" {
+				return nil, nil
+			}
+			return "", nil
+		}
+		s, err := toStringValue(args[0])
+		if err != nil {
+			return nil, err
+		}
+		switch op {
+		case "$toLower":
+			return strings.ToLower(s), nil
+		case "$toUpper":
+			return strings.ToUpper(s), nil
+		}
+		return s, nil
+	case "$mergeObjects":
+		out := make(map[string]interface{})
+		for _, a := range args {
+			if a == nil {
+				continue
+			}
+			m, ok := a.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("%w: $mergeObjects only supports documents, not %T", ErrInvalidPipeline, a)
+			}
+			for k, v := range m {
+				out[k] = v
+			}
+		}
+		return out, nil
+	}
+	return nil, fmt.Errorf("%w: unknown expression operator %s", ErrInvalidPipeline, op)
+}
+
+// evalOperands evaluates an operator's arguments, which are an array
+// of expressions or a single expression. Missing fields evaluate to
+// nil.
+func evalOperands(doc map[string]interface{}, arg interface{}) ([]interface{}, error) {
+	exprs, ok := arg.(primitive.A)
+	if !ok {
+		exprs = primitive.A{arg}
+	}
+	args := make([]interface{}, len(exprs))
+	for i, e := range exprs {
+		v, _, err := evalExpression(doc, e)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = v
+	}
+	return args, nil
+}
+
+// addValues adds numbers and, optionally, a single date, to which
+// the numbers are added as milliseconds.
+func addValues(args []interface{}) (interface{}, error) {
+	var sum interface{} = int32(0)
+	var date *primitive.DateTime
+	for _, a := range args {
+		switch v := a.(type) {
+		case nil:
+			return nil, nil
+		case primitive.DateTime:
+			if date != nil {
+				return nil, fmt.Errorf("%w: $add only supports one date", ErrInvalidPipeline)
+			}
+			date = &v
+		default:
+			if _, ok := toFloat(v); !ok {
+				return nil, fmt.Errorf("%w: $add only supports numbers and dates, not %T", ErrInvalidPipeline, a)
+			}
+			sum = addNumbers(sum, v)
+		}
+	}
+	if date != nil {
+		return *date + primitive.DateTime(roundMillis(sum)), nil
+	}
+	return sum, nil
+}
+
+// subtractValues subtracts b from a. A date minus a date is the
+// difference in milliseconds, and a date minus a number is a date.
+func subtractValues(a, b interface{}) (interface{}, error) {
+	if a == nil || b == nil {
+		return nil, nil
+	}
+	ad, aDate := a.(primitive.DateTime)
+	bd, bDate := b.(primitive.DateTime)
+	_, bNum := toFloat(b)
+	switch {
+	case aDate && bDate:
+		return int64(ad - bd), nil
+	case aDate && bNum:
+		return ad - primitive.DateTime(roundMillis(b)), nil
+	}
+	if _, ok := toFloat(a); !ok || !bNum {
+		return nil, fmt.Errorf("%w: $subtract only supports numbers and dates", ErrInvalidPipeline)
+	}
+	return addNumbers(a, multiplyNumbers(b, int32(-1))), nil
+}
+
+// roundMillis rounds a number of milliseconds to an int64.
+func roundMillis(v interface{}) int64 {
+	if n, ok := toInt(v); ok {
+		return n
+	}
+	f, _ := toFloat(v)
+	return int64(math.Round(f))
+}
+
+// toStringValue converts a value to a string, as $toString does.
+func toStringValue(v interface{}) (string, error) {
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case primitive.ObjectID:
+		return v.Hex(), nil
+	case primitive.DateTime:
+		return v.Time().UTC().Format("2006-01-02T15:04:05.000Z"), nil
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64), nil
+	}
+	if n, ok := toInt(v); ok {
+		return strconv.FormatInt(n, 10), nil
+	}
+	return "", fmt.Errorf("%w: can't convert %T to a string", ErrInvalidPipeline, v)
+}
+
+// toInt32 converts a value to an int32, as $toInt does. Doubles are
+// truncated.
+func toInt32(v interface{}) (interface{}, error) {
+	var n int64
+	switch x := v.(type) {
+	case nil:
+		return nil, nil
+	case bool:
+		if x {
+			return int32(1), nil
+		}
+		return int32(0), nil
+	case string:
+		i, err := strconv.ParseInt(strings.TrimSpace(x), 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("%w: can't convert %q to an int", ErrInvalidPipeline, x)
+		}
+		return int32(i), nil
+	case float64:
+		if math.IsNaN(x) || x < math.MinInt32 || x >= math.MaxInt32+1 {
+			return nil, fmt.Errorf("%w: %v doesn't fit in an int", ErrInvalidPipeline, x)
+		}
+		return int32(x), nil
+	default:
+		var ok bool
+		if n, ok = toInt(v); !ok {
+			return nil, fmt.Errorf("%w: can't convert %T to an int", ErrInvalidPipeline, v)
+		}
+	}
+	if n < math.MinInt32 || n > math.MaxInt32 {
+		return nil, fmt.Errorf("%w: %d doesn't fit in an int", ErrInvalidPipeline, n)
+	}
+	return int32(n), nil
+}
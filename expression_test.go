@@ -0,0 +1,113 @@
+package mingodb_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/korrbit/mingodb"
+	"github.com/korrbit/mingodb/testutil"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// expressionDoc is the document the expressions are evaluated against.
+var expressionDoc = map[string]interface{}{
+	"_id":   1,
+	"first": "Ada",
+	"last":  "Lovelace",
+	"n":     6,
+	"x":     2.5,
+	"s":     " 42",
+	"at":    time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	"end":   time.Date(2024, 1, 1, 0, 0, 1, 0, time.UTC),
+	"ok":    true,
+}
+
+// projectValue returns the value of the expression in a computed
+// $project field, or the error running the pipeline.
+func projectValue(t *testing.T, expr interface{}) (interface{}, error) {
+	t.Helper()
+	c := testutil.NewTestDB(t).CollectionMust("items")
+	testutil.SeedCollection(t, c, expressionDoc)
+	res, err := c.Aggregate(context.Background(), mingodb.Pipeline{
+		mingodb.ProjectStage(map[string]interface{}{"_id": 0, "v": expr}),
+	})
+	if err != nil {
+		return nil, err
+	}
+	var doc map[string]interface{}
+	if !res.Next() {
+		t.Fatal("Aggregate returned no documents")
+	}
+	if err := res.Decode(&doc); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	return doc["v"], nil
+}
+
+func TestProjectExpressions(t *testing.T) {
+	at := primitive.NewDateTimeFromTime(expressionDoc["at"].(time.Time))
+	tests := []struct {
+		name     string
+		expr     interface{}
+		expected interface{}
+	}{
+		{"FieldPath", "$first", "Ada"},
+		{"Literal", map[string]interface{}{"$literal": "$first"}, "$first"},
+		{"Concat", map[string]interface{}{"$concat": []interface{}{"$first", " ", "$last"}}, "Ada Lovelace"},
+		{"ConcatMissing", map[string]interface{}{"$concat": []interface{}{"$first", "$missing"}}, nil},
+		{"AddInts", map[string]interface{}{"$add": []interface{}{"$n", 1}}, int32(7)},
+		{"AddDouble", map[string]interface{}{"$add": []interface{}{"$n", "$x"}}, 8.5},
+		{"AddDate", map[string]interface{}{"$add": []interface{}{"$at", 1000}}, at + 1000},
+		{"Subtract", map[string]interface{}{"$subtract": []interface{}{"$n", 10}}, int32(-4)},
+		{"SubtractDates", map[string]interface{}{"$subtract": []interface{}{"$end", "$at"}}, int64(1000)},
+		{"SubtractFromDate", map[string]interface{}{"$subtract": []interface{}{"$at", 1000}}, at - 1000},
+		{"Multiply", map[string]interface{}{"$multiply": []interface{}{"$n", "$x"}}, 15.0},
+		{"Divide", map[string]interface{}{"$divide": []interface{}{"$n", 4}}, 1.5},
+		{"ToLower", map[string]interface{}{"$toLower": "$first"}, "ada"},
+		{"ToUpper", map[string]interface{}{"$toUpper": "$missing"}, ""},
+		{"ToString", map[string]interface{}{"$toString": "$n"}, "6"},
+		{"ToStringDate", map[string]interface{}{"$toString": "$at"}, "2024-01-01T00:00:00.000Z"},
+		{"ToInt", map[string]interface{}{"$toInt": "$s"}, int32(42)},
+		{"ToIntDouble", map[string]interface{}{"$toInt": "$x"}, int32(2)},
+		{"ToIntBool", map[string]interface{}{"$toInt": "$ok"}, int32(1)},
+		{"Nested", map[string]interface{}{"$toUpper": map[string]interface{}{"$concat": []interface{}{"$first", "!"}}}, "ADA!"},
+		{"Document", map[string]interface{}{"name": "$first", "missing": "$missing"}, map[string]interface{}{"name": "Ada"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := projectValue(t, tt.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(v, tt.expected) {
+				t.Errorf("got %v (%T), expected %v (%T)", v, v, tt.expected, tt.expected)
+			}
+		})
+	}
+}
+
+func TestProjectExpressionErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		expr interface{}
+	}{
+		{"UnknownOperator", map[string]interface{}{"$sqrt": "$n"}},
+		{"ConcatNumber", map[string]interface{}{"$concat": []interface{}{"$first", "$n"}}},
+		{"AddString", map[string]interface{}{"$add": []interface{}{"$n", "$first"}}},
+		{"AddTwoDates", map[string]interface{}{"$add": []interface{}{"$at", "$end"}}},
+		{"SubtractArgs", map[string]interface{}{"$subtract": []interface{}{"$n"}}},
+		{"DivideByZero", map[string]interface{}{"$divide": []interface{}{"$n", 0}}},
+		{"ToIntString", map[string]interface{}{"$toInt": "$first"}},
+		{"ToIntOverflow", map[string]interface{}{"$toInt": 1e10}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := projectValue(t, tt.expr); !errors.Is(err, mingodb.ErrInvalidPipeline) {
+				t.Errorf("got %v, expected ErrInvalidPipeline", err)
+			}
+		})
+	}
+}
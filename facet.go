@@ -0,0 +1,83 @@
+package mingodb
+
+import (
+	"context"
+	"fmt"
+
+	bolt "go.etcd.io/bbolt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// facetStage runs several pipelines against the same documents, and
+// outputs a single document with each pipeline's results as an array
+// under the facet's name:
+//
+//	{"$facet": {
+//		"cheapest":   [{"$sort": {"price": 1}}, {"$limit": 3}],
+//		"categories": [{"$group": {"_id": "$category"}}],
+//	}}
+//
+// Each pipeline is run on its own copy of the documents, so stages that
+// modify them don't affect the other facets.
+func facetStage(ctx context.Context, db *Database, tx *bolt.Tx, docs []map[string]interface{}, arg interface{}) ([]map[string]interface{}, error) {
+	spec, err := stageDocument(arg)
+	if err != nil {
+		return nil, err
+	}
+	if len(spec) == 0 {
+		return nil, fmt.Errorf("%w: $facet requires at least one facet", ErrInvalidPipeline)
+	}
+
+	out := make(map[string]interface{}, len(spec))
+	for name, v := range spec {
+		pipeline, err := facetPipeline(v)
+		if err != nil {
+			return nil, fmt.Errorf("facet %s: %w", name, err)
+		}
+		results, err := runPipeline(ctx, db, tx, copyDocuments(docs), pipeline, 0)
+		if err != nil {
+			return nil, fmt.Errorf("facet %s: %w", name, err)
+		}
+		arr := make(primitive.A, len(results))
+		for i, doc := range results {
+			arr[i] = doc
+		}
+		out[name] = arr
+	}
+	return []map[string]interface{}{out}, nil
+}
+
+// facetPipeline converts a facet's decoded array of stages into a
+// Pipeline.
+func facetPipeline(v interface{}) (Pipeline, error) {
+	stages, ok := v.(primitive.A)
+	if !ok {
+		return nil, fmt.Errorf("%w: a $facet must be an array of stages", ErrInvalidPipeline)
+	}
+	pipeline := make(Pipeline, len(stages))
+	for i, s := range stages {
+		m, ok := s.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%w: stage %d must be a document", ErrInvalidPipeline, i)
+		}
+		for k, v := range m {
+			pipeline[i] = append(pipeline[i], bson.E{Key: k, Value: v})
+		}
+	}
+	return pipeline, nil
+}
+
+// copyDocuments returns a shallow copy of each document, so that
+// stages can set their fields without affecting the originals.
+func copyDocuments(docs []map[string]interface{}) []map[string]interface{} {
+	out := make([]map[string]interface{}, len(docs))
+	for i, doc := range docs {
+		cp := make(map[string]interface{}, len(doc))
+		for k, v := range doc {
+			cp[k] = v
+		}
+		out[i] = cp
+	}
+	return out
+}
@@ -0,0 +1,107 @@
+package mingodb_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/korrbit/mingodb"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestFacetStage(t *testing.T) {
+	c := people(t)
+	got := aggregate(t, c, mingodb.Pipeline{
+		mingodb.MatchStage(map[string]interface{}{"age": map[string]interface{}{"$gte": 25}}),
+		mingodb.FacetStage(map[string]mingodb.Pipeline{
+			"cities": {
+				mingodb.GroupStage("$city", map[string]interface{}{"n": map[string]interface{}{"$sum": 1}}),
+				mingodb.SortStage(map[string]int{"_id": 1}),
+			},
+			"oldest": {
+				mingodb.SortStage(map[string]int{"age": -1}),
+				mingodb.LimitStage(1),
+				mingodb.ProjectStage(map[string]interface{}{"_id": 0, "name": 1}),
+			},
+			// Facets don't see each other's changes to the documents.
+			"renamed": {
+				mingodb.AddFieldsStage(map[string]interface{}{"name": "X"}),
+				mingodb.ProjectStage(map[string]interface{}{"_id": 0, "name": 1}),
+			},
+			"names": {
+				mingodb.SortStage(map[string]int{"name": 1}),
+				mingodb.ProjectStage(map[string]interface{}{"_id": 0, "name": 1}),
+			},
+			"none": {
+				mingodb.MatchStage(map[string]interface{}{"city": "Rome"}),
+			},
+		}),
+	})
+	expected := []map[string]interface{}{{
+		"cities": primitive.A{
+			map[string]interface{}{"_id": "London", "n": int32(1)},
+			map[string]interface{}{"_id": "Paris", "n": int32(2)},
+		},
+		"oldest": primitive.A{map[string]interface{}{"name": "Carol"}},
+		"renamed": primitive.A{
+			map[string]interface{}{"name": "X"},
+			map[string]interface{}{"name": "X"},
+			map[string]interface{}{"name": "X"},
+		},
+		"names": primitive.A{
+			map[string]interface{}{"name": "Alice"},
+			map[string]interface{}{"name": "Bob"},
+			map[string]interface{}{"name": "Carol"},
+		},
+		"none": primitive.A{},
+	}}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %v, expected %v", got, expected)
+	}
+}
+
+func TestFacetStageLookup(t *testing.T) {
+	db := shop(t)
+	got := aggregate(t, db.CollectionMust("users"), mingodb.Pipeline{
+		mingodb.FacetStage(map[string]mingodb.Pipeline{
+			"orders": {
+				mingodb.LookupStage("orders", "_id", "userId", "orders"),
+				mingodb.ProjectStage(map[string]interface{}{"_id": 0, "orders": 1}),
+			},
+		}),
+	})
+	expected := []map[string]interface{}{{
+		"orders": primitive.A{
+			map[string]interface{}{"orders": primitive.A{
+				map[string]interface{}{"_id": int32(10), "userId": int32(1), mingodb.VersionField: int64(1)},
+				map[string]interface{}{"_id": int32(11), "userId": int32(1), mingodb.VersionField: int64(1)},
+			}},
+			map[string]interface{}{"orders": primitive.A{}},
+		},
+	}}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %v, expected %v", got, expected)
+	}
+}
+
+func TestFacetStageErrors(t *testing.T) {
+	c := people(t)
+	tests := []struct {
+		name  string
+		stage bson.D
+	}{
+		{"NoFacets", mingodb.FacetStage(map[string]mingodb.Pipeline{})},
+		{"NotAnArray", bson.D{{Key: "$facet", Value: bson.D{{Key: "a", Value: 1}}}}},
+		{"StageNotADocument", bson.D{{Key: "$facet", Value: bson.D{{Key: "a", Value: bson.A{1}}}}}},
+		{"InvalidStage", mingodb.FacetStage(map[string]mingodb.Pipeline{"a": {mingodb.LimitStage(-1)}})},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := c.Aggregate(context.Background(), mingodb.Pipeline{tt.stage}); !errors.Is(err, mingodb.ErrInvalidPipeline) {
+				t.Errorf("got %v, expected ErrInvalidPipeline", err)
+			}
+		})
+	}
+}
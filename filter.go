@@ -0,0 +1,631 @@
+package mingodb
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// parseFilter converts a filter into a map[string]interface{}
+// that can be matched against stored documents. A nil filter
+// matches every document.
+func parseFilter(filter interface{}) (map[string]interface{}, error) {
+	if filter == nil {
+		return map[string]interface{}{}, nil
+	}
+
+	// Filters follow the same rules as documents.
+	m, err := toDocument(filter)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFilter, err)
+	}
+
+	f, err := normalizeDocument(m)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFilter, err)
+	}
+	return f, nil
+}
+
+// isIDFilter reports whether filter is a bare _id value rather
+// than a filter document.
+func isIDFilter(filter interface{}) bool {
+	if filter == nil {
+		return false
+	}
+	t := reflect.TypeOf(filter)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() != reflect.Struct && t.Kind() != reflect.Map
+}
+
+// matchesFilter reports whether doc matches every condition
+// in the filter.
+//
+// A condition is either a value, which the field must be equal to,
+// or a document of operators, such as {"$gte": 18, "$lt": 65}. Returns
+// ErrInvalidFilter if the filter uses an unknown operator.
+func matchesFilter(doc, filter map[string]interface{}) (bool, error) {
+	for k, cond := range filter {
+		var ok bool
+		var err error
+		if k == "$text" {
+			// Text searches are matched through the text index.
+			err = fmt.Errorf("%w: $text is only supported by Find, FindOne and FindOneAndDelete", ErrInvalidFilter)
+		} else if strings.HasPrefix(k, "$") {
+			ok, err = matchesLogical(doc, k, cond)
+		} else {
+			ok, err = matchesField(doc, k, cond)
+		}
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// matchesLogical reports whether doc matches a top-level logical
+// operator, such as {"$or": [{"a": 1}, {"b": 2}]}. Each of the
+// operator's sub-filters is matched like a filter of its own.
+func matchesLogical(doc map[string]interface{}, op string, arg interface{}) (bool, error) {
+	filters, ok := arg.(primitive.A)
+	if !ok || len(filters) == 0 {
+		return false, fmt.Errorf("%w: %s requires a non-empty array", ErrInvalidFilter, op)
+	}
+
+	// Count the number of matching sub-filters.
+	var n int
+	for _, f := range filters {
+		sub, ok := f.(map[string]interface{})
+		if !ok {
+			return false, fmt.Errorf("%w: %s requires an array of documents", ErrInvalidFilter, op)
+		}
+		ok, err := matchesFilter(doc, sub)
+		if err != nil {
+			return false, err
+		}
+		if ok {
+			n++
+		}
+	}
+
+	switch op {
+	case "$and":
+		return n == len(filters), nil
+	case "$or":
+		return n > 0, nil
+	case "$nor":
+		return n == 0, nil
+	}
+	return false, fmt.Errorf("%w: unknown operator %s", ErrInvalidFilter, op)
+}
+
+// matchesField reports whether the field k of doc matches
+// the condition. k can use dot-notation to refer to a nested
+// field or an array element, such as "address.city" or "scores.0".
+func matchesField(doc map[string]interface{}, k string, cond interface{}) (bool, error) {
+	v, exists := doc[k]
+	if !exists && strings.Contains(k, ".") {
+		v, exists = lookupPath(doc, k)
+	}
+	if isOperatorDocument(cond) {
+		return matchesOperators(v, exists, cond.(map[string]interface{}))
+	}
+	return exists && valuesEqual(v, cond), nil
+}
+
+// matchesOperators reports whether a value satisfies every operator
+// in ops, for example {"$gt": 5, "$lt": 10}. exists is false if the
+// value is missing from the document.
+func matchesOperators(v interface{}, exists bool, ops map[string]interface{}) (bool, error) {
+	for op, arg := range ops {
+		var ok bool
+		var err error
+		switch op {
+		case "$regex":
+			ok, err = matchesRegex(v, exists, arg, ops["$options"])
+		case "$options":
+			// Used by $regex.
+			if _, hasRegex := ops["$regex"]; !hasRegex {
+				return false, fmt.Errorf("%w: $options requires $regex", ErrInvalidFilter)
+			}
+			continue
+		default:
+			ok, err = evalOperator(op, v, exists, arg)
+		}
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// evalOperator reports whether a value satisfies a single operator.
+// exists is false if the value is missing from the document.
+func evalOperator(op string, v interface{}, exists bool, arg interface{}) (bool, error) {
+	switch op {
+	case "$eq":
+		return exists && valuesEqual(v, arg), nil
+	case "$ne":
+		// Missing fields are never equal to the operand.
+		return !exists || !valuesEqual(v, arg), nil
+	case "$exists":
+		// A field with a null value still exists.
+		want, ok := arg.(bool)
+		if !ok {
+			return false, fmt.Errorf("%w: $exists requires a boolean", ErrInvalidFilter)
+		}
+		return exists == want, nil
+	case "$type":
+		if !exists {
+			return false, nil
+		}
+		return matchesType(v, arg)
+	case "$not":
+		// Negate an operator expression or, like $ne, an equality.
+		if isOperatorDocument(arg) {
+			ok, err := matchesOperators(v, exists, arg.(map[string]interface{}))
+			return !ok, err
+		}
+		return !exists || !valuesEqual(v, arg), nil
+	case "$elemMatch":
+		cond, ok := arg.(map[string]interface{})
+		if !ok {
+			return false, fmt.Errorf("%w: $elemMatch requires a document", ErrInvalidFilter)
+		}
+		arr, ok := v.(primitive.A)
+		if !exists || !ok {
+			return false, nil
+		}
+		return anyElementMatches(arr, cond)
+	case "$all":
+		list, ok := arg.(primitive.A)
+		if !ok {
+			return false, fmt.Errorf("%w: $all requires an array", ErrInvalidFilter)
+		}
+		return matchesAll(v, exists, list)
+	case "$in", "$nin":
+		list, ok := arg.(primitive.A)
+		if !ok {
+			return false, fmt.Errorf("%w: %s requires an array", ErrInvalidFilter, op)
+		}
+		in := exists && matchesAny(v, list)
+		if op == "$nin" {
+			return !in, nil
+		}
+		return in, nil
+	case "$lt":
+		return exists && sameTypeClass(v, arg) && compareValues(v, arg) < 0, nil
+	case "$lte":
+		return exists && sameTypeClass(v, arg) && compareValues(v, arg) <= 0, nil
+	case "$gt":
+		return exists && sameTypeClass(v, arg) && compareValues(v, arg) > 0, nil
+	case "$gte":
+		return exists && sameTypeClass(v, arg) && compareValues(v, arg) >= 0, nil
+	}
+	return false, fmt.Errorf("%w: unknown operator %s", ErrInvalidFilter, op)
+}
+
+// anyElementMatches reports whether at least one element of arr
+// satisfies every condition in cond. The condition is either a
+// document of operators, such as {"$gt": 80, "$lt": 100}, or a
+// filter that embedded documents are matched against.
+func anyElementMatches(arr primitive.A, cond map[string]interface{}) (bool, error) {
+	ops := isOperatorDocument(cond)
+	for _, elem := range arr {
+		var ok bool
+		var err error
+		if ops {
+			ok, err = matchesOperators(elem, true, cond)
+		} else if doc, isDoc := elem.(map[string]interface{}); isDoc {
+			ok, err = matchesFilter(doc, cond)
+		}
+		if err != nil {
+			return false, err
+		}
+		if ok {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// matchesAll reports whether v is an array that contains every
+// value in list, in any order. A value can also be an $elemMatch
+// condition, which must be satisfied by one of the array's
+// elements. An empty list matches everything.
+func matchesAll(v interface{}, exists bool, list primitive.A) (bool, error) {
+	if len(list) == 0 {
+		return true, nil
+	}
+	arr, ok := v.(primitive.A)
+	if !exists || !ok {
+		return false, nil
+	}
+
+	for _, want := range list {
+		// Is it an $elemMatch condition?
+		if m, ok := want.(map[string]interface{}); ok && len(m) == 1 {
+			if cond, ok := m["$elemMatch"]; ok {
+				c, ok := cond.(map[string]interface{})
+				if !ok {
+					return false, fmt.Errorf("%w: $elemMatch requires a document", ErrInvalidFilter)
+				}
+				ok, err := anyElementMatches(arr, c)
+				if err != nil || !ok {
+					return false, err
+				}
+				continue
+			}
+		}
+
+		found := false
+		for _, elem := range arr {
+			if valuesEqual(elem, want) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// matchesAny reports whether v is equal to any value in list. If v
+// is an array, it matches if the array itself or any of its elements
+// is in the list.
+func matchesAny(v interface{}, list primitive.A) bool {
+	for _, want := range list {
+		if valuesEqual(v, want) {
+			return true
+		}
+	}
+	if arr, ok := v.(primitive.A); ok {
+		for _, elem := range arr {
+			for _, want := range list {
+				if valuesEqual(elem, want) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// sameTypeClass reports whether two values can be compared with
+// the comparison operators. Like MongoDB, only values of the
+// same type (with all numbers being one type) are compared.
+func sameTypeClass(a, b interface{}) bool {
+	return typeRank(a) == typeRank(b)
+}
+
+// equalityFields returns a new document containing the fields
+// of the filter that are matched by equality.
+func equalityFields(filter map[string]interface{}) map[string]interface{} {
+	doc := make(map[string]interface{})
+	for k, v := range filter {
+		if strings.HasPrefix(k, "$") || isOperatorDocument(v) {
+			continue
+		}
+		doc[k] = v
+	}
+	return doc
+}
+
+// isOperatorDocument reports whether v is a document of query or
+// update operators, such as {"$gt": 5}.
+func isOperatorDocument(v interface{}) bool {
+	m, ok := v.(map[string]interface{})
+	if !ok || len(m) == 0 {
+		return false
+	}
+	for k := range m {
+		if !strings.HasPrefix(k, "$") {
+			return false
+		}
+	}
+	return true
+}
+
+// valuesEqual reports whether two decoded BSON values are equal.
+// Numeric values are compared by value, regardless of their type.
+func valuesEqual(a, b interface{}) bool {
+	// Are they both numbers? NaN isn't equal to anything, not even
+	// itself.
+	if fa, ok := toFloat(a); ok {
+		fb, ok := toFloat(b)
+		if !ok || math.IsNaN(fa) || math.IsNaN(fb) {
+			return false
+		}
+		c, _ := compareNumbers(a, b)
+		return c == 0
+	}
+
+	switch av := a.(type) {
+	case primitive.A:
+		bv, ok := b.(primitive.A)
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for i := range av {
+			if !valuesEqual(av[i], bv[i]) {
+				return false
+			}
+		}
+		return true
+	case map[string]interface{}:
+		bv, ok := b.(map[string]interface{})
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for k := range av {
+			if _, ok := bv[k]; !ok || !valuesEqual(av[k], bv[k]) {
+				return false
+			}
+		}
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// compareValues compares two decoded BSON values. Returns -1 if
+// a is less than b, 1 if a is greater than b and 0 if they are
+// equal.
+//
+// Numbers are compared by value (see compareNumbers), strings
+// lexicographically, dates chronologically and ObjectIDs by their
+// timestamp then bytes. Values of different types are ordered by
+// type, following MongoDB's comparison order.
+func compareValues(a, b interface{}) int {
+	ra, rb := typeRank(a), typeRank(b)
+	if ra != rb {
+		return compareInts(int64(ra), int64(rb))
+	}
+
+	// Symbols compare as the strings they hold.
+	if s, ok := a.(primitive.Symbol); ok {
+		a = string(s)
+	}
+	if s, ok := b.(primitive.Symbol); ok {
+		b = string(s)
+	}
+
+	switch av := a.(type) {
+	case string:
+		return strings.Compare(av, b.(string))
+	case bool:
+		bv := b.(bool)
+		if av == bv {
+			return 0
+		}
+		if !av {
+			return -1
+		}
+		return 1
+	case primitive.ObjectID:
+		bv := b.(primitive.ObjectID)
+		if c := compareInts(av.Timestamp().Unix(), bv.Timestamp().Unix()); c != 0 {
+			return c
+		}
+		return bytes.Compare(av[:], bv[:])
+	}
+
+	if c, ok := compareNumbers(a, b); ok {
+		return c
+	}
+	if ta, ok := toTime(a); ok {
+		tb, _ := toTime(b)
+		return compareInts(ta.UnixNano(), tb.UnixNano())
+	}
+	return 0
+}
+
+// typeRank returns the position of v's type in MongoDB's
+// comparison order.
+func typeRank(v interface{}) int {
+	if _, ok := toFloat(v); ok {
+		return 2
+	}
+	if _, ok := toTime(v); ok {
+		return 9
+	}
+	switch v.(type) {
+	case nil, primitive.Null:
+		return 1
+	case string, primitive.Symbol:
+		return 3
+	case map[string]interface{}, primitive.D, primitive.M:
+		return 4
+	case primitive.A:
+		return 5
+	case primitive.Binary:
+		return 6
+	case primitive.ObjectID:
+		return 7
+	case bool:
+		return 8
+	case primitive.Timestamp:
+		return 10
+	case primitive.Regex:
+		return 11
+	}
+	return 12
+}
+
+// compareInts compares two integers.
+func compareInts(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// compareNumbers compares two numbers by value, like compareValues.
+// The second return value is false if either isn't a number.
+//
+// Two integers are compared as int64s and an integer and a float are
+// compared exactly, so integers too large to be held by a float64,
+// such as 1<<53 + 1, aren't rounded. Only two floats are compared as
+// float64s.
+func compareNumbers(a, b interface{}) (int, bool) {
+	fa, ok := toFloat(a)
+	if !ok {
+		return 0, false
+	}
+	fb, ok := toFloat(b)
+	if !ok {
+		return 0, false
+	}
+	ia, aInt := toInt(a)
+	ib, bInt := toInt(b)
+	switch {
+	case aInt && bInt:
+		return compareInts(ia, ib), true
+	case aInt:
+		return compareIntFloat(ia, fb), true
+	case bInt:
+		return -compareIntFloat(ib, fa), true
+	}
+	return compareFloats(fa, fb), true
+}
+
+// compareIntFloat compares an integer with a float without rounding
+// the integer to a float64.
+func compareIntFloat(i int64, f float64) int {
+	switch {
+	case math.IsNaN(f):
+		return compareFloats(float64(i), f)
+	case f >= math.MaxInt64:
+		return -1
+	case f < math.MinInt64:
+		return 1
+	}
+	// Compare the integer part of f, then its fraction.
+	t := math.Trunc(f)
+	if c := compareInts(i, int64(t)); c != 0 {
+		return c
+	}
+	return compareFloats(t, f)
+}
+
+// compareFloats compares two floats. NaN is neither less than nor
+// greater than any number, so it compares as equal.
+func compareFloats(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// toTime converts a date value to a time.Time. The second
+// return value is false if v isn't a date.
+func toTime(v interface{}) (time.Time, bool) {
+	switch t := v.(type) {
+	case time.Time:
+		return t, true
+	case primitive.DateTime:
+		return t.Time(), true
+	}
+	return time.Time{}, false
+}
+
+// toFloat converts a numeric value to a float64. The second
+// return value is false if v isn't a number.
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int8:
+		return float64(n), true
+	case int16:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint:
+		return float64(n), true
+	case uint8:
+		return float64(n), true
+	case uint16:
+		return float64(n), true
+	case uint32:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case float32:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+// toInt converts an integer value to an int64. The second return
+// value is false if v isn't an integer.
+func toInt(v interface{}) (int64, bool) {
+	switch n := v.(type) {
+	case int:
+		return int64(n), true
+	case int8:
+		return int64(n), true
+	case int16:
+		return int64(n), true
+	case int32:
+		return int64(n), true
+	case int64:
+		return n, true
+	}
+	return 0, false
+}
+
+// scanMatches calls fn for each document in the bucket that
+// matches the filter, passing its key, its raw BSON and its
+// decoded contents. Iteration stops early if fn returns false
+// or an error, or if ctx is cancelled.
+//
+// Note that fn must not modify the bucket while it is being
+// scanned. Collect the keys instead and write after the scan.
+func scanMatches(ctx context.Context, b *bolt.Bucket, filter map[string]interface{}, fn func(k, v []byte, doc map[string]interface{}) (bool, error)) error {
+	c := b.Cursor()
+	for k, v := c.First(); k != nil; k, v = c.Next() {
+		// Has the scan been cancelled?
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		countScanned(ctx)
+
+		data, doc, err := decodeDocument(b.Tx(), v)
+		if err != nil {
+			return err
+		}
+		ok, err := matchesFilter(doc, filter)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		more, err := fn(k, data, doc)
+		if err != nil || !more {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,234 @@
+package mingodb_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/korrbit/mingodb"
+	"github.com/korrbit/mingodb/testutil"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestCompareStringsAndSymbols(t *testing.T) {
+	ctx := context.Background()
+	db := testutil.NewTestDB(t)
+	c := db.CollectionMust("items")
+	testutil.SeedCollection(t, c,
+		map[string]interface{}{"_id": 1, "name": "b"},
+		map[string]interface{}{"_id": 2, "name": primitive.Symbol("c")},
+		map[string]interface{}{"_id": 3, "name": primitive.Symbol("a")},
+	)
+
+	res, err := c.Find(ctx, nil, mingodb.FindOptions{Sort: []mingodb.SortField{{Field: "name", Dir: 1}}})
+	if err != nil {
+		t.Fatalf("Find: %v", err)
+	}
+	var ids []int32
+	for res.Next() {
+		var doc struct {
+			ID int32 `bson:"_id"`
+		}
+		if err := res.Decode(&doc); err != nil {
+			t.Fatalf("Decode: %v", err)
+		}
+		ids = append(ids, doc.ID)
+	}
+	if len(ids) != 3 || ids[0] != 3 || ids[1] != 1 || ids[2] != 2 {
+		t.Errorf("sorted _ids are %v, expected [3 1 2]", ids)
+	}
+
+	testutil.AssertDocumentCount(t, c, map[string]interface{}{"name": map[string]interface{}{"$lt": "b"}}, 1)
+	testutil.AssertDocumentCount(t, c, map[string]interface{}{"name": map[string]interface{}{"$gte": "b"}}, 2)
+}
+
+func TestCompareLargeIntegers(t *testing.T) {
+	const big = int64(1) << 53
+	c := testutil.NewTestDB(t).CollectionMust("items")
+	testutil.SeedCollection(t, c,
+		map[string]interface{}{"_id": 1, "n": big},
+		map[string]interface{}{"_id": 2, "n": big + 1},
+		map[string]interface{}{"_id": 3, "n": float64(big)},
+	)
+
+	// big+1 can't be held by a float64, which would round it to big.
+	tests := []struct {
+		name     string
+		filter   map[string]interface{}
+		expected []int32
+	}{
+		{"equal", map[string]interface{}{"n": big + 1}, []int32{2}},
+		{"equal to float", map[string]interface{}{"n": float64(big)}, []int32{1, 3}},
+		{"greater", map[string]interface{}{"n": map[string]interface{}{"$gt": big}}, []int32{2}},
+		{"less", map[string]interface{}{"n": map[string]interface{}{"$lt": big + 1}}, []int32{1, 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := findIDs(t, c, tt.filter); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("got %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+
+	got := findIDs(t, c, nil, mingodb.FindOptions{Sort: []mingodb.SortField{{Field: "n", Dir: -1}}})
+	if got[0] != 2 {
+		t.Errorf("sorted _ids are %v, expected 2 first", got)
+	}
+}
+
+// filterTest is a test of a filter against the items collection,
+// which should return the documents with the expected _ids.
+type filterTest struct {
+	name     string
+	filter   map[string]interface{}
+	expected []int32
+}
+
+// runFilterTests runs each of the tests against a collection holding
+// four documents.
+func runFilterTests(t *testing.T, tests []filterTest) {
+	t.Helper()
+	c := testutil.NewTestDB(t).CollectionMust("items")
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	testutil.SeedCollection(t, c,
+		map[string]interface{}{"_id": 1, "name": "Go", "age": 10, "score": 1.5, "at": day, "status": "active",
+			"tags": []interface{}{"go", "bbolt"}, "scores": []interface{}{85, 95}},
+		map[string]interface{}{"_id": 2, "name": "golang", "age": 20, "score": 2, "at": day.Add(time.Hour), "status": "pending",
+			"tags": []interface{}{"go"}, "scores": []interface{}{50}, "deletedAt": nil},
+		map[string]interface{}{"_id": 3, "name": "Rust", "age": 30, "score": "high", "status": "deleted",
+			"tags": []interface{}{}, "scores": []interface{}{100}},
+		map[string]interface{}{"_id": 4},
+	)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := findIDs(t, c, tt.filter); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("got %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestComparisonOperators(t *testing.T) {
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	runFilterTests(t, []filterTest{
+		{"$gt", map[string]interface{}{"age": map[string]interface{}{"$gt": 10}}, []int32{2, 3}},
+		{"$gte", map[string]interface{}{"age": map[string]interface{}{"$gte": 20}}, []int32{2, 3}},
+		{"$lt", map[string]interface{}{"age": map[string]interface{}{"$lt": 20}}, []int32{1}},
+		{"$lte", map[string]interface{}{"age": map[string]interface{}{"$lte": 20}}, []int32{1, 2}},
+		{"range", map[string]interface{}{"age": map[string]interface{}{"$gt": 10, "$lte": 30}}, []int32{2, 3}},
+		{"int and float", map[string]interface{}{"score": map[string]interface{}{"$gt": 1}}, []int32{1, 2}},
+		{"strings", map[string]interface{}{"name": map[string]interface{}{"$lt": "R"}}, []int32{1}},
+		{"times", map[string]interface{}{"at": map[string]interface{}{"$gt": day}}, []int32{2}},
+	})
+}
+
+func TestNe(t *testing.T) {
+	runFilterTests(t, []filterTest{
+		{"value", map[string]interface{}{"status": map[string]interface{}{"$ne": "deleted"}}, []int32{1, 2, 4}},
+		{"array", map[string]interface{}{"tags": map[string]interface{}{"$ne": []interface{}{"go", "bbolt"}}}, []int32{2, 3, 4}},
+		{"$and", map[string]interface{}{"$and": []interface{}{
+			map[string]interface{}{"status": map[string]interface{}{"$ne": "deleted"}},
+			map[string]interface{}{"status": map[string]interface{}{"$ne": "pending"}},
+		}}, []int32{1, 4}},
+	})
+}
+
+func TestInAndNin(t *testing.T) {
+	runFilterTests(t, []filterTest{
+		{"$in", map[string]interface{}{"status": map[string]interface{}{"$in": []interface{}{"active", "pending"}}}, []int32{1, 2}},
+		{"$in array field", map[string]interface{}{"tags": map[string]interface{}{"$in": []interface{}{"bbolt", "rust"}}}, []int32{1}},
+		{"$nin", map[string]interface{}{"status": map[string]interface{}{"$nin": []interface{}{"active", "pending"}}}, []int32{3, 4}},
+		{"$nin array field", map[string]interface{}{"tags": map[string]interface{}{"$nin": []interface{}{"bbolt"}}}, []int32{2, 3, 4}},
+	})
+}
+
+func TestLogicalOperators(t *testing.T) {
+	young := map[string]interface{}{"age": map[string]interface{}{"$lt": 15}}
+	pending := map[string]interface{}{"status": "pending"}
+	runFilterTests(t, []filterTest{
+		{"$and", map[string]interface{}{"$and": []interface{}{young, map[string]interface{}{"name": "Go"}}}, []int32{1}},
+		{"$or", map[string]interface{}{"$or": []interface{}{young, pending}}, []int32{1, 2}},
+		{"$nor", map[string]interface{}{"$nor": []interface{}{young, pending}}, []int32{3, 4}},
+		{"nested", map[string]interface{}{"$and": []interface{}{
+			map[string]interface{}{"$or": []interface{}{young, pending}},
+			map[string]interface{}{"tags": map[string]interface{}{"$in": []interface{}{"bbolt"}}},
+		}}, []int32{1}},
+	})
+}
+
+func TestNot(t *testing.T) {
+	runFilterTests(t, []filterTest{
+		{"operator", map[string]interface{}{"age": map[string]interface{}{"$not": map[string]interface{}{"$gt": 15}}}, []int32{1, 4}},
+		{"value", map[string]interface{}{"status": map[string]interface{}{"$not": "active"}}, []int32{2, 3, 4}},
+	})
+}
+
+func TestExists(t *testing.T) {
+	runFilterTests(t, []filterTest{
+		{"true", map[string]interface{}{"age": map[string]interface{}{"$exists": true}}, []int32{1, 2, 3}},
+		{"false", map[string]interface{}{"age": map[string]interface{}{"$exists": false}}, []int32{4}},
+		{"null", map[string]interface{}{"deletedAt": map[string]interface{}{"$exists": true}}, []int32{2}},
+	})
+}
+
+func TestElemMatch(t *testing.T) {
+	runFilterTests(t, []filterTest{
+		{"one element", map[string]interface{}{"scores": map[string]interface{}{"$elemMatch": map[string]interface{}{"$gt": 80, "$lt": 100}}}, []int32{1}},
+		{"no element", map[string]interface{}{"scores": map[string]interface{}{"$elemMatch": map[string]interface{}{"$gt": 100}}}, []int32{}},
+		{"non-array field", map[string]interface{}{"age": map[string]interface{}{"$elemMatch": map[string]interface{}{"$gt": 0}}}, []int32{}},
+	})
+
+	// Every condition must hold for the same embedded document.
+	c := testutil.NewTestDB(t).CollectionMust("orders")
+	testutil.SeedCollection(t, c,
+		map[string]interface{}{"_id": 1, "items": []interface{}{
+			map[string]interface{}{"sku": "a", "qty": 1},
+			map[string]interface{}{"sku": "b", "qty": 5},
+		}},
+		map[string]interface{}{"_id": 2, "items": []interface{}{
+			map[string]interface{}{"sku": "a", "qty": 5},
+		}},
+	)
+	filter := map[string]interface{}{"items": map[string]interface{}{"$elemMatch": map[string]interface{}{
+		"sku": "a", "qty": map[string]interface{}{"$gt": 2},
+	}}}
+	if got := findIDs(t, c, filter); !reflect.DeepEqual(got, []int32{2}) {
+		t.Errorf("got %v, expected [2]", got)
+	}
+}
+
+func TestAll(t *testing.T) {
+	runFilterTests(t, []filterTest{
+		{"every value", map[string]interface{}{"tags": map[string]interface{}{"$all": []interface{}{"bbolt", "go"}}}, []int32{1}},
+		{"one value", map[string]interface{}{"tags": map[string]interface{}{"$all": []interface{}{"go"}}}, []int32{1, 2}},
+		{"empty", map[string]interface{}{"tags": map[string]interface{}{"$all": []interface{}{}}}, []int32{1, 2, 3, 4}},
+		{"$elemMatch", map[string]interface{}{"scores": map[string]interface{}{"$all": []interface{}{
+			map[string]interface{}{"$elemMatch": map[string]interface{}{"$gt": 90}},
+			map[string]interface{}{"$elemMatch": map[string]interface{}{"$lt": 90}},
+		}}}, []int32{1}},
+	})
+}
+
+func TestDotNotation(t *testing.T) {
+	c := testutil.NewTestDB(t).CollectionMust("people")
+	testutil.SeedCollection(t, c,
+		map[string]interface{}{"_id": 1, "address": map[string]interface{}{"city": "London", "geo": map[string]interface{}{"lat": 51}}, "scores": []interface{}{90, 70}},
+		map[string]interface{}{"_id": 2, "address": map[string]interface{}{"city": "Paris"}, "scores": []interface{}{60}},
+		map[string]interface{}{"_id": 3, "address": "unknown"},
+	)
+	tests := []filterTest{
+		{"nested field", map[string]interface{}{"address.city": "London"}, []int32{1}},
+		{"deeply nested field", map[string]interface{}{"address.geo.lat": map[string]interface{}{"$gt": 50}}, []int32{1}},
+		{"array index", map[string]interface{}{"scores.0": map[string]interface{}{"$gte": 60}}, []int32{1, 2}},
+		{"out of range", map[string]interface{}{"scores.1": map[string]interface{}{"$exists": true}}, []int32{1}},
+		{"through a non-document", map[string]interface{}{"address.city": map[string]interface{}{"$exists": false}}, []int32{3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := findIDs(t, c, tt.filter); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("got %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,257 @@
+package mingodb
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// accumulator is a field computed by a $group stage, such as
+// {"total": {"$sum": "$amount"}}.
+type accumulator struct {
+	field string      // Output field
+	op    string      // Accumulator operator, such as $sum
+	expr  interface{} // Expression the operator is applied to
+}
+
+// accumulatorState is the running value of an accumulator
+// for a single group.
+type accumulatorState struct {
+	value interface{} // Current value
+	count int         // Number of values accumulated
+}
+
+// groupState is a group of documents that share the same _id.
+type groupState struct {
+	id     interface{}
+	states []accumulatorState
+}
+
+// groupStage groups the documents by the _id expression and computes
+// each accumulator for every group:
+//
+//	{"$group": {"_id": "$region", "total": {"$sum": "$amount"}}}
+//
+// The _id can be null, which puts every document in a single group,
+// a field path or a document of field paths. The supported accumulators
+// are $sum, $avg, $min, $max, $count, $first, $last, $push and
+// $addToSet. Groups are returned in the order they were first seen.
+func groupStage(docs []map[string]interface{}, arg interface{}) ([]map[string]interface{}, error) {
+	spec, err := stageDocument(arg)
+	if err != nil {
+		return nil, err
+	}
+	idExpr, ok := spec["_id"]
+	if !ok {
+		return nil, fmt.Errorf("%w: $group requires an _id", ErrInvalidPipeline)
+	}
+	accs, err := parseAccumulators(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	// Assign each document to a group, keyed on its serialized _id.
+	groups := make(map[string]*groupState)
+	var order []string
+	for _, doc := range docs {
+		id, _, err := evalExpression(doc, idExpr)
+		if err != nil {
+			return nil, err
+		}
+		key, err := groupKey(id)
+		if err != nil {
+			return nil, err
+		}
+		g, ok := groups[key]
+		if !ok {
+			g = &groupState{id: id, states: make([]accumulatorState, len(accs))}
+			groups[key] = g
+			order = append(order, key)
+		}
+
+		for i, acc := range accs {
+			if err := accumulate(&g.states[i], acc, doc); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	out := make([]map[string]interface{}, len(order))
+	for i, key := range order {
+		g := groups[key]
+		doc := map[string]interface{}{"_id": g.id}
+		for j, acc := range accs {
+			doc[acc.field] = accumulatorResult(g.states[j], acc)
+		}
+		out[i] = doc
+	}
+	return out, nil
+}
+
+// parseAccumulators returns the accumulators in a $group stage's
+// argument, which is every field other than the _id.
+func parseAccumulators(spec map[string]interface{}) ([]accumulator, error) {
+	var accs []accumulator
+	for field, v := range spec {
+		if field == "_id" {
+			continue
+		}
+		m, ok := v.(map[string]interface{})
+		if !ok || len(m) != 1 {
+			return nil, fmt.Errorf("%w: %s must be an accumulator document with a single operator", ErrInvalidPipeline, field)
+		}
+		for op, expr := range m {
+			switch op {
+			case "$sum", "$avg", "$min", "$max", "$count", "$first", "$last", "$push", "$addToSet":
+			default:
+				return nil, fmt.Errorf("%w: unknown accumulator %s", ErrInvalidPipeline, op)
+			}
+			accs = append(accs, accumulator{field: field, op: op, expr: expr})
+		}
+	}
+
+	// Sort the accumulators so that errors are reported consistently.
+	sort.Slice(accs, func(i, j int) bool { return accs[i].field < accs[j].field })
+	return accs, nil
+}
+
+// accumulate adds doc to the running value of the accumulator.
+func accumulate(s *accumulatorState, acc accumulator, doc map[string]interface{}) error {
+	if acc.op == "$count" {
+		s.count++
+		return nil
+	}
+
+	v, exists, err := evalExpression(doc, acc.expr)
+	if err != nil {
+		return err
+	}
+
+	switch acc.op {
+	case "$sum", "$avg":
+		// Non-numeric values are ignored.
+		if _, ok := toFloat(v); !ok || !exists {
+			return nil
+		}
+		if s.count == 0 {
+			s.value = v
+		} else {
+			s.value = addNumbers(s.value, v)
+		}
+		s.count++
+	case "$min", "$max":
+		// Missing and null values are ignored.
+		if !exists || v == nil {
+			return nil
+		}
+		c := 0
+		if s.count > 0 {
+			c = compareValues(v, s.value)
+		}
+		if s.count == 0 || (acc.op == "$min" && c < 0) || (acc.op == "$max" && c > 0) {
+			s.value = v
+		}
+		s.count++
+	case "$first":
+		if s.count == 0 {
+			s.value = v
+		}
+		s.count++
+	case "$last":
+		s.value = v
+		s.count++
+	case "$push":
+		if !exists {
+			return nil
+		}
+		arr, _ := s.value.(primitive.A)
+		s.value = append(arr, v)
+		s.count++
+	case "$addToSet":
+		if !exists {
+			return nil
+		}
+		arr, _ := s.value.(primitive.A)
+		for _, elem := range arr {
+			if reflect.DeepEqual(elem, v) {
+				return nil
+			}
+		}
+		s.value = append(arr, v)
+		s.count++
+	}
+	return nil
+}
+
+// accumulatorResult returns the final value of the accumulator.
+func accumulatorResult(s accumulatorState, acc accumulator) interface{} {
+	switch acc.op {
+	case "$sum":
+		if s.count == 0 {
+			return int32(0)
+		}
+	case "$avg":
+		if s.count == 0 {
+			return nil
+		}
+		sum, _ := toFloat(s.value)
+		return sum / float64(s.count)
+	case "$count":
+		return countValue(s.count)
+	case "$push", "$addToSet":
+		if s.value == nil {
+			return primitive.A{}
+		}
+	}
+	return s.value
+}
+
+// countValue returns a count as an int32, or as an int64 if it's too
+// large for an int32.
+func countValue(n int) interface{} {
+	if n > math.MaxInt32 {
+		return int64(n)
+	}
+	return int32(n)
+}
+
+// groupKey serializes a group's _id so that equal _ids have the
+// same key. Maps are serialized with their keys in sorted order.
+func groupKey(id interface{}) (string, error) {
+	t, b, err := bson.MarshalValue(canonicalValue(id))
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidPipeline, err)
+	}
+	return string(append([]byte{byte(t)}, b...)), nil
+}
+
+// canonicalValue converts the maps within v into bson.Ds with
+// sorted keys, so that equal values always serialize the same way.
+func canonicalValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case nil:
+		return primitive.Null{}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		d := make(bson.D, len(keys))
+		for i, k := range keys {
+			d[i] = bson.E{Key: k, Value: canonicalValue(v[k])}
+		}
+		return d
+	case primitive.A:
+		a := make(primitive.A, len(v))
+		for i, elem := range v {
+			a[i] = canonicalValue(elem)
+		}
+		return a
+	}
+	return v
+}
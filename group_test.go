@@ -0,0 +1,91 @@
+package mingodb_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/korrbit/mingodb"
+	"github.com/korrbit/mingodb/testutil"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// sales returns a collection holding four sales in two regions.
+func sales(t *testing.T) *mingodb.Collection {
+	t.Helper()
+	c := testutil.NewTestDB(t).CollectionMust("sales")
+	testutil.SeedCollection(t, c,
+		map[string]interface{}{"_id": 1, "region": "north", "item": "a", "amount": 10},
+		map[string]interface{}{"_id": 2, "region": "south", "item": "b", "amount": 5},
+		map[string]interface{}{"_id": 3, "region": "north", "item": "a", "amount": 20},
+		map[string]interface{}{"_id": 4, "region": "north", "item": "c", "amount": 30},
+	)
+	return c
+}
+
+func TestGroup(t *testing.T) {
+	c := sales(t)
+	tests := []struct {
+		name     string
+		id       interface{}
+		acc      map[string]interface{}
+		expected []map[string]interface{}
+	}{
+		{
+			name: "field",
+			id:   "$region",
+			acc: map[string]interface{}{
+				"total": map[string]interface{}{"$sum": "$amount"},
+				"avg":   map[string]interface{}{"$avg": "$amount"},
+				"n":     map[string]interface{}{"$count": map[string]interface{}{}},
+			},
+			expected: []map[string]interface{}{
+				{"_id": "north", "total": int32(60), "avg": 20.0, "n": int32(3)},
+				{"_id": "south", "total": int32(5), "avg": 5.0, "n": int32(1)},
+			},
+		},
+		{
+			name: "null",
+			id:   nil,
+			acc: map[string]interface{}{
+				"min":   map[string]interface{}{"$min": "$amount"},
+				"max":   map[string]interface{}{"$max": "$amount"},
+				"first": map[string]interface{}{"$first": "$item"},
+				"last":  map[string]interface{}{"$last": "$item"},
+			},
+			expected: []map[string]interface{}{
+				{"_id": nil, "min": int32(5), "max": int32(30), "first": "a", "last": "c"},
+			},
+		},
+		{
+			name: "document",
+			id:   map[string]interface{}{"region": "$region", "item": "$item"},
+			acc: map[string]interface{}{
+				"amounts": map[string]interface{}{"$push": "$amount"},
+			},
+			expected: []map[string]interface{}{
+				{"_id": map[string]interface{}{"region": "north", "item": "a"}, "amounts": primitive.A{int32(10), int32(20)}},
+				{"_id": map[string]interface{}{"region": "south", "item": "b"}, "amounts": primitive.A{int32(5)}},
+				{"_id": map[string]interface{}{"region": "north", "item": "c"}, "amounts": primitive.A{int32(30)}},
+			},
+		},
+		{
+			name: "$addToSet",
+			id:   "$region",
+			acc: map[string]interface{}{
+				"items": map[string]interface{}{"$addToSet": "$item"},
+			},
+			expected: []map[string]interface{}{
+				{"_id": "north", "items": primitive.A{"a", "c"}},
+				{"_id": "south", "items": primitive.A{"b"}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := aggregate(t, c, mingodb.Pipeline{mingodb.GroupStage(tt.id, tt.acc)})
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("got %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+}
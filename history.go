@@ -0,0 +1,277 @@
+package mingodb
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// The fields added to the versions of a document kept by
+// EnableVersioning. Each version also has the document's VersionField.
+const (
+	HistoryModifiedAtField = "__modifiedAt" // When the version was replaced
+	HistoryOperationField  = "__operation"  // "update", "replace" or "delete"
+)
+
+// EnableVersioning keeps every version of the collection's documents.
+// Before a document is updated, replaced or deleted, its current
+// version is copied into the collection's history, with the time and
+// the operation that replaced it (see HistoryModifiedAtField and
+// HistoryOperationField). Use GetHistory to read a document's versions
+// and Rollback to restore one.
+//
+// Versions are numbered by the document's VersionField, which is
+// incremented by every write to the document.
+//
+// The history is kept in a bucket named __history_<collection>, which
+// is dropped and renamed along with the collection. Like Use,
+// versioning applies wherever the collection is used, and
+// EnableVersioning should be called before the collection is used by
+// multiple goroutines.
+func (c *Collection) EnableVersioning() {
+	c.versioning = true
+}
+
+// GetHistory returns the versions of the document with the specified
+// _id that are kept in the collection's history, oldest first unless
+// HistoryOptions.NewestFirst is set. The document's current version
+// isn't included. TotalMatched is the number of versions kept.
+func (c *Collection) GetHistory(ctx context.Context, id interface{}, opts ...HistoryOptions) (_ *MultiResult, err error) {
+	ctx, end := c.startOperation(ctx, "getHistory", id, nil)
+	defer func() { end(err) }()
+	o := mergeHistoryOptions(opts)
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	res := &MultiResult{}
+	err = c.read(func(tx *bolt.Tx) error {
+		if _, err := c.bucket(tx); err != nil {
+			return err
+		}
+		hb, err := c.documentHistory(tx, id)
+		if err != nil || hb == nil {
+			return err
+		}
+
+		res.TotalMatched = countKeys(tx, hb)
+		cur := hb.Cursor()
+		first, next := cur.First, cur.Next
+		if o.NewestFirst {
+			first, next = cur.Last, cur.Prev
+		}
+		for k, v := first(); k != nil; k, v = next() {
+			if o.Limit > 0 && len(res.data) == o.Limit {
+				break
+			}
+			data, _, err := decodeDocument(tx, v)
+			if err != nil {
+				return err
+			}
+			res.data = append(res.data, append([]byte(nil), data...))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	res.ResultCount = len(res.data)
+	return res, nil
+}
+
+// Rollback restores the version of the document with the specified
+// _id whose VersionField is version, from the collection's history.
+// The document is restored even if it has since been deleted. Returns
+// ErrNoDocuments if the history has no such version.
+//
+// The restored document is given a new version, so the rollback can
+// itself be rolled back if versioning is enabled. Middleware is called
+// as for an update, with id as the filter and a nil update.
+func (c *Collection) Rollback(ctx context.Context, id interface{}, version int) (err error) {
+	ctx, end := c.startOperation(ctx, "rollback", id, nil)
+	defer func() { end(err) }()
+	if _, _, err := bson.MarshalValue(id); err != nil {
+		return err
+	}
+	if err := c.beforeUpdate(ctx, id, nil); err != nil {
+		return err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	err = c.write(func(tx *bolt.Tx) error {
+		w, err := c.writeTx(ctx, tx)
+		if err != nil {
+			return err
+		}
+		hb, err := c.documentHistory(tx, id)
+		if err != nil {
+			return err
+		}
+
+		// Find the version, and the latest version number.
+		var doc map[string]interface{}
+		var latest int64
+		if hb != nil {
+			cur := hb.Cursor()
+			for k, v := cur.Last(); k != nil; k, v = cur.Prev() {
+				_, m, err := decodeDocument(tx, v)
+				if err != nil {
+					return err
+				}
+				n, _ := toInt(m[VersionField])
+				if n > latest {
+					latest = n
+				}
+				if doc == nil && n == int64(version) {
+					doc = m
+				}
+			}
+		}
+		if doc == nil {
+			return fmt.Errorf("_id %v version %d: %w", id, version, ErrNoDocuments)
+		}
+		delete(doc, HistoryModifiedAtField)
+		delete(doc, HistoryOperationField)
+
+		key, err := w.key(id)
+		if err != nil {
+			return err
+		}
+		if current := w.b.Get(key); current != nil {
+			_, m, err := decodeDocument(tx, current)
+			if err != nil {
+				return err
+			}
+			if n, _ := toInt(m[VersionField]); n > latest {
+				latest = n
+			}
+			doc[VersionField] = latest + 1
+			data, err := bson.Marshal(doc)
+			if err != nil {
+				return err
+			}
+			_, err = w.replace(key, data)
+			return err
+		}
+
+		// It's been deleted, so insert it again.
+		doc[VersionField] = latest + 1
+		data, err := bson.Marshal(doc)
+		if err != nil {
+			return err
+		}
+		return w.insert(id, key, data)
+	})
+	if err != nil {
+		return err
+	}
+	c.afterUpdate(ctx, &UpdateResult{MatchedCount: 1, UpdateCount: 1})
+	return nil
+}
+
+// historyBucketName returns the name of the bucket that holds a
+// collection's history. It has a nested bucket for each document,
+// keyed by the document's key.
+func historyBucketName(collection string) string {
+	return "__history_" + collection
+}
+
+// documentHistory returns the bucket of the document's versions, or
+// nil if none have been kept.
+func (c *Collection) documentHistory(tx *bolt.Tx, id interface{}) (*bolt.Bucket, error) {
+	b := tx.Bucket([]byte(historyBucketName(c.name)))
+	if b == nil {
+		return nil, nil
+	}
+	key, err := documentKey(tx, c.name, id)
+	if err != nil {
+		return nil, err
+	}
+	return b.Bucket(key), nil
+}
+
+// recordHistory adds the document stored under key, old, to the
+// collection's history before op replaces or deletes it.
+func (w *writeTx) recordHistory(op string, key, old []byte) error {
+	_, prev, err := decodeDocument(w.tx, old)
+	if err != nil {
+		return err
+	}
+	version, _ := toInt(prev[VersionField])
+	prev[VersionField] = version
+	prev[HistoryModifiedAtField] = time.Now()
+	prev[HistoryOperationField] = op
+
+	// Store the version under the next number in the document's
+	// history, so that they're kept in order.
+	hb, err := w.tx.CreateBucketIfNotExists([]byte(historyBucketName(w.c.name)))
+	if err != nil {
+		return err
+	}
+	db, err := hb.CreateBucketIfNotExists(key)
+	if err != nil {
+		return err
+	}
+	n, err := db.NextSequence()
+	if err != nil {
+		return err
+	}
+	entry, err := bson.Marshal(prev)
+	if err != nil {
+		return err
+	}
+	if entry, err = encodeDocument(w.tx, entry); err != nil {
+		return err
+	}
+	k := make([]byte, 8)
+	binary.BigEndian.PutUint64(k, n)
+	return db.Put(k, entry)
+}
+
+// dropHistory deletes the collection's history, if it has one.
+func dropHistory(tx *bolt.Tx, collection string) error {
+	err := tx.DeleteBucket([]byte(historyBucketName(collection)))
+	if errors.Is(err, bolt.ErrBucketNotFound) {
+		return nil
+	}
+	return err
+}
+
+// moveHistory gives the dst collection the src collection's history.
+func moveHistory(ctx context.Context, tx *bolt.Tx, src, dst string) error {
+	from := tx.Bucket([]byte(historyBucketName(src)))
+	if from == nil {
+		return nil
+	}
+	to, err := tx.CreateBucket([]byte(historyBucketName(dst)))
+	if err != nil {
+		return err
+	}
+	err = from.ForEach(func(k, _ []byte) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		fb := from.Bucket(k)
+		tb, err := to.CreateBucket(k)
+		if err != nil {
+			return err
+		}
+		if err := tb.SetSequence(fb.Sequence()); err != nil {
+			return err
+		}
+		return fb.ForEach(tb.Put)
+	})
+	if err != nil {
+		return err
+	}
+	return dropHistory(tx, src)
+}
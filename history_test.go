@@ -0,0 +1,102 @@
+package mingodb_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/korrbit/mingodb"
+	"github.com/korrbit/mingodb/testutil"
+)
+
+// historyVersion is a document version kept by EnableVersioning.
+type historyVersion struct {
+	N          int       `bson:"n"`
+	Version    int       `bson:"__version"`
+	Operation  string    `bson:"__operation"`
+	ModifiedAt time.Time `bson:"__modifiedAt"`
+}
+
+// history returns the versions of the document with _id 1.
+func history(t *testing.T, c *mingodb.Collection, opts ...mingodb.HistoryOptions) []historyVersion {
+	t.Helper()
+	res, err := c.GetHistory(context.Background(), 1, opts...)
+	if err != nil {
+		t.Fatalf("GetHistory: %v", err)
+	}
+	var versions []historyVersion
+	for res.Next() {
+		var v historyVersion
+		if err := res.Decode(&v); err != nil {
+			t.Fatalf("Decode: %v", err)
+		}
+		if v.ModifiedAt.IsZero() {
+			t.Errorf("version %d has no %s", v.Version, mingodb.HistoryModifiedAtField)
+		}
+		v.ModifiedAt = time.Time{}
+		versions = append(versions, v)
+	}
+	return versions
+}
+
+// versioned returns a collection with versioning enabled holding the
+// document with _id 1, which has been updated twice and deleted.
+func versioned(t *testing.T) *mingodb.Collection {
+	t.Helper()
+	ctx := context.Background()
+	c := testutil.NewTestDB(t).CollectionMust("items")
+	c.EnableVersioning()
+	testutil.SeedCollection(t, c, map[string]interface{}{"_id": 1, "n": 1})
+	for _, n := range []int{2, 3} {
+		if _, err := c.UpdateOne(ctx, map[string]interface{}{"_id": 1}, map[string]interface{}{"$set": map[string]interface{}{"n": n}}); err != nil {
+			t.Fatalf("UpdateOne: %v", err)
+		}
+	}
+	if _, err := c.DeleteOne(ctx, 1); err != nil {
+		t.Fatalf("DeleteOne: %v", err)
+	}
+	return c
+}
+
+func TestGetHistory(t *testing.T) {
+	c := versioned(t)
+	expected := []historyVersion{
+		{N: 1, Version: 1, Operation: "update"},
+		{N: 2, Version: 2, Operation: "update"},
+		{N: 3, Version: 3, Operation: "delete"},
+	}
+	if versions := history(t, c); !reflect.DeepEqual(versions, expected) {
+		t.Errorf("got versions %+v, expected %+v", versions, expected)
+	}
+	newest := []historyVersion{expected[2], expected[1]}
+	if versions := history(t, c, mingodb.HistoryOptions{NewestFirst: true, Limit: 2}); !reflect.DeepEqual(versions, newest) {
+		t.Errorf("got newest versions %+v, expected %+v", versions, newest)
+	}
+}
+
+func TestRollback(t *testing.T) {
+	ctx := context.Background()
+	c := versioned(t)
+
+	// The deleted document is restored with a new version.
+	if err := c.Rollback(ctx, 1, 2); err != nil {
+		t.Fatalf("Rollback: %v", err)
+	}
+	testutil.AssertDocumentExists(t, c, map[string]interface{}{"_id": 1, "n": 2, mingodb.VersionField: 4})
+
+	// Rolling back the existing document keeps its current version
+	// in the history.
+	if err := c.Rollback(ctx, 1, 1); err != nil {
+		t.Fatalf("Rollback: %v", err)
+	}
+	testutil.AssertDocumentExists(t, c, map[string]interface{}{"_id": 1, "n": 1, mingodb.VersionField: 5})
+	if versions := history(t, c); len(versions) != 4 || versions[3].Version != 4 {
+		t.Errorf("got versions %+v, expected version 4 to be kept", versions)
+	}
+
+	if err := c.Rollback(ctx, 1, 10); !errors.Is(err, mingodb.ErrNoDocuments) {
+		t.Errorf("Rollback returned %v, expected ErrNoDocuments", err)
+	}
+}
@@ -0,0 +1,217 @@
+package mingodb
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"io"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/oklog/ulid/v2"
+	bolt "go.etcd.io/bbolt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// IDGenerator generates the _id of documents inserted without one.
+// The generator is set for a whole database with OpenOptions.IDGenerator
+// or for a single collection with CollectionOptions.IDGenerator.
+type IDGenerator interface {
+	NewID() interface{}
+}
+
+// sequenceGenerator is an IDGenerator whose IDs are generated within
+// the inserting transaction.
+type sequenceGenerator interface {
+	IDGenerator
+	nextID(tx *bolt.Tx, collection string) (interface{}, error)
+}
+
+// ObjectIDGenerator generates primitive.ObjectIDs. It's the default.
+type ObjectIDGenerator struct{}
+
+// NewID returns a new ObjectID.
+func (ObjectIDGenerator) NewID() interface{} {
+	return primitive.NewObjectID()
+}
+
+// UUIDGenerator generates random (version 4) UUIDs, as strings such
+// as "f47ac10b-58cc-4372-a567-0e02b2c3d479".
+type UUIDGenerator struct{}
+
+// NewID returns a new UUID.
+func (UUIDGenerator) NewID() interface{} {
+	return uuid.NewString()
+}
+
+// ULIDGenerator generates ULIDs, as 26-character strings such as
+// "01ARZ3NDEKTSV4RRFFQ69G5FAV". ULIDs sort by the time they were
+// generated, so a collection's documents are scanned in insertion
+// order. It's safe to use from multiple goroutines.
+type ULIDGenerator struct{}
+
+// ulidEntropy is the random source of ULIDGenerator. It's monotonic,
+// so that ULIDs generated in the same millisecond still sort in the
+// order they were generated.
+var ulidEntropy = struct {
+	sync.Mutex
+	r io.Reader
+}{r: ulid.Monotonic(rand.Reader, 0)}
+
+// NewID returns a new ULID.
+func (ULIDGenerator) NewID() interface{} {
+	ulidEntropy.Lock()
+	defer ulidEntropy.Unlock()
+	return ulid.MustNew(ulid.Now(), ulidEntropy.r).String()
+}
+
+// ParseULID parses a ULID generated by ULIDGenerator.
+func ParseULID(s string) (ulid.ULID, error) {
+	return ulid.ParseStrict(s)
+}
+
+// AutoIncrementIDGenerator generates int64 _ids that count up from 1,
+// using the bolt sequence of a bucket named __seq_<collection>.
+//
+// The keys of a collection created with the generator are the _ids
+// as big-endian uint64s, rather than as BSON, so that scans return
+// its documents in insertion order. The _ids themselves are int64s,
+// as BSON has no unsigned integers.
+type AutoIncrementIDGenerator struct{}
+
+// AutoIncrementGenerator is another name for AutoIncrementIDGenerator.
+type AutoIncrementGenerator = AutoIncrementIDGenerator
+
+// NewID returns nil. The IDs are generated within the transaction
+// that inserts the document, so that they're never reused.
+func (AutoIncrementIDGenerator) NewID() interface{} {
+	return nil
+}
+
+// nextID returns the next value of the collection's sequence.
+func (AutoIncrementIDGenerator) nextID(tx *bolt.Tx, collection string) (interface{}, error) {
+	b, err := startSequence(tx, collection)
+	if err != nil {
+		return nil, err
+	}
+	n, err := b.NextSequence()
+	if err != nil {
+		return nil, err
+	}
+	return int64(n), nil
+}
+
+// orderedKeysMarker is the key stored in a collection's sequence
+// bucket when the collection's integer _ids are stored as big-endian
+// keys.
+var orderedKeysMarker = []byte("orderedKeys")
+
+// startSequence returns the collection's sequence bucket, creating it
+// if needed. The keys of a collection without documents are switched
+// to big-endian when its sequence is created, while a collection that
+// already has documents keeps their BSON keys.
+func startSequence(tx *bolt.Tx, collection string) (*bolt.Bucket, error) {
+	name := []byte(sequenceBucketName(collection))
+	if b := tx.Bucket(name); b != nil {
+		return b, nil
+	}
+	b, err := tx.CreateBucket(name)
+	if err != nil {
+		return nil, err
+	}
+	if c := tx.Bucket([]byte(collection)); c != nil {
+		if k, _ := c.Cursor().First(); k != nil {
+			return b, nil
+		}
+	}
+	return b, b.Put(orderedKeysMarker, []byte{1})
+}
+
+// documentKey returns the key of the document with the specified _id
+// in the collection: a big-endian uint64 for an integer _id in a
+// collection with ordered keys (see AutoIncrementIDGenerator), or
+// otherwise the _id as BSON.
+func documentKey(tx *bolt.Tx, collection string, id interface{}) ([]byte, error) {
+	if n, ok := toInt(id); ok {
+		if b := tx.Bucket([]byte(sequenceBucketName(collection))); b != nil && b.Get(orderedKeysMarker) != nil {
+			key := make([]byte, 8)
+			binary.BigEndian.PutUint64(key, uint64(n))
+			return key, nil
+		}
+	}
+	_, key, err := bson.MarshalValue(id)
+	return key, err
+}
+
+// sequenceBucketName returns the name of the bucket whose sequence
+// generates a collection's auto-incrementing _ids.
+func sequenceBucketName(collection string) string {
+	return "__seq_" + collection
+}
+
+// idGenerator returns the collection's IDGenerator.
+func (c *Collection) idGenerator() IDGenerator {
+	switch {
+	case c.ids != nil:
+		return c.ids
+	case c.db.ids != nil:
+		return c.db.ids
+	}
+	return ObjectIDGenerator{}
+}
+
+// prepareSequence starts the collection's sequence if it uses an
+// AutoIncrementIDGenerator, so that its keys are ordered from its
+// first document, even one inserted with its own _id.
+func (c *Collection) prepareSequence(tx *bolt.Tx) error {
+	if _, ok := c.idGenerator().(sequenceGenerator); !ok {
+		return nil
+	}
+	_, err := startSequence(tx, c.name)
+	return err
+}
+
+// newID generates an _id for a document inserted without one.
+func (w *writeTx) newID() (interface{}, error) {
+	gen := w.c.idGenerator()
+	if s, ok := gen.(sequenceGenerator); ok {
+		return s.nextID(w.tx, w.c.name)
+	}
+	return gen.NewID(), nil
+}
+
+// key returns the key of the document with the specified _id.
+func (w *writeTx) key(id interface{}) ([]byte, error) {
+	return documentKey(w.tx, w.c.name, id)
+}
+
+// dropSequence deletes the collection's sequence, if it has one.
+func dropSequence(tx *bolt.Tx, collection string) error {
+	err := tx.DeleteBucket([]byte(sequenceBucketName(collection)))
+	if errors.Is(err, bolt.ErrBucketNotFound) {
+		return nil
+	}
+	return err
+}
+
+// copySequence sets the dst collection's sequence to the src
+// collection's, so that the _ids of documents copied from src
+// aren't generated again for dst. The ordered keys marker is copied
+// too, as the documents are copied with their keys.
+func copySequence(tx *bolt.Tx, src, dst string) error {
+	from := tx.Bucket([]byte(sequenceBucketName(src)))
+	if from == nil {
+		return nil
+	}
+	to, err := tx.CreateBucketIfNotExists([]byte(sequenceBucketName(dst)))
+	if err != nil {
+		return err
+	}
+	if v := from.Get(orderedKeysMarker); v != nil {
+		if err := to.Put(orderedKeysMarker, v); err != nil {
+			return err
+		}
+	}
+	return to.SetSequence(from.Sequence())
+}
@@ -0,0 +1,160 @@
+package mingodb_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/korrbit/mingodb"
+	"github.com/korrbit/mingodb/testutil"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// counter is an IDGenerator that counts up from 100.
+type counter struct{ n int32 }
+
+func (c *counter) NewID() interface{} {
+	c.n++
+	return 100 + c.n
+}
+
+func TestIDGenerators(t *testing.T) {
+	ctx := context.Background()
+	db, err := mingodb.OpenWithOptions(filepath.Join(t.TempDir(), "test.db"), &mingodb.OpenOptions{IDGenerator: mingodb.UUIDGenerator{}})
+	if err != nil {
+		t.Fatalf("OpenWithOptions: %v", err)
+	}
+	defer db.Close()
+
+	// The database's generator.
+	id, err := db.CollectionMust("a").InsertOne(ctx, map[string]interface{}{"n": 1})
+	if err != nil {
+		t.Fatalf("InsertOne: %v", err)
+	}
+	if s, ok := id.(string); !ok {
+		t.Errorf("_id is a %T, expected a string", id)
+	} else if _, err := uuid.Parse(s); err != nil {
+		t.Errorf("_id %q isn't a UUID: %v", s, err)
+	}
+
+	// A collection's own generator.
+	c := db.CollectionMust("b", mingodb.CollectionOptions{IDGenerator: &counter{}})
+	if id, err = c.InsertOne(ctx, map[string]interface{}{"n": 1}); err != nil {
+		t.Fatalf("InsertOne: %v", err)
+	}
+	if id != int32(101) {
+		t.Errorf("got _id %v, expected 101", id)
+	}
+	testutil.AssertDocumentExists(t, c, map[string]interface{}{"_id": 101, "n": 1})
+
+	// An explicit _id is kept.
+	if id, err = c.InsertOne(ctx, map[string]interface{}{"_id": "x"}); err != nil {
+		t.Fatalf("InsertOne: %v", err)
+	}
+	if id != "x" {
+		t.Errorf("got _id %v, expected x", id)
+	}
+
+	// The default.
+	if id, err = testutil.NewTestDB(t).CollectionMust("c").InsertOne(ctx, map[string]interface{}{}); err != nil {
+		t.Fatalf("InsertOne: %v", err)
+	}
+	if _, ok := id.(primitive.ObjectID); !ok {
+		t.Errorf("_id is a %T, expected a primitive.ObjectID", id)
+	}
+}
+
+func TestAutoIncrementIDs(t *testing.T) {
+	ctx := context.Background()
+	c := testutil.NewTestDB(t).CollectionMust("items", mingodb.CollectionOptions{IDGenerator: mingodb.AutoIncrementIDGenerator{}})
+	docs := make([]interface{}, 300)
+	for i := range docs {
+		docs[i] = map[string]interface{}{"n": i}
+	}
+	ids, err := c.InsertMany(ctx, docs)
+	if err != nil {
+		t.Fatalf("InsertMany: %v", err)
+	}
+	if ids[0] != int64(1) || ids[299] != int64(300) {
+		t.Errorf("got _ids %v to %v, expected 1 to 300", ids[0], ids[299])
+	}
+
+	// Documents are scanned in insertion order.
+	res, err := c.Find(ctx, nil)
+	if err != nil {
+		t.Fatalf("Find: %v", err)
+	}
+	var want int64 = 1
+	for res.Next() {
+		var doc struct {
+			ID int64 `bson:"_id"`
+		}
+		if err := res.Decode(&doc); err != nil {
+			t.Fatalf("Decode: %v", err)
+		}
+		if doc.ID != want {
+			t.Fatalf("got _id %d, expected %d", doc.ID, want)
+		}
+		want++
+	}
+
+	// Any integer type finds the document.
+	if _, err := c.GetByID(ctx, 256); err != nil {
+		t.Errorf("GetByID: %v", err)
+	}
+
+	// Deleted _ids aren't reused.
+	if _, err := c.DeleteOne(ctx, int64(300)); err != nil {
+		t.Fatalf("DeleteOne: %v", err)
+	}
+	id, err := c.InsertOne(ctx, map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("InsertOne: %v", err)
+	}
+	if id != int64(301) {
+		t.Errorf("got _id %v, expected 301", id)
+	}
+}
+
+func TestULIDs(t *testing.T) {
+	ctx := context.Background()
+	c := testutil.NewTestDB(t).CollectionMust("items", mingodb.CollectionOptions{IDGenerator: mingodb.ULIDGenerator{}})
+	docs := make([]interface{}, 100)
+	for i := range docs {
+		docs[i] = map[string]interface{}{"n": i}
+	}
+	ids, err := c.InsertMany(ctx, docs)
+	if err != nil {
+		t.Fatalf("InsertMany: %v", err)
+	}
+	for i, id := range ids {
+		s, ok := id.(string)
+		if !ok {
+			t.Fatalf("_id is a %T, expected a string", id)
+		}
+		if _, err := mingodb.ParseULID(s); err != nil {
+			t.Fatalf("ParseULID(%q): %v", s, err)
+		}
+		if i > 0 && s <= ids[i-1].(string) {
+			t.Fatalf("_id %s doesn't sort after %s", s, ids[i-1])
+		}
+	}
+
+	// Documents are scanned in insertion order.
+	res, err := c.Find(ctx, nil)
+	if err != nil {
+		t.Fatalf("Find: %v", err)
+	}
+	for i := 0; res.Next(); i++ {
+		var doc struct {
+			N int `bson:"n"`
+		}
+		if err := res.Decode(&doc); err != nil {
+			t.Fatalf("Decode: %v", err)
+		}
+		if doc.N != i {
+			t.Fatalf("document %d has n %d", i, doc.N)
+		}
+	}
+}
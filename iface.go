@@ -0,0 +1,110 @@
+package mingodb
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// CollectionIface has the methods of Collection, so that code can
+// depend on it rather than on *Collection, and tests can pass it a
+// mock such as mock.MockCollection. *Collection and TxCollection
+// implement it.
+type CollectionIface interface {
+	Name() string
+	Database() *Database
+	Drop() error
+	Stats(ctx context.Context) (*CollectionStats, error)
+
+	// Configuration
+	Use(mw ...Middleware)
+	SetValidator(fn func(doc map[string]interface{}) error)
+	SetJSONSchema(schema []byte) error
+	SetCap(maxDocuments int) error
+	EnableSoftDelete(deletedAtField string)
+	EnableVersioning()
+
+	// Reads
+	Find(ctx context.Context, filter interface{}, opts ...FindOptions) (*MultiResult, error)
+	FindOne(ctx context.Context, filter interface{}, opts ...FindOptions) (*SingleResult, error)
+	FindWithDeleted(ctx context.Context, filter interface{}, opts ...FindOptions) (*MultiResult, error)
+	GetByID(ctx context.Context, id interface{}) (interface{}, error)
+	GetByIDInto(ctx context.Context, id interface{}, result interface{}) error
+	GetByIDs(ctx context.Context, ids []interface{}) ([]interface{}, error)
+	CountDocuments(ctx context.Context, filter interface{}) (int, error)
+	Distinct(ctx context.Context, field string, filter interface{}) ([]interface{}, error)
+	Aggregate(ctx context.Context, pipeline Pipeline) (*MultiResult, error)
+	MapReduce(ctx context.Context, mapFn func(doc map[string]interface{}) []KeyValue, reduceFn func(key interface{}, values []interface{}) interface{}, out string) error
+	Explain(ctx context.Context, filter interface{}, opts ...FindOptions) (QueryPlan, error)
+	Query() *Query
+	Watch(ctx context.Context, pipeline []interface{}) (<-chan ChangeEvent, error)
+	GetHistory(ctx context.Context, id interface{}, opts ...HistoryOptions) (*MultiResult, error)
+
+	// Writes
+	InsertOne(ctx context.Context, doc interface{}) (InsertID, error)
+	InsertMany(ctx context.Context, docs []interface{}) ([]InsertID, error)
+	UpdateOne(ctx context.Context, filter interface{}, update interface{}) (*UpdateResult, error)
+	UpdateMany(ctx context.Context, filter interface{}, update interface{}) (*UpdateResult, error)
+	UpsertOne(ctx context.Context, filter interface{}, update interface{}) (*UpsertResult, error)
+	ReplaceOne(ctx context.Context, filter interface{}, replacement interface{}) (*UpdateResult, error)
+	OptimisticUpdate(ctx context.Context, filter interface{}, update interface{}, version int64) (*UpdateResult, error)
+	FindOneAndUpdate(ctx context.Context, filter interface{}, update interface{}, opts ...FindOneAndUpdateOptions) (*SingleResult, error)
+	FindOneAndDelete(ctx context.Context, filter interface{}, opts ...FindOptions) (*SingleResult, error)
+	DeleteOne(ctx context.Context, filter interface{}) (*DeleteResult, error)
+	DeleteMany(ctx context.Context, filter interface{}) (*DeleteResult, error)
+	HardDelete(ctx context.Context, filter interface{}) (*DeleteResult, error)
+	BulkWrite(ctx context.Context, ops []WriteOperation, opts ...BulkWriteOptions) (*BulkWriteResult, error)
+	Rollback(ctx context.Context, id interface{}, version int) error
+
+	// Indexes
+	CreateIndex(ctx context.Context, key string, opts ...IndexOptions) (string, error)
+	CreateCompoundIndex(ctx context.Context, keys []IndexKey, opts ...IndexOptions) (string, error)
+	CreateTextIndex(ctx context.Context, fields []string, opts ...TextIndexOptions) (string, error)
+	CreateTTLIndex(ctx context.Context, field string, expiry time.Duration) (string, error)
+	ListIndexes(ctx context.Context) ([]IndexInfo, error)
+
+	// Import and export
+	Export(ctx context.Context, w io.Writer, opts ...ExportOptions) (int, error)
+	Import(ctx context.Context, r io.Reader, opts ...ImportOptions) (int, error)
+	ExportCSV(ctx context.Context, w io.Writer, fields []string, opts ...FindOptions) error
+	ImportCSV(ctx context.Context, r io.Reader, opts ...CSVImportOptions) (int, error)
+}
+
+var (
+	_ CollectionIface = (*Collection)(nil)
+	_ CollectionIface = TxCollection{}
+)
+
+// DatabaseIface has the methods of Database that code using a
+// database needs most, so that tests can pass it a mock such as
+// mock.MockDatabase. Database.Collection returns a *Collection rather
+// than a CollectionIface, so *Database doesn't implement DatabaseIface
+// itself; Database.Iface returns an implementation that uses the
+// database.
+type DatabaseIface interface {
+	Collection(name string) (CollectionIface, error)
+	ListCollections(ctx context.Context) ([]string, error)
+	BeginTx(ctx context.Context) (*Transaction, error)
+	Stats() (*DatabaseStats, error)
+	Close() error
+}
+
+// Iface returns a DatabaseIface that uses the database.
+func (db *Database) Iface() DatabaseIface {
+	return databaseIface{db}
+}
+
+// databaseIface is the DatabaseIface returned by Database.Iface.
+type databaseIface struct {
+	*Database
+}
+
+// Collection returns the collection with the specified name (see
+// Database.Collection).
+func (d databaseIface) Collection(name string) (CollectionIface, error) {
+	c, err := d.Database.Collection(name)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
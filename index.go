@@ -0,0 +1,562 @@
+package mingodb
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/x/bsonx/bsoncore"
+)
+
+// indexesBucket is the name of the bucket that holds the definitions
+// of every collection's indexes, in a nested bucket per collection.
+const indexesBucket = "__indexes"
+
+// indexEntriesBucket is the name of the bucket that holds the entries
+// of every collection's indexes, in a nested bucket per collection that
+// holds a bucket per index. Nesting them keeps the entries of indexes
+// on different collections apart, whatever their names.
+const indexEntriesBucket = "__idx"
+
+// IndexKey is a field covered by an index.
+type IndexKey struct {
+	Field string `bson:"field"`
+	Dir   int    `bson:"dir"` // 1 for ascending order, -1 for descending order
+}
+
+// IndexOptions configures CreateIndex.
+type IndexOptions struct {
+	// Name is the name of the index. Defaults to the field and
+	// direction, such as "email_1".
+	Name string
+
+	// Unique rejects documents with the same value as an existing
+	// document for the indexed field. Inserts and updates that would
+	// break the constraint return ErrDuplicateKey. Documents missing
+	// the field are indexed as null, so only one is allowed unless
+	// the index is also sparse.
+	Unique bool
+
+	// Sparse only indexes documents that have the indexed field
+	// (or, for a compound index, at least one of the fields).
+	Sparse bool
+}
+
+// IndexInfo describes an index. It's returned by ListIndexes.
+type IndexInfo struct {
+	Name   string     `bson:"name"`
+	Keys   []IndexKey `bson:"keys"`
+	Unique bool       `bson:"unique"`
+	Sparse bool       `bson:"sparse"`
+
+	// TTL is true for a TTL index, which deletes documents once
+	// ExpireAfter has passed since the date in the indexed field.
+	// See CreateTTLIndex.
+	TTL         bool          `bson:"ttl,omitempty"`
+	ExpireAfter time.Duration `bson:"expireAfter,omitempty"`
+
+	// Text is true for a text index, which indexes the words in its
+	// fields using the rules of DefaultLanguage. See CreateTextIndex.
+	Text            bool   `bson:"text,omitempty"`
+	DefaultLanguage string `bson:"defaultLanguage,omitempty"`
+}
+
+// index is an index of a collection within a transaction.
+type index struct {
+	IndexInfo
+	b *bolt.Bucket // Maps index keys to the primary keys of documents
+}
+
+// indexEntry is the value stored under an index key.
+type indexEntry struct {
+	Keys [][]byte `bson:"keys"` // Primary keys of the indexed documents
+}
+
+// indexEntries returns the bucket that holds the entries of the
+// named collection's indexes, or nil if it doesn't have any.
+func indexEntries(tx *bolt.Tx, collection string) *bolt.Bucket {
+	root := tx.Bucket([]byte(indexEntriesBucket))
+	if root == nil {
+		return nil
+	}
+	return root.Bucket([]byte(collection))
+}
+
+// createIndexEntries returns the bucket that holds the entries of the
+// named collection's indexes, creating it if it doesn't exist.
+func createIndexEntries(tx *bolt.Tx, collection string) (*bolt.Bucket, error) {
+	root, err := tx.CreateBucketIfNotExists([]byte(indexEntriesBucket))
+	if err != nil {
+		return nil, err
+	}
+	return root.CreateBucketIfNotExists([]byte(collection))
+}
+
+// CreateIndex creates an index on the field in key and returns the
+// index's name. Prefix the field with "-" to index it in descending
+// order, such as "-createdAt".
+//
+// The index is built from the documents already in the collection and
+// is then kept up to date as documents are inserted, updated and deleted.
+// Creating an index that already exists does nothing, but an index with
+// the same name and different options returns ErrIndexExists.
+func (c *Collection) CreateIndex(ctx context.Context, key string, opts ...IndexOptions) (string, error) {
+	k := IndexKey{Field: key, Dir: 1}
+	if strings.HasPrefix(key, "-") {
+		k = IndexKey{Field: key[1:], Dir: -1}
+	}
+	return c.createIndex(ctx, []IndexKey{k}, opts)
+}
+
+// CreateCompoundIndex creates an index on multiple fields and returns
+// the index's name. Its keys are made of the fields' values in order,
+// so the index can be used to find documents by the index's leading
+// fields. For example, an index on lastName then firstName can find
+// documents by lastName, or by both lastName and firstName.
+//
+// The name defaults to each field and direction in turn, such as
+// "lastName_1_firstName_1". Otherwise, it behaves like CreateIndex.
+func (c *Collection) CreateCompoundIndex(ctx context.Context, keys []IndexKey, opts ...IndexOptions) (string, error) {
+	return c.createIndex(ctx, keys, opts)
+}
+
+// createIndex creates an index on the keys.
+func (c *Collection) createIndex(ctx context.Context, keys []IndexKey, opts []IndexOptions) (string, error) {
+	if len(keys) == 0 {
+		return "", fmt.Errorf("%w: no keys", ErrInvalidIndex)
+	}
+	seen := make(map[string]bool)
+	var parts []string
+	for _, k := range keys {
+		if k.Field == "" || strings.HasPrefix(k.Field, "$") || seen[k.Field] {
+			return "", fmt.Errorf("%w: invalid key %q", ErrInvalidIndex, k.Field)
+		}
+		if k.Dir != 1 && k.Dir != -1 {
+			return "", fmt.Errorf("%w: direction for %s must be 1 or -1", ErrInvalidIndex, k.Field)
+		}
+		seen[k.Field] = true
+		parts = append(parts, fmt.Sprintf("%s_%d", k.Field, k.Dir))
+	}
+
+	info := IndexInfo{Keys: append([]IndexKey(nil), keys...)}
+	for _, opt := range opts {
+		if opt.Name != "" {
+			info.Name = opt.Name
+		}
+		info.Unique = opt.Unique
+		info.Sparse = opt.Sparse
+	}
+	if info.Name == "" {
+		info.Name = strings.Join(parts, "_")
+	}
+	return c.buildIndex(ctx, info)
+}
+
+// buildIndex creates the index described by info and indexes the
+// documents already in the collection.
+func (c *Collection) buildIndex(ctx context.Context, info IndexInfo) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	err := c.write(func(tx *bolt.Tx) error {
+		b, err := c.bucket(tx)
+		if err != nil {
+			return err
+		}
+		meta, err := c.indexMeta(tx)
+		if err != nil {
+			return err
+		}
+
+		// Does the index already exist?
+		if v := meta.Get([]byte(info.Name)); v != nil {
+			var existing IndexInfo
+			if err := bson.Unmarshal(v, &existing); err != nil {
+				return err
+			}
+			if !sameIndex(existing, info) {
+				return fmt.Errorf("%s: %w", info.Name, ErrIndexExists)
+			}
+			return nil
+		}
+
+		// Only one text index is allowed.
+		if info.Text {
+			indexes, err := c.indexes(tx)
+			if err != nil {
+				return err
+			}
+			for _, idx := range indexes {
+				if idx.Text {
+					return fmt.Errorf("%w: collection already has text index %s", ErrIndexExists, idx.Name)
+				}
+			}
+		}
+
+		// Create the index's bucket, replacing any left behind,
+		// and index the existing documents.
+		entries, err := createIndexEntries(tx, c.name)
+		if err != nil {
+			return err
+		}
+		name := []byte(info.Name)
+		if err := entries.DeleteBucket(name); err != nil && !errors.Is(err, bolt.ErrBucketNotFound) {
+			return err
+		}
+		ib, err := entries.CreateBucket(name)
+		if err != nil {
+			return err
+		}
+		idx := &index{IndexInfo: info, b: ib}
+		cur := b.Cursor()
+		for pk, v := cur.First(); pk != nil; pk, v = cur.Next() {
+			// Has the operation been cancelled?
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			_, doc, err := decodeDocument(tx, v)
+			if err != nil {
+				return err
+			}
+			if err := idx.add(pk, doc); err != nil {
+				return err
+			}
+		}
+
+		data, err := bson.Marshal(info)
+		if err != nil {
+			return err
+		}
+		return meta.Put([]byte(info.Name), data)
+	})
+	if err != nil {
+		return "", err
+	}
+	return info.Name, nil
+}
+
+// ListIndexes returns the collection's indexes, sorted by name.
+func (c *Collection) ListIndexes(ctx context.Context) ([]IndexInfo, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var infos []IndexInfo
+	err := c.read(func(tx *bolt.Tx) error {
+		if _, err := c.bucket(tx); err != nil {
+			return err
+		}
+		indexes, err := c.indexes(tx)
+		if err != nil {
+			return err
+		}
+		for _, idx := range indexes {
+			infos = append(infos, idx.IndexInfo)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return infos, nil
+}
+
+// indexMeta returns the bucket that holds the definitions of the
+// collection's indexes, creating it if it doesn't exist.
+func (c *Collection) indexMeta(tx *bolt.Tx) (*bolt.Bucket, error) {
+	root, err := tx.CreateBucketIfNotExists([]byte(indexesBucket))
+	if err != nil {
+		return nil, err
+	}
+	return root.CreateBucketIfNotExists([]byte(c.name))
+}
+
+// indexes returns the collection's indexes within tx, sorted by name.
+func (c *Collection) indexes(tx *bolt.Tx) ([]*index, error) {
+	return loadIndexes(tx, c.name)
+}
+
+// loadIndexes returns the indexes of the named collection within
+// tx, sorted by name.
+func loadIndexes(tx *bolt.Tx, collection string) ([]*index, error) {
+	root := tx.Bucket([]byte(indexesBucket))
+	if root == nil {
+		return nil, nil
+	}
+	meta := root.Bucket([]byte(collection))
+	if meta == nil {
+		return nil, nil
+	}
+
+	entries := indexEntries(tx, collection)
+	var indexes []*index
+	err := meta.ForEach(func(k, v []byte) error {
+		idx := &index{}
+		if err := bson.Unmarshal(v, &idx.IndexInfo); err != nil {
+			return err
+		}
+		if entries != nil {
+			idx.b = entries.Bucket([]byte(idx.Name))
+		}
+		if idx.b == nil {
+			return fmt.Errorf("%w: missing bucket for index %s", ErrInvalidIndex, idx.Name)
+		}
+		indexes = append(indexes, idx)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return indexes, nil
+}
+
+// dropIndexes deletes every index of the collection.
+func (c *Collection) dropIndexes(tx *bolt.Tx) error {
+	if root := tx.Bucket([]byte(indexEntriesBucket)); root != nil && root.Bucket([]byte(c.name)) != nil {
+		if err := root.DeleteBucket([]byte(c.name)); err != nil {
+			return err
+		}
+	}
+	if root := tx.Bucket([]byte(indexesBucket)); root != nil && root.Bucket([]byte(c.name)) != nil {
+		return root.DeleteBucket([]byte(c.name))
+	}
+	return nil
+}
+
+// sameIndex reports whether two indexes have the same definition.
+func sameIndex(a, b IndexInfo) bool {
+	if a.Name != b.Name || a.Unique != b.Unique || a.Sparse != b.Sparse || len(a.Keys) != len(b.Keys) ||
+		a.TTL != b.TTL || a.ExpireAfter != b.ExpireAfter || a.Text != b.Text || a.DefaultLanguage != b.DefaultLanguage {
+		return false
+	}
+	for i := range a.Keys {
+		if a.Keys[i] != b.Keys[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// entries returns the index keys of doc. A document has a key for
+// each element of an indexed array field, and a missing field is
+// indexed as null unless the index is sparse.
+func (idx *index) entries(doc map[string]interface{}) ([][]byte, error) {
+	if idx.TTL {
+		return idx.ttlEntries(doc), nil
+	}
+	if idx.Text {
+		return idx.textEntries(doc), nil
+	}
+
+	// Find the values of each indexed field.
+	values := make([]primitive.A, len(idx.Keys))
+	var found bool
+	for i, k := range idx.Keys {
+		v, ok := lookupPath(doc, k.Field)
+		found = found || ok
+		if arr, isArray := v.(primitive.A); isArray && len(arr) > 0 {
+			values[i] = arr
+		} else {
+			values[i] = primitive.A{v}
+		}
+	}
+	if idx.Sparse && !found {
+		return nil, nil
+	}
+
+	// Build a key from every combination of the values.
+	keys := [][]byte{nil}
+	for _, vals := range values {
+		var next [][]byte
+		for _, prefix := range keys {
+			for _, v := range vals {
+				enc, err := encodeIndexValue(v)
+				if err != nil {
+					return nil, err
+				}
+				next = append(next, append(append([]byte(nil), prefix...), enc...))
+			}
+		}
+		keys = next
+	}
+
+	// Remove duplicates.
+	sort.Slice(keys, func(i, j int) bool { return bytes.Compare(keys[i], keys[j]) < 0 })
+	out := keys[:0]
+	for i, k := range keys {
+		if i == 0 || !bytes.Equal(k, keys[i-1]) {
+			out = append(out, k)
+		}
+	}
+	return out, nil
+}
+
+// add adds the document stored under pk to the index.
+func (idx *index) add(pk []byte, doc map[string]interface{}) error {
+	keys, err := idx.entries(doc)
+	if err != nil {
+		return err
+	}
+	for _, k := range keys {
+		if err := idx.addEntry(k, pk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// remove removes the document stored under pk from the index.
+func (idx *index) remove(pk []byte, doc map[string]interface{}) error {
+	keys, err := idx.entries(doc)
+	if err != nil {
+		return err
+	}
+	for _, k := range keys {
+		if err := idx.removeEntry(k, pk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// lookup returns the primary keys stored under the index key.
+func (idx *index) lookup(key []byte) ([][]byte, error) {
+	v := idx.b.Get(key)
+	if v == nil {
+		return nil, nil
+	}
+	var e indexEntry
+	if err := bson.Unmarshal(v, &e); err != nil {
+		return nil, err
+	}
+	return e.Keys, nil
+}
+
+// addEntry adds pk to the primary keys stored under the index key.
+func (idx *index) addEntry(key, pk []byte) error {
+	pks, err := idx.lookup(key)
+	if err != nil {
+		return err
+	}
+	for _, k := range pks {
+		if bytes.Equal(k, pk) {
+			return nil
+		}
+	}
+
+	// Is another document already using the key?
+	if idx.Unique && len(pks) > 0 {
+		return fmt.Errorf("index %s: value %v: %w", idx.Name, decodeIndexKey(key), ErrDuplicateKey)
+	}
+	return idx.putEntry(key, append(pks, append([]byte(nil), pk...)))
+}
+
+// removeEntry removes pk from the primary keys stored under the
+// index key, deleting the key once no documents are left.
+func (idx *index) removeEntry(key, pk []byte) error {
+	pks, err := idx.lookup(key)
+	if err != nil {
+		return err
+	}
+	out := pks[:0]
+	for _, k := range pks {
+		if !bytes.Equal(k, pk) {
+			out = append(out, k)
+		}
+	}
+	if len(out) == 0 {
+		return idx.b.Delete(key)
+	}
+	return idx.putEntry(key, out)
+}
+
+// putEntry stores the primary keys under the index key.
+func (idx *index) putEntry(key []byte, pks [][]byte) error {
+	data, err := bson.Marshal(indexEntry{Keys: pks})
+	if err != nil {
+		return err
+	}
+	return idx.b.Put(key, data)
+}
+
+// encodeIndexValue serializes a value for use in an index key. Values
+// that valuesEqual considers equal have the same encoding, so numbers
+// are converted to a common type and maps have their keys sorted.
+func encodeIndexValue(v interface{}) ([]byte, error) {
+	t, b, err := bson.MarshalValue(indexValue(v))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIndex, err)
+	}
+	return append([]byte{byte(t)}, b...), nil
+}
+
+// decodeIndexKey returns the values in an index key, for use in
+// error messages. A key with a single value returns the value itself.
+func decodeIndexKey(key []byte) interface{} {
+	var values []interface{}
+	for len(key) > 0 {
+		t := bsontype.Type(key[0])
+		v, rem, ok := bsoncore.ReadValue(key[1:], t)
+		if !ok {
+			break
+		}
+		var val interface{}
+		if err := (bson.RawValue{Type: t, Value: v.Data}).Unmarshal(&val); err != nil {
+			break
+		}
+		values = append(values, val)
+		key = rem
+	}
+	if len(values) == 1 {
+		return values[0]
+	}
+	return values
+}
+
+// indexValue converts v into its canonical form for indexing.
+func indexValue(v interface{}) interface{} {
+	if f, ok := toFloat(v); ok {
+		// Integers, and floats that hold an integer, become int64s.
+		if i, ok := toInt(v); ok {
+			return i
+		}
+		if f == math.Trunc(f) && f >= math.MinInt64 && f < math.MaxInt64 {
+			return int64(f)
+		}
+		return f
+	}
+
+	switch v := v.(type) {
+	case nil:
+		return primitive.Null{}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		d := make(bson.D, len(keys))
+		for i, k := range keys {
+			d[i] = bson.E{Key: k, Value: indexValue(v[k])}
+		}
+		return d
+	case primitive.A:
+		a := make(primitive.A, len(v))
+		for i, elem := range v {
+			a[i] = indexValue(elem)
+		}
+		return a
+	}
+	return v
+}
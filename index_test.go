@@ -0,0 +1,239 @@
+package mingodb_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/korrbit/mingodb"
+	"github.com/korrbit/mingodb/testutil"
+)
+
+func TestIndexNamesDoNotCollideAcrossCollections(t *testing.T) {
+	ctx := context.Background()
+	db := testutil.NewTestDB(t)
+
+	// "a_b" + "c_1" and "a" + "b_c_1" once shared an entries bucket.
+	ab := db.CollectionMust("a_b")
+	testutil.SeedCollection(t, ab,
+		map[string]interface{}{"_id": 1, "c": "x"},
+		map[string]interface{}{"_id": 2, "c": "y"},
+	)
+	if _, err := ab.CreateIndex(ctx, "c", mingodb.IndexOptions{Name: "c_1"}); err != nil {
+		t.Fatalf("CreateIndex: %v", err)
+	}
+	a := db.CollectionMust("a")
+	testutil.SeedCollection(t, a, map[string]interface{}{"_id": 1, "b": "z"})
+	if _, err := a.CreateIndex(ctx, "b", mingodb.IndexOptions{Name: "b_c_1"}); err != nil {
+		t.Fatalf("CreateIndex: %v", err)
+	}
+
+	// Building the second index mustn't have replaced the first's entries.
+	plan, err := ab.Explain(ctx, map[string]interface{}{"c": "x"})
+	if err != nil {
+		t.Fatalf("Explain: %v", err)
+	}
+	if plan.IndexName != "c_1" {
+		t.Fatalf("query planned with index %q, expected c_1", plan.IndexName)
+	}
+	testutil.AssertDocumentCount(t, ab, map[string]interface{}{"c": "x"}, 1)
+	testutil.AssertDocumentCount(t, ab, map[string]interface{}{"c": "y"}, 1)
+
+	// Nor should dropping the second collection.
+	if err := a.Drop(); err != nil {
+		t.Fatalf("Drop: %v", err)
+	}
+	testutil.AssertDocumentCount(t, ab, map[string]interface{}{"c": "y"}, 1)
+	indexes, err := ab.ListIndexes(ctx)
+	if err != nil {
+		t.Fatalf("ListIndexes: %v", err)
+	}
+	if len(indexes) != 1 || indexes[0].Name != "c_1" {
+		t.Errorf("got indexes %v, expected only c_1", indexes)
+	}
+}
+
+func TestCopyCollectionWithIndexes(t *testing.T) {
+	ctx := context.Background()
+	db := testutil.NewTestDB(t)
+	src := db.CollectionMust("src")
+	testutil.SeedCollection(t, src,
+		map[string]interface{}{"_id": 1, "email": "a@example.com"},
+		map[string]interface{}{"_id": 2, "email": "b@example.com"},
+	)
+	if _, err := src.CreateIndex(ctx, "email", mingodb.IndexOptions{Unique: true}); err != nil {
+		t.Fatalf("CreateIndex: %v", err)
+	}
+	if err := db.CopyCollection(ctx, "src", "dst", mingodb.CopyOptions{IncludeIndexes: true}); err != nil {
+		t.Fatalf("CopyCollection: %v", err)
+	}
+
+	dst := db.CollectionMust("dst")
+	testutil.AssertDocumentCount(t, dst, map[string]interface{}{"email": "b@example.com"}, 1)
+	if _, err := dst.InsertOne(ctx, map[string]interface{}{"email": "a@example.com"}); err == nil {
+		t.Error("inserted a duplicate email into the copy's unique index")
+	}
+}
+
+func TestCreateIndex(t *testing.T) {
+	ctx := context.Background()
+	c := people(t)
+
+	name, err := c.CreateIndex(ctx, "city")
+	if err != nil {
+		t.Fatalf("CreateIndex: %v", err)
+	}
+	if name != "city_1" {
+		t.Errorf("got index name %q, expected city_1", name)
+	}
+	if name, err = c.CreateIndex(ctx, "-age", mingodb.IndexOptions{Name: "by_age", Sparse: true}); err != nil {
+		t.Fatalf("CreateIndex: %v", err)
+	}
+	if name != "by_age" {
+		t.Errorf("got index name %q, expected by_age", name)
+	}
+
+	// Creating the same index again does nothing.
+	if _, err := c.CreateIndex(ctx, "city"); err != nil {
+		t.Errorf("CreateIndex: %v", err)
+	}
+	if _, err := c.CreateIndex(ctx, "city", mingodb.IndexOptions{Unique: true}); !errors.Is(err, mingodb.ErrIndexExists) {
+		t.Errorf("CreateIndex returned %v, expected ErrIndexExists", err)
+	}
+
+	indexes, err := c.ListIndexes(ctx)
+	if err != nil {
+		t.Fatalf("ListIndexes: %v", err)
+	}
+	expected := []mingodb.IndexInfo{
+		{Name: "by_age", Keys: []mingodb.IndexKey{{Field: "age", Dir: -1}}, Sparse: true},
+		{Name: "city_1", Keys: []mingodb.IndexKey{{Field: "city", Dir: 1}}},
+	}
+	if !reflect.DeepEqual(indexes, expected) {
+		t.Errorf("got %+v, expected %+v", indexes, expected)
+	}
+
+	// Documents inserted later are indexed too.
+	testutil.SeedCollection(t, c, map[string]interface{}{"_id": 4, "city": "Rome"})
+	if got := findIDs(t, c, map[string]interface{}{"city": "Rome"}, mingodb.FindOptions{UseIndex: "city_1"}); !reflect.DeepEqual(got, []int32{4}) {
+		t.Errorf("found %v through the index, expected [4]", got)
+	}
+}
+
+func TestUniqueIndex(t *testing.T) {
+	ctx := context.Background()
+	c := people(t)
+	if _, err := c.CreateIndex(ctx, "name", mingodb.IndexOptions{Unique: true}); err != nil {
+		t.Fatalf("CreateIndex: %v", err)
+	}
+	tests := []struct {
+		name  string
+		write func() error
+	}{
+		{"InsertOne", func() error {
+			_, err := c.InsertOne(ctx, map[string]interface{}{"name": "Alice"})
+			return err
+		}},
+		{"InsertMany", func() error {
+			_, err := c.InsertMany(ctx, []interface{}{
+				map[string]interface{}{"name": "Dave"},
+				map[string]interface{}{"name": "Dave"},
+			})
+			return err
+		}},
+		{"UpdateOne", func() error {
+			_, err := c.UpdateOne(ctx, map[string]interface{}{"_id": 2}, map[string]interface{}{"$set": map[string]interface{}{"name": "Alice"}})
+			return err
+		}},
+		{"UpdateMany", func() error {
+			_, err := c.UpdateMany(ctx, map[string]interface{}{"city": "Paris"}, map[string]interface{}{"$set": map[string]interface{}{"name": "Eve"}})
+			return err
+		}},
+		{"ReplaceOne", func() error {
+			_, err := c.ReplaceOne(ctx, map[string]interface{}{"_id": 2}, map[string]interface{}{"name": "Carol"})
+			return err
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.write(); !errors.Is(err, mingodb.ErrDuplicateKey) {
+				t.Errorf("got %v, expected ErrDuplicateKey", err)
+			}
+		})
+	}
+	if got := findIDs(t, c, nil); !reflect.DeepEqual(got, []int32{1, 2, 3}) {
+		t.Errorf("collection holds %v, expected [1 2 3]", got)
+	}
+	testutil.AssertDocumentExists(t, c, map[string]interface{}{"_id": 2, "name": "Bob"})
+
+	// A document can keep its own value.
+	if _, err := c.UpdateOne(ctx, map[string]interface{}{"_id": 2}, map[string]interface{}{"$set": map[string]interface{}{"name": "Bob", "age": 26}}); err != nil {
+		t.Errorf("UpdateOne: %v", err)
+	}
+}
+
+func TestCompoundIndex(t *testing.T) {
+	ctx := context.Background()
+	c := testutil.NewTestDB(t).CollectionMust("people")
+	testutil.SeedCollection(t, c,
+		map[string]interface{}{"_id": 1, "lastName": "Smith", "firstName": "Bob"},
+		map[string]interface{}{"_id": 2, "lastName": "Jones", "firstName": "Alice"},
+		map[string]interface{}{"_id": 3, "lastName": "Smith", "firstName": "Alice"},
+	)
+	name, err := c.CreateCompoundIndex(ctx, []mingodb.IndexKey{{Field: "lastName", Dir: 1}, {Field: "firstName", Dir: 1}}, mingodb.IndexOptions{Unique: true})
+	if err != nil {
+		t.Fatalf("CreateCompoundIndex: %v", err)
+	}
+	if name != "lastName_1_firstName_1" {
+		t.Errorf("got index name %q, expected lastName_1_firstName_1", name)
+	}
+
+	tests := []struct {
+		name     string
+		filter   map[string]interface{}
+		fields   []string
+		expected []int32
+	}{
+		{"leading field", map[string]interface{}{"lastName": "Smith"}, []string{"lastName"}, []int32{1, 3}},
+		{"both fields", map[string]interface{}{"lastName": "Smith", "firstName": "Alice"}, []string{"lastName", "firstName"}, []int32{3}},
+		{"trailing field", map[string]interface{}{"firstName": "Alice"}, nil, []int32{2, 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan, err := c.Explain(ctx, tt.filter)
+			if err != nil {
+				t.Fatalf("Explain: %v", err)
+			}
+			if !reflect.DeepEqual(plan.IndexFields, tt.fields) {
+				t.Errorf("planned with index fields %v, expected %v", plan.IndexFields, tt.fields)
+			}
+			got := findIDs(t, c, tt.filter)
+			sort.Slice(got, func(i, j int) bool { return got[i] < got[j] })
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("got %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+
+	// The index can be forced, even without a leading field.
+	plan, err := c.Explain(ctx, map[string]interface{}{"firstName": "Alice"}, mingodb.FindOptions{UseIndex: name})
+	if err != nil {
+		t.Fatalf("Explain: %v", err)
+	}
+	if plan.IndexName != name {
+		t.Errorf("planned with index %q, expected %s", plan.IndexName, name)
+	}
+	if _, err := c.Find(ctx, nil, mingodb.FindOptions{UseIndex: "missing"}); !errors.Is(err, mingodb.ErrIndexNotFound) {
+		t.Errorf("Find returned %v, expected ErrIndexNotFound", err)
+	}
+
+	// Uniqueness applies to the combination of fields.
+	if _, err := c.InsertOne(ctx, map[string]interface{}{"lastName": "Jones", "firstName": "Bob"}); err != nil {
+		t.Errorf("InsertOne: %v", err)
+	}
+	if _, err := c.InsertOne(ctx, map[string]interface{}{"lastName": "Smith", "firstName": "Bob"}); !errors.Is(err, mingodb.ErrDuplicateKey) {
+		t.Errorf("InsertOne returned %v, expected ErrDuplicateKey", err)
+	}
+}
@@ -0,0 +1,141 @@
+package mingodb
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	bolt "go.etcd.io/bbolt"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// lookupStage joins each document with the documents in another
+// collection whose foreignField is equal to the document's localField:
+//
+//	{"$lookup": {"from": "orders", "localField": "userId", "foreignField": "userId", "as": "orders"}}
+//
+// The matching documents are stored as an array under the "as" field.
+// The options may also be written with a leading "$", such as "$from".
+// If the foreign collection doesn't exist, the array is empty. The
+// database's own buckets aren't collections, so naming one of them
+// returns ErrInvalidCollectionName (see Database.Collection).
+//
+// The foreign collection is read with the settings of its handle, so
+// documents it has soft-deleted aren't joined (see EnableSoftDelete).
+func lookupStage(ctx context.Context, db *Database, tx *bolt.Tx, docs []map[string]interface{}, arg interface{}) ([]map[string]interface{}, error) {
+	spec, err := stageDocument(arg)
+	if err != nil {
+		return nil, err
+	}
+	opts := make(map[string]string, len(spec))
+	for k, v := range spec {
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("%w: $lookup %s must be a string", ErrInvalidPipeline, k)
+		}
+		opts[strings.TrimPrefix(k, "$")] = s
+	}
+	for _, k := range []string{"from", "localField", "foreignField", "as"} {
+		if opts[k] == "" {
+			return nil, fmt.Errorf("%w: $lookup requires %s", ErrInvalidPipeline, k)
+		}
+	}
+
+	if isInternalBucket(opts["from"]) {
+		return nil, fmt.Errorf("%s: %w", opts["from"], ErrInvalidCollectionName)
+	}
+
+	// Documents are often joined on the same value, so cache
+	// the foreign documents found for each value.
+	from := db.handle(opts["from"])
+	b := tx.Bucket([]byte(opts["from"]))
+	indexes, err := loadIndexes(tx, opts["from"])
+	if err != nil {
+		return nil, err
+	}
+	cache := make(map[string][]map[string]interface{})
+	for _, doc := range docs {
+		local, _ := lookupPath(doc, opts["localField"])
+		key, err := groupKey(local)
+		if err != nil {
+			return nil, err
+		}
+
+		joined, ok := cache[key]
+		if !ok && b != nil {
+			// An array matches any of its elements.
+			var cond interface{} = local
+			if arr, ok := local.(primitive.A); ok {
+				cond = map[string]interface{}{"$in": arr}
+			}
+			filter := from.visible(map[string]interface{}{opts["foreignField"]: cond})
+			matches, _, err := findMatches(ctx, b, indexes, filter, FindOptions{}, false)
+			if err != nil {
+				return nil, err
+			}
+			for _, m := range matches {
+				joined = append(joined, m.doc)
+			}
+			cache[key] = joined
+		}
+
+		arr := make(primitive.A, len(joined))
+		for i, j := range joined {
+			arr[i] = j
+		}
+		doc[opts["as"]] = arr
+	}
+	return docs, nil
+}
+
+// LookupPipeline describes the documents returned by Database.Lookup.
+type LookupPipeline struct {
+	// Collection is the name of the collection to return documents
+	// from.
+	Collection string
+
+	// Filter selects the documents to return, following the same rules
+	// as Find. A nil Filter returns every document.
+	Filter interface{}
+
+	// Joins are the collections to join with each document, in order.
+	Joins []Join
+}
+
+// Join joins a document with the documents in another collection
+// whose ForeignField is equal to the document's LocalField. The
+// documents are added to the document as an array under As.
+type Join struct {
+	Collection   string
+	LocalField   string
+	ForeignField string
+	As           string
+}
+
+// Lookup returns the documents in a collection that match a filter,
+// each joined with the documents of other collections. It's a simpler
+// way of running a $match stage followed by a $lookup stage for each
+// join (see Collection.Aggregate), and like Aggregate it reads every
+// collection in a single transaction. Each collection is read with the
+// settings of its handle (see Database.Collection), so its middleware
+// is called and documents it has soft-deleted are left out.
+//
+// Returns ErrCollectionNotFound if the collection doesn't exist. A
+// joined collection that doesn't exist joins no documents.
+func (db *Database) Lookup(ctx context.Context, pipeline LookupPipeline) (*MultiResult, error) {
+	if pipeline.Collection == "" {
+		return nil, ErrEmptyBucketName
+	}
+	var stages Pipeline
+	if pipeline.Filter != nil {
+		stages = append(stages, MatchStage(pipeline.Filter))
+	}
+	for i, j := range pipeline.Joins {
+		if j.Collection == "" || j.LocalField == "" || j.ForeignField == "" || j.As == "" {
+			return nil, fmt.Errorf("%w: join %d requires Collection, LocalField, ForeignField and As", ErrInvalidPipeline, i)
+		}
+		stages = append(stages, LookupStage(j.Collection, j.LocalField, j.ForeignField, j.As))
+	}
+
+	return db.handle(pipeline.Collection).Aggregate(ctx, stages)
+}
@@ -0,0 +1,188 @@
+package mingodb_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/korrbit/mingodb"
+	"github.com/korrbit/mingodb/testutil"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// shop returns a database holding users and their orders.
+func shop(t *testing.T) *mingodb.Database {
+	t.Helper()
+	db := testutil.NewTestDB(t)
+	testutil.SeedCollection(t, db.CollectionMust("users"),
+		map[string]interface{}{"_id": 1, "name": "Alice"},
+		map[string]interface{}{"_id": 2, "name": "Bob"},
+	)
+	testutil.SeedCollection(t, db.CollectionMust("orders"),
+		map[string]interface{}{"_id": 10, "userId": 1},
+		map[string]interface{}{"_id": 11, "userId": 1},
+		map[string]interface{}{"_id": 12, "userId": 3},
+	)
+	return db
+}
+
+func TestLookupStage(t *testing.T) {
+	db := shop(t)
+	users := db.CollectionMust("users")
+	expected := []map[string]interface{}{
+		{"_id": int32(1), "name": "Alice", mingodb.VersionField: int64(1), "orders": primitive.A{
+			map[string]interface{}{"_id": int32(10), "userId": int32(1), mingodb.VersionField: int64(1)},
+			map[string]interface{}{"_id": int32(11), "userId": int32(1), mingodb.VersionField: int64(1)},
+		}},
+		{"_id": int32(2), "name": "Bob", mingodb.VersionField: int64(1), "orders": primitive.A{}},
+	}
+
+	got := aggregate(t, users, mingodb.Pipeline{mingodb.LookupStage("orders", "_id", "userId", "orders")})
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %v, expected %v", got, expected)
+	}
+
+	// The options can be written with a leading "$".
+	got = aggregate(t, users, mingodb.Pipeline{{{Key: "$lookup", Value: bson.D{
+		{Key: "$from", Value: "orders"},
+		{Key: "$localField", Value: "_id"},
+		{Key: "$foreignField", Value: "userId"},
+		{Key: "$as", Value: "orders"},
+	}}}})
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %v, expected %v", got, expected)
+	}
+
+	// A missing collection joins no documents.
+	got = aggregate(t, users, mingodb.Pipeline{mingodb.LookupStage("missing", "_id", "userId", "orders")})
+	for _, doc := range got {
+		if orders, ok := doc["orders"].(primitive.A); !ok || len(orders) != 0 {
+			t.Errorf("got %v, expected no orders", doc)
+		}
+	}
+}
+
+func TestLookupStageInternalBucket(t *testing.T) {
+	db := shop(t)
+	users := db.CollectionMust("users")
+	if _, err := users.CreateIndex(context.Background(), "name"); err != nil {
+		t.Fatalf("CreateIndex: %v", err)
+	}
+	for _, from := range []string{"__indexes", "__idx"} {
+		_, err := users.Aggregate(context.Background(), mingodb.Pipeline{mingodb.LookupStage(from, "_id", "_id", "joined")})
+		if !errors.Is(err, mingodb.ErrInvalidCollectionName) {
+			t.Errorf("$lookup from %s returned %v, expected ErrInvalidCollectionName", from, err)
+		}
+	}
+}
+
+func TestDatabaseLookup(t *testing.T) {
+	ctx := context.Background()
+	db := shop(t)
+	testutil.SeedCollection(t, db.CollectionMust("profiles"),
+		map[string]interface{}{"_id": 100, "userId": 1, "bio": "hi"},
+	)
+
+	res, err := db.Lookup(ctx, mingodb.LookupPipeline{
+		Collection: "users",
+		Filter:     map[string]interface{}{"name": "Alice"},
+		Joins: []mingodb.Join{
+			{Collection: "orders", LocalField: "_id", ForeignField: "userId", As: "orders"},
+			{Collection: "profiles", LocalField: "_id", ForeignField: "userId", As: "profiles"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Lookup: %v", err)
+	}
+	var got []map[string]interface{}
+	for res.Next() {
+		var doc map[string]interface{}
+		if err := res.Decode(&doc); err != nil {
+			t.Fatalf("Decode: %v", err)
+		}
+		got = append(got, doc)
+	}
+	expected := []map[string]interface{}{{
+		"_id":                int32(1),
+		"name":               "Alice",
+		mingodb.VersionField: int64(1),
+		"orders": primitive.A{
+			map[string]interface{}{"_id": int32(10), "userId": int32(1), mingodb.VersionField: int64(1)},
+			map[string]interface{}{"_id": int32(11), "userId": int32(1), mingodb.VersionField: int64(1)},
+		},
+		"profiles": primitive.A{
+			map[string]interface{}{"_id": int32(100), "userId": int32(1), "bio": "hi", mingodb.VersionField: int64(1)},
+		},
+	}}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %v, expected %v", got, expected)
+	}
+}
+
+func TestDatabaseLookupSoftDelete(t *testing.T) {
+	ctx := context.Background()
+	db := shop(t)
+	for _, name := range []string{"users", "orders"} {
+		db.CollectionMust(name).EnableSoftDelete("deletedAt")
+	}
+	if _, err := db.CollectionMust("users").DeleteOne(ctx, 2); err != nil {
+		t.Fatalf("DeleteOne: %v", err)
+	}
+	if _, err := db.CollectionMust("orders").DeleteOne(ctx, 11); err != nil {
+		t.Fatalf("DeleteOne: %v", err)
+	}
+
+	res, err := db.Lookup(ctx, mingodb.LookupPipeline{
+		Collection: "users",
+		Joins:      []mingodb.Join{{Collection: "orders", LocalField: "_id", ForeignField: "userId", As: "orders"}},
+	})
+	if err != nil {
+		t.Fatalf("Lookup: %v", err)
+	}
+	var got []map[string]interface{}
+	for res.Next() {
+		var doc map[string]interface{}
+		if err := res.Decode(&doc); err != nil {
+			t.Fatalf("Decode: %v", err)
+		}
+		got = append(got, doc)
+	}
+	expected := []map[string]interface{}{{
+		"_id":                int32(1),
+		"name":               "Alice",
+		mingodb.VersionField: int64(1),
+		"orders": primitive.A{
+			map[string]interface{}{"_id": int32(10), "userId": int32(1), mingodb.VersionField: int64(1)},
+		},
+	}}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %v, expected %v", got, expected)
+	}
+}
+
+func TestDatabaseLookupErrors(t *testing.T) {
+	ctx := context.Background()
+	db := shop(t)
+	tests := []struct {
+		name     string
+		pipeline mingodb.LookupPipeline
+		err      error
+	}{
+		{"NoCollection", mingodb.LookupPipeline{}, mingodb.ErrEmptyBucketName},
+		{"MissingCollection", mingodb.LookupPipeline{Collection: "missing"}, mingodb.ErrCollectionNotFound},
+		{"InternalCollection", mingodb.LookupPipeline{Collection: "__indexes"}, mingodb.ErrInvalidCollectionName},
+		{"IncompleteJoin", mingodb.LookupPipeline{
+			Collection: "users",
+			Joins:      []mingodb.Join{{Collection: "orders", LocalField: "_id"}},
+		}, mingodb.ErrInvalidPipeline},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := db.Lookup(ctx, tt.pipeline); !errors.Is(err, tt.err) {
+				t.Errorf("got %v, expected %v", err, tt.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package mingodb
+
+import (
+	"context"
+	"fmt"
+
+	bolt "go.etcd.io/bbolt"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// KeyValue is a pair emitted by the map function of MapReduce.
+type KeyValue struct {
+	Key, Value interface{}
+}
+
+// MapReduce calls mapFn for each document in the collection, groups
+// the values it emits by key and calls reduceFn with each key and its
+// values. The results are written to the out collection, which is
+// created if needed, as documents of the form {_id: key, value:
+// result}, replacing any documents already in it.
+//
+// Keys are grouped like the _ids of a $group stage, so they must be
+// BSON values, and numbers of different types are different keys.
+// Keys are reduced in the order they were first emitted. The whole
+// operation runs in a single transaction, so if it fails the out
+// collection is left as it was.
+//
+// Aggregate is usually faster, but MapReduce can run any Go code.
+func (c *Collection) MapReduce(ctx context.Context, mapFn func(doc map[string]interface{}) []KeyValue, reduceFn func(key interface{}, values []interface{}) interface{}, out string) (err error) {
+	ctx, end := c.startOperation(ctx, "mapReduce", nil, nil)
+	defer func() { end(err) }()
+	if out == "" {
+		return ErrEmptyBucketName
+	}
+	if isInternalBucket(out) {
+		return fmt.Errorf("%s: %w", out, ErrCollectionNotFound)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return c.write(func(tx *bolt.Tx) error {
+		b, err := c.bucket(tx)
+		if err != nil {
+			return err
+		}
+
+		// Map the documents, grouping the values by key.
+		type group struct {
+			key    interface{}
+			values []interface{}
+		}
+		var groups []*group
+		byKey := make(map[string]*group)
+		err = scanMatches(ctx, b, c.visible(nil), func(k, v []byte, doc map[string]interface{}) (bool, error) {
+			for _, kv := range mapFn(doc) {
+				t, bk, err := bson.MarshalValue(canonicalValue(kv.Key))
+				if err != nil {
+					return false, fmt.Errorf("key %v: %w", kv.Key, err)
+				}
+				gk := string(append([]byte{byte(t)}, bk...))
+				g, ok := byKey[gk]
+				if !ok {
+					g = &group{key: kv.Key}
+					byKey[gk] = g
+					groups = append(groups, g)
+				}
+				g.values = append(g.values, kv.Value)
+			}
+			return true, nil
+		})
+		if err != nil {
+			return err
+		}
+
+		// Empty the out collection, then reduce each group into it.
+		if _, err := tx.CreateBucketIfNotExists([]byte(out)); err != nil {
+			return err
+		}
+		w, err := (&Collection{db: c.db, name: out, txn: c.txn}).writeTx(ctx, tx)
+		if err != nil {
+			return err
+		}
+		var keys [][]byte
+		cur := w.b.Cursor()
+		for k, _ := cur.First(); k != nil; k, _ = cur.Next() {
+			keys = append(keys, append([]byte(nil), k...))
+		}
+		for _, k := range keys {
+			if err := w.delete(k); err != nil {
+				return err
+			}
+		}
+		for _, g := range groups {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			doc := map[string]interface{}{"_id": g.key, "value": reduceFn(g.key, g.values)}
+			id, key, data, err := prepareDocument(doc, w.newID, w.key)
+			if err != nil {
+				return fmt.Errorf("key %v: %w", g.key, err)
+			}
+			if err := w.insert(id, key, data); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,72 @@
+package mingodb_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/korrbit/mingodb"
+	"github.com/korrbit/mingodb/testutil"
+)
+
+// ageByCity emits each person's age keyed by their city.
+func ageByCity(doc map[string]interface{}) []mingodb.KeyValue {
+	return []mingodb.KeyValue{{Key: doc["city"], Value: doc["age"]}}
+}
+
+// sumAges sums the ages emitted by ageByCity.
+func sumAges(_ interface{}, values []interface{}) interface{} {
+	var sum int32
+	for _, v := range values {
+		sum += v.(int32)
+	}
+	return sum
+}
+
+func TestMapReduce(t *testing.T) {
+	ctx := context.Background()
+	c := people(t)
+	out := c.Database().CollectionMust("totals")
+	testutil.SeedCollection(t, out, map[string]interface{}{"_id": "stale"})
+
+	if err := c.MapReduce(ctx, ageByCity, sumAges, "totals"); err != nil {
+		t.Fatalf("MapReduce: %v", err)
+	}
+	res, err := out.Find(ctx, nil)
+	if err != nil {
+		t.Fatalf("Find: %v", err)
+	}
+	type total struct {
+		City string `bson:"_id"`
+		Age  int32  `bson:"value"`
+	}
+	var totals []total
+	for res.Next() {
+		var doc total
+		if err := res.Decode(&doc); err != nil {
+			t.Fatalf("Decode: %v", err)
+		}
+		totals = append(totals, doc)
+	}
+	// The keys are reduced, and so inserted, in the order they were
+	// first emitted.
+	if expected := []total{{"Paris", 65}, {"London", 25}}; !reflect.DeepEqual(totals, expected) {
+		t.Errorf("got %+v, expected %+v", totals, expected)
+	}
+}
+
+func TestMapReduceInvalidKey(t *testing.T) {
+	c := people(t)
+	out := c.Database().CollectionMust("totals")
+	testutil.SeedCollection(t, out, map[string]interface{}{"_id": "kept"})
+
+	mapFn := func(doc map[string]interface{}) []mingodb.KeyValue {
+		return []mingodb.KeyValue{{Key: make(chan int), Value: 1}}
+	}
+	if err := c.MapReduce(context.Background(), mapFn, sumAges, "totals"); err == nil {
+		t.Fatal("MapReduce with a key that isn't a BSON value succeeded")
+	}
+	// The out collection is left as it was.
+	testutil.AssertDocumentCount(t, out, nil, 1)
+	testutil.AssertDocumentExists(t, out, map[string]interface{}{"_id": "kept"})
+}
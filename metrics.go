@@ -0,0 +1,109 @@
+package mingodb
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// WithMetrics returns CollectionOptions that register a
+// MetricsMiddleware whose metrics are registered with reg. It panics
+// if the metrics can't be registered (see NewMetricsMiddleware).
+func WithMetrics(reg prometheus.Registerer) CollectionOptions {
+	m, err := NewMetricsMiddleware(reg)
+	if err != nil {
+		panic(err)
+	}
+	return CollectionOptions{Middleware: []Middleware{m}}
+}
+
+// DefaultMetrics returns CollectionOptions that register a
+// MetricsMiddleware with prometheus.DefaultRegisterer.
+func DefaultMetrics() CollectionOptions {
+	return WithMetrics(prometheus.DefaultRegisterer)
+}
+
+// MetricsMiddleware records Prometheus metrics for each of the
+// collection's operations (see OperationMiddleware):
+//
+//	mingodb_operation_duration_seconds{collection, operation}
+//	mingodb_operation_total{collection, operation, status}
+//	mingodb_documents_scanned_total{collection, operation}
+//
+// where operation is the operation's name, such as "find", and status
+// is "ok" or "error".
+type MetricsMiddleware struct {
+	BaseMiddleware
+
+	duration *prometheus.HistogramVec
+	total    *prometheus.CounterVec
+	scanned  *prometheus.CounterVec
+}
+
+// NewMetricsMiddleware returns a MetricsMiddleware whose metrics are
+// registered with reg. If the metrics have already been registered,
+// for example by another collection's MetricsMiddleware, the existing
+// metrics are shared.
+func NewMetricsMiddleware(reg prometheus.Registerer) (*MetricsMiddleware, error) {
+	m := &MetricsMiddleware{
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name: "mingodb_operation_duration_seconds",
+			Help: "Duration of MingoDB collection operations.",
+		}, []string{"collection", "operation"}),
+		total: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "mingodb_operation_total",
+			Help: "Number of MingoDB collection operations.",
+		}, []string{"collection", "operation", "status"}),
+		scanned: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "mingodb_documents_scanned_total",
+			Help: "Number of documents scanned by MingoDB collection operations.",
+		}, []string{"collection", "operation"}),
+	}
+	var err error
+	if m.duration, err = register(reg, m.duration); err != nil {
+		return nil, err
+	}
+	if m.total, err = register(reg, m.total); err != nil {
+		return nil, err
+	}
+	if m.scanned, err = register(reg, m.scanned); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// register registers c with reg, or returns the collector that's
+// already registered in its place.
+func register[C prometheus.Collector](reg prometheus.Registerer, c C) (C, error) {
+	err := reg.Register(c)
+	var are prometheus.AlreadyRegisteredError
+	if errors.As(err, &are) {
+		if existing, ok := are.ExistingCollector.(C); ok {
+			return existing, nil
+		}
+	}
+	return c, err
+}
+
+// StartOperation returns ctx. The operation's start time is tracked
+// by the collection.
+func (m *MetricsMiddleware) StartOperation(ctx context.Context, _ *Collection, _ string) context.Context {
+	return ctx
+}
+
+// EndOperation records the operation's metrics.
+func (m *MetricsMiddleware) EndOperation(ctx context.Context, err error) {
+	op := operationFrom(ctx)
+	if op == nil {
+		return
+	}
+	status := "ok"
+	if err != nil {
+		status = "error"
+	}
+	m.duration.WithLabelValues(op.c.name, op.name).Observe(time.Since(op.start).Seconds())
+	m.total.WithLabelValues(op.c.name, op.name, status).Inc()
+	m.scanned.WithLabelValues(op.c.name, op.name).Add(float64(op.scanned))
+}
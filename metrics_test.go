@@ -0,0 +1,56 @@
+package mingodb_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/korrbit/mingodb"
+	"github.com/korrbit/mingodb/testutil"
+	"github.com/prometheus/client_golang/prometheus"
+	promtestutil "github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMetricsMiddleware(t *testing.T) {
+	ctx := context.Background()
+	reg := prometheus.NewRegistry()
+	db := testutil.NewTestDB(t)
+	// Both collections share the registered metrics.
+	items := db.CollectionMust("items", mingodb.WithMetrics(reg))
+	other := db.CollectionMust("other", mingodb.WithMetrics(reg))
+	testutil.SeedCollection(t, items,
+		map[string]interface{}{"_id": 1},
+		map[string]interface{}{"_id": 2},
+	)
+
+	if _, err := items.Find(ctx, nil); err != nil {
+		t.Fatalf("Find: %v", err)
+	}
+	if _, err := items.InsertOne(ctx, map[string]interface{}{"_id": 1}); err == nil {
+		t.Fatal("InsertOne of a duplicate _id succeeded")
+	}
+	if _, err := other.Find(ctx, nil); err != nil {
+		t.Fatalf("Find: %v", err)
+	}
+
+	expected := `
+# HELP mingodb_operation_total Number of MingoDB collection operations.
+# TYPE mingodb_operation_total counter
+mingodb_operation_total{collection="items",operation="find",status="ok"} 1
+mingodb_operation_total{collection="items",operation="insertMany",status="ok"} 1
+mingodb_operation_total{collection="items",operation="insertOne",status="error"} 1
+mingodb_operation_total{collection="other",operation="find",status="ok"} 1
+# HELP mingodb_documents_scanned_total Number of documents scanned by MingoDB collection operations.
+# TYPE mingodb_documents_scanned_total counter
+mingodb_documents_scanned_total{collection="items",operation="find"} 2
+mingodb_documents_scanned_total{collection="items",operation="insertMany"} 0
+mingodb_documents_scanned_total{collection="items",operation="insertOne"} 0
+mingodb_documents_scanned_total{collection="other",operation="find"} 0
+`
+	if err := promtestutil.GatherAndCompare(reg, strings.NewReader(expected), "mingodb_operation_total", "mingodb_documents_scanned_total"); err != nil {
+		t.Error(err)
+	}
+	if n := promtestutil.CollectAndCount(reg, "mingodb_operation_duration_seconds"); n != 4 {
+		t.Errorf("got %d duration histograms, expected 4", n)
+	}
+}
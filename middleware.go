@@ -0,0 +1,248 @@
+package mingodb
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// Middleware is called before and after a collection's writes. See
+// Collection.Use.
+//
+// The Before methods are called with the operation's arguments and
+// can return an error to cancel the operation, which then returns
+// that error. The After methods are called once the operation has
+// succeeded.
+type Middleware interface {
+	BeforeInsert(ctx context.Context, doc interface{}) error
+	AfterInsert(ctx context.Context, doc interface{}, id InsertID)
+	BeforeUpdate(ctx context.Context, filter, update interface{}) error
+	AfterUpdate(ctx context.Context, result *UpdateResult)
+	BeforeDelete(ctx context.Context, filter interface{}) error
+	AfterDelete(ctx context.Context, result *DeleteResult)
+}
+
+// BaseMiddleware implements every Middleware method as a no-op, so
+// that middleware that only needs some of the methods can embed it.
+type BaseMiddleware struct{}
+
+func (BaseMiddleware) BeforeInsert(context.Context, interface{}) error              { return nil }
+func (BaseMiddleware) AfterInsert(context.Context, interface{}, InsertID)           {}
+func (BaseMiddleware) BeforeUpdate(context.Context, interface{}, interface{}) error { return nil }
+func (BaseMiddleware) AfterUpdate(context.Context, *UpdateResult)                   {}
+func (BaseMiddleware) BeforeDelete(context.Context, interface{}) error              { return nil }
+func (BaseMiddleware) AfterDelete(context.Context, *DeleteResult)                   {}
+
+// Use registers middleware with the collection. Each middleware is
+// called in the order it was registered, around every write:
+// InsertOne, InsertMany and Import (once for each document);
+// UpdateOne, UpdateMany, UpsertOne (even when it inserts a document),
+// FindOneAndUpdate, OptimisticUpdate, ReplaceOne (with the replacement
+// as the update) and Rollback; and DeleteOne, DeleteMany, HardDelete
+// and FindOneAndDelete. BulkWrite calls them for each of its
+// operations. The TTL worker calls BeforeDelete and AfterDelete when
+// it deletes expired documents (see CreateTTLIndex).
+//
+// The middleware applies wherever the collection is used, since
+// Database.Collection returns the same handle for every call with the
+// collection's name. Collections returned by Transaction.Collection and
+// Snapshot.Collection have the middleware registered by then. Use
+// should be called before the collection is used by multiple
+// goroutines, including the TTL worker.
+func (c *Collection) Use(mw ...Middleware) {
+	c.middleware = append(c.middleware, mw...)
+}
+
+// OperationMiddleware is Middleware that's also called around every
+// operation on the collection, including reads: Find, FindOne,
+// FindWithDeleted, GetByID, GetByIDInto, GetByIDs, CountDocuments, Distinct, Aggregate,
+// the Insert, Update, Upsert, Replace, FindOneAnd and Delete methods,
+// BulkWrite, OptimisticUpdate, GetHistory, Rollback, MapReduce, Export,
+// Import and the TTL worker's "expire".
+//
+// StartOperation is called with the operation's name, such as "find"
+// or "insertOne", and returns the context for the rest of the
+// operation, including the other hooks. EndOperation is then called
+// with that context and the error the operation returned, if any.
+// Operations run by another operation, such as the writes of a
+// BulkWrite, don't start operations of their own.
+type OperationMiddleware interface {
+	Middleware
+	StartOperation(ctx context.Context, c *Collection, op string) context.Context
+	EndOperation(ctx context.Context, err error)
+}
+
+// operation describes an operation that's in progress, for middleware
+// that needs more than the hooks' arguments (see ProfilingMiddleware).
+// It's added to the context passed to the hooks.
+type operation struct {
+	c              *Collection
+	name           string // Such as "find" or "insertOne"
+	filter, update interface{}
+	start          time.Time
+	scanned        int           // Number of documents scanned
+	ids            []interface{} // _ids of the documents written
+}
+
+// operationKey is the context key of the operation.
+type operationKey struct{}
+
+// startOperation adds an operation to ctx and starts it with each
+// OperationMiddleware, if the collection has middleware. The returned
+// function ends the operation with its error.
+func (c *Collection) startOperation(ctx context.Context, name string, filter, update interface{}) (context.Context, func(error)) {
+	// Is this operation part of another? If it's part of another
+	// collection's, it mustn't count towards that operation.
+	o := operationFrom(ctx)
+	if o != nil && o.c == c {
+		return ctx, func(error) {}
+	}
+	if len(c.middleware) == 0 {
+		if o != nil {
+			ctx = context.WithValue(ctx, operationKey{}, (*operation)(nil))
+		}
+		return ctx, func(error) {}
+	}
+
+	o = &operation{c: c, name: name, filter: filter, update: update, start: time.Now()}
+	ctx = context.WithValue(ctx, operationKey{}, o)
+	var started []OperationMiddleware
+	var ctxs []context.Context
+	for _, mw := range c.middleware {
+		if om, ok := mw.(OperationMiddleware); ok {
+			ctx = om.StartOperation(ctx, c, name)
+			started = append(started, om)
+			ctxs = append(ctxs, ctx)
+		}
+	}
+
+	// End the operations in the reverse order they were started.
+	return ctx, func(err error) {
+		for i := len(started) - 1; i >= 0; i-- {
+			started[i].EndOperation(ctxs[i], err)
+		}
+	}
+}
+
+// operationFrom returns ctx's operation, or nil if it has none.
+func operationFrom(ctx context.Context) *operation {
+	o, _ := ctx.Value(operationKey{}).(*operation)
+	return o
+}
+
+// countScanned counts a document scanned by ctx's operation.
+func countScanned(ctx context.Context) {
+	if o := operationFrom(ctx); o != nil {
+		o.scanned++
+	}
+}
+
+// written records that the document with the specified _id has been
+// written by the operation.
+func (w *writeTx) written(id interface{}) {
+	if w.op != nil {
+		w.op.ids = append(w.op.ids, id)
+	}
+}
+
+// beforeInsert calls each middleware's BeforeInsert.
+func (c *Collection) beforeInsert(ctx context.Context, doc interface{}) error {
+	for _, mw := range c.middleware {
+		if err := mw.BeforeInsert(ctx, doc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// afterInsert calls each middleware's AfterInsert.
+func (c *Collection) afterInsert(ctx context.Context, doc interface{}, id InsertID) {
+	for _, mw := range c.middleware {
+		mw.AfterInsert(ctx, doc, id)
+	}
+}
+
+// beforeUpdate calls each middleware's BeforeUpdate.
+func (c *Collection) beforeUpdate(ctx context.Context, filter, update interface{}) error {
+	for _, mw := range c.middleware {
+		if err := mw.BeforeUpdate(ctx, filter, update); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// afterUpdate calls each middleware's AfterUpdate.
+func (c *Collection) afterUpdate(ctx context.Context, res *UpdateResult) {
+	for _, mw := range c.middleware {
+		mw.AfterUpdate(ctx, res)
+	}
+}
+
+// beforeDelete calls each middleware's BeforeDelete.
+func (c *Collection) beforeDelete(ctx context.Context, filter interface{}) error {
+	for _, mw := range c.middleware {
+		if err := mw.BeforeDelete(ctx, filter); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// afterDelete calls each middleware's AfterDelete.
+func (c *Collection) afterDelete(ctx context.Context, res *DeleteResult) {
+	for _, mw := range c.middleware {
+		mw.AfterDelete(ctx, res)
+	}
+}
+
+// LoggingMiddleware logs a line of key=value pairs for each write,
+// such as:
+//
+//	mingodb: op=update filter=map[name:Bob] update=map[$set:map[age:42]]
+//	mingodb: op=update matched=1 updated=1
+type LoggingMiddleware struct {
+	// Logger receives the lines. Defaults to log.Default().
+	Logger *log.Logger
+}
+
+func (m LoggingMiddleware) logf(format string, v ...interface{}) {
+	l := m.Logger
+	if l == nil {
+		l = log.Default()
+	}
+	l.Printf("mingodb: "+format, v...)
+}
+
+// BeforeInsert logs the document.
+func (m LoggingMiddleware) BeforeInsert(_ context.Context, doc interface{}) error {
+	m.logf("op=insert doc=%v", doc)
+	return nil
+}
+
+// AfterInsert logs the document's _id.
+func (m LoggingMiddleware) AfterInsert(_ context.Context, _ interface{}, id InsertID) {
+	m.logf("op=insert id=%v", id)
+}
+
+// BeforeUpdate logs the filter and update.
+func (m LoggingMiddleware) BeforeUpdate(_ context.Context, filter, update interface{}) error {
+	m.logf("op=update filter=%v update=%v", filter, update)
+	return nil
+}
+
+// AfterUpdate logs the number of matched and updated documents.
+func (m LoggingMiddleware) AfterUpdate(_ context.Context, res *UpdateResult) {
+	m.logf("op=update matched=%d updated=%d", res.MatchedCount, res.UpdateCount)
+}
+
+// BeforeDelete logs the filter.
+func (m LoggingMiddleware) BeforeDelete(_ context.Context, filter interface{}) error {
+	m.logf("op=delete filter=%v", filter)
+	return nil
+}
+
+// AfterDelete logs the number of deleted documents.
+func (m LoggingMiddleware) AfterDelete(_ context.Context, res *DeleteResult) {
+	m.logf("op=delete deleted=%d", res.DeleteCount)
+}
@@ -0,0 +1,111 @@
+package mingodb_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/korrbit/mingodb"
+	"github.com/korrbit/mingodb/testutil"
+)
+
+// recorder is Middleware that records the hooks it's called with.
+type recorder struct {
+	name  string
+	calls *[]string
+	err   error // Returned by the Before hooks
+}
+
+func (r recorder) record(format string, v ...interface{}) {
+	*r.calls = append(*r.calls, r.name+": "+fmt.Sprintf(format, v...))
+}
+
+func (r recorder) BeforeInsert(_ context.Context, doc interface{}) error {
+	r.record("BeforeInsert")
+	return r.err
+}
+
+func (r recorder) AfterInsert(_ context.Context, _ interface{}, id mingodb.InsertID) {
+	r.record("AfterInsert %v", id)
+}
+
+func (r recorder) BeforeUpdate(_ context.Context, _, _ interface{}) error {
+	r.record("BeforeUpdate")
+	return r.err
+}
+
+func (r recorder) AfterUpdate(_ context.Context, res *mingodb.UpdateResult) {
+	r.record("AfterUpdate %d", res.UpdateCount)
+}
+
+func (r recorder) BeforeDelete(_ context.Context, _ interface{}) error {
+	r.record("BeforeDelete")
+	return r.err
+}
+
+func (r recorder) AfterDelete(_ context.Context, res *mingodb.DeleteResult) {
+	r.record("AfterDelete %d", res.DeleteCount)
+}
+
+func TestMiddleware(t *testing.T) {
+	ctx := context.Background()
+	var calls []string
+	c := testutil.NewTestDB(t).CollectionMust("items")
+	c.Use(recorder{name: "a", calls: &calls}, recorder{name: "b", calls: &calls})
+
+	if _, err := c.InsertOne(ctx, map[string]interface{}{"_id": 1}); err != nil {
+		t.Fatalf("InsertOne: %v", err)
+	}
+	if _, err := c.UpdateOne(ctx, map[string]interface{}{"_id": 1}, map[string]interface{}{"$set": map[string]interface{}{"a": 1}}); err != nil {
+		t.Fatalf("UpdateOne: %v", err)
+	}
+	if _, err := c.DeleteOne(ctx, 1); err != nil {
+		t.Fatalf("DeleteOne: %v", err)
+	}
+	expected := []string{
+		"a: BeforeInsert", "b: BeforeInsert", "a: AfterInsert 1", "b: AfterInsert 1",
+		"a: BeforeUpdate", "b: BeforeUpdate", "a: AfterUpdate 1", "b: AfterUpdate 1",
+		"a: BeforeDelete", "b: BeforeDelete", "a: AfterDelete 1", "b: AfterDelete 1",
+	}
+	if !reflect.DeepEqual(calls, expected) {
+		t.Errorf("got calls %q, expected %q", calls, expected)
+	}
+}
+
+func TestMiddlewareCancelsWrites(t *testing.T) {
+	ctx := context.Background()
+	errDenied := errors.New("denied")
+	var calls []string
+	c := testutil.NewTestDB(t).CollectionMust("items")
+	testutil.SeedCollection(t, c, map[string]interface{}{"_id": 1})
+	c.Use(recorder{name: "a", calls: &calls, err: errDenied}, recorder{name: "b", calls: &calls})
+
+	if _, err := c.InsertOne(ctx, map[string]interface{}{"_id": 2}); !errors.Is(err, errDenied) {
+		t.Errorf("InsertOne returned %v, expected the middleware's error", err)
+	}
+	if _, err := c.DeleteMany(ctx, nil); !errors.Is(err, errDenied) {
+		t.Errorf("DeleteMany returned %v, expected the middleware's error", err)
+	}
+	if expected := []string{"a: BeforeInsert", "a: BeforeDelete"}; !reflect.DeepEqual(calls, expected) {
+		t.Errorf("got calls %q, expected %q", calls, expected)
+	}
+	testutil.AssertDocumentCount(t, c, nil, 1)
+}
+
+func TestLoggingMiddleware(t *testing.T) {
+	var buf bytes.Buffer
+	c := testutil.NewTestDB(t).CollectionMust("items")
+	c.Use(mingodb.LoggingMiddleware{Logger: log.New(&buf, "", 0)})
+	if _, err := c.InsertOne(context.Background(), map[string]interface{}{"_id": 1}); err != nil {
+		t.Fatalf("InsertOne: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if expected := []string{"mingodb: op=insert doc=map[_id:1]", "mingodb: op=insert id=1"}; !reflect.DeepEqual(lines, expected) {
+		t.Errorf("logged %q, expected %q", lines, expected)
+	}
+}
@@ -0,0 +1,215 @@
+package mingodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// migrationsCollection is the collection that stores the version of
+// the database's migrations.
+const migrationsCollection = "__migrations"
+
+// migrationVersionID is the _id of the document in the migrations
+// collection that stores the current version.
+const migrationVersionID = "version"
+
+// Migrator runs the migrations that evolve a database's documents, in
+// the order of their versions, and records the version the database
+// has been migrated to in the __migrations collection:
+//
+//	m := mingodb.NewMigrator(db).
+//		Collection("users").
+//		Add(1, addStatus, removeStatus).
+//		Add(2, splitName, joinName)
+//	if err := m.Migrate(ctx); err != nil {
+//		return err
+//	}
+//
+// Each migration runs in a Transaction along with the update of the
+// version, so a migration that fails leaves the database as it was.
+// The migration is passed the collection within the transaction; it
+// must not write to the database other than through the transaction,
+// as only one read-write transaction can be open at a time.
+type Migrator struct {
+	db         *Database
+	collection string // The collection of the migrations added next
+	migrations []migration
+	err        error // Error adding a migration, if any
+}
+
+// migration is a migration added to a Migrator.
+type migration struct {
+	version    int
+	collection string
+	up, down   func(ctx context.Context, col *Collection) error
+}
+
+// NewMigrator returns a Migrator without any migrations.
+func NewMigrator(db *Database) *Migrator {
+	return &Migrator{db: db}
+}
+
+// Collection sets the collection passed to the migrations added after
+// it.
+func (m *Migrator) Collection(name string) *Migrator {
+	m.collection = name
+	return m
+}
+
+// Add adds a migration. up migrates the collection to the version,
+// which must be positive and not already added, and down undoes it;
+// down can be nil if the migration can't be rolled back. An invalid
+// migration is reported by Migrate and Rollback.
+func (m *Migrator) Add(version int, up func(ctx context.Context, col *Collection) error, down func(ctx context.Context, col *Collection) error) *Migrator {
+	switch {
+	case m.err != nil:
+	case version <= 0:
+		m.err = fmt.Errorf("%w: version %d must be positive", ErrInvalidMigration, version)
+	case m.collection == "":
+		m.err = fmt.Errorf("%w: version %d has no collection (see Migrator.Collection)", ErrInvalidMigration, version)
+	case up == nil:
+		m.err = fmt.Errorf("%w: version %d has no up function", ErrInvalidMigration, version)
+	}
+	for _, mg := range m.migrations {
+		if m.err == nil && mg.version == version {
+			m.err = fmt.Errorf("%w: version %d was added twice", ErrInvalidMigration, version)
+		}
+	}
+	m.migrations = append(m.migrations, migration{version, m.collection, up, down})
+	return m
+}
+
+// Version returns the version the database has been migrated to, or
+// 0 if it hasn't been migrated.
+func (m *Migrator) Version(ctx context.Context) (int, error) {
+	exists, err := m.db.CollectionExists(ctx, migrationsCollection)
+	if err != nil || !exists {
+		return 0, err
+	}
+	c := &Collection{db: m.db, name: migrationsCollection}
+	return migrationVersion(ctx, c)
+}
+
+// Migrate runs the up functions of the migrations whose versions are
+// newer than the database's, oldest first. If a migration fails, the
+// migrations before it stay applied, and its error is returned.
+func (m *Migrator) Migrate(ctx context.Context) error {
+	if m.err != nil {
+		return m.err
+	}
+	migrations := m.sorted()
+	for i := range migrations {
+		mg := migrations[i]
+		err := m.step(ctx, mg, func(current int) (int, bool) {
+			return mg.version, current < mg.version
+		}, mg.up)
+		if err != nil {
+			return fmt.Errorf("migration %d: %w", mg.version, err)
+		}
+	}
+	return nil
+}
+
+// Rollback runs the down functions of the migrations that have been
+// applied and whose versions are newer than toVersion, newest first.
+// After each one, the database's version is set to the version of the
+// migration before it, or 0. If a migration fails, or has no down
+// function, the migrations before it stay rolled back, and its error
+// is returned.
+func (m *Migrator) Rollback(ctx context.Context, toVersion int) error {
+	if m.err != nil {
+		return m.err
+	}
+	if toVersion < 0 {
+		return fmt.Errorf("%w: version %d must not be negative", ErrInvalidMigration, toVersion)
+	}
+	migrations := m.sorted()
+	for i := len(migrations) - 1; i >= 0; i-- {
+		mg := migrations[i]
+		if mg.version <= toVersion {
+			break
+		}
+		prev := 0
+		if i > 0 {
+			prev = migrations[i-1].version
+		}
+		err := m.step(ctx, mg, func(current int) (int, bool) {
+			return prev, current >= mg.version
+		}, func(ctx context.Context, col *Collection) error {
+			if mg.down == nil {
+				return fmt.Errorf("%w: no down function", ErrInvalidMigration)
+			}
+			return mg.down(ctx, col)
+		})
+		if err != nil {
+			return fmt.Errorf("migration %d: %w", mg.version, err)
+		}
+	}
+	return nil
+}
+
+// sorted returns the migrations in the order of their versions.
+func (m *Migrator) sorted() []migration {
+	migrations := append([]migration(nil), m.migrations...)
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].version < migrations[j].version
+	})
+	return migrations
+}
+
+// step runs fn in a transaction with the migration's collection, if
+// next reports that it should be run given the current version, then
+// sets the version to the one returned by next. The version is read in
+// the same transaction, so a migration that another Migrator has
+// already run isn't run again.
+func (m *Migrator) step(ctx context.Context, mg migration, next func(current int) (int, bool), fn func(ctx context.Context, col *Collection) error) error {
+	t, err := m.db.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer t.Rollback()
+
+	versions, err := t.Collection(migrationsCollection)
+	if err != nil {
+		return err
+	}
+	current, err := migrationVersion(ctx, versions.Collection)
+	if err != nil {
+		return err
+	}
+	version, run := next(current)
+	if !run {
+		return nil
+	}
+
+	col, err := t.Collection(mg.collection)
+	if err != nil {
+		return err
+	}
+	if err := fn(ctx, col.Collection); err != nil {
+		return err
+	}
+	_, err = versions.UpsertOne(ctx, map[string]interface{}{"_id": migrationVersionID}, map[string]interface{}{
+		"$set": map[string]interface{}{"version": version, "migratedAt": time.Now()},
+	})
+	if err != nil {
+		return err
+	}
+	return t.Commit()
+}
+
+// migrationVersion returns the version stored in the migrations
+// collection, or 0 if there isn't one.
+func migrationVersion(ctx context.Context, c *Collection) (int, error) {
+	var doc struct {
+		Version int `bson:"version"`
+	}
+	err := c.GetByIDInto(ctx, migrationVersionID, &doc)
+	if errors.Is(err, ErrNoDocuments) {
+		return 0, nil
+	}
+	return doc.Version, err
+}
@@ -0,0 +1,132 @@
+package mingodb_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/korrbit/mingodb"
+	"github.com/korrbit/mingodb/testutil"
+)
+
+// updateAll returns a migration that applies the update to every
+// document, and records the version in calls.
+func updateAll(calls *[]int, version int, update map[string]interface{}) func(context.Context, *mingodb.Collection) error {
+	return func(ctx context.Context, col *mingodb.Collection) error {
+		*calls = append(*calls, version)
+		_, err := col.UpdateMany(ctx, nil, update)
+		return err
+	}
+}
+
+// assertVersion reports an error unless the database has been
+// migrated to expected.
+func assertVersion(t *testing.T, m *mingodb.Migrator, expected int) {
+	t.Helper()
+	v, err := m.Version(context.Background())
+	if err != nil {
+		t.Fatalf("Version: %v", err)
+	}
+	if v != expected {
+		t.Errorf("got version %d, expected %d", v, expected)
+	}
+}
+
+func TestMigrator(t *testing.T) {
+	ctx := context.Background()
+	db := testutil.NewTestDB(t)
+	users := db.CollectionMust("users")
+	testutil.SeedCollection(t, users, map[string]interface{}{"_id": 1, "name": "Alice"})
+
+	var calls []int
+	m := mingodb.NewMigrator(db).
+		Collection("users").
+		// Added out of order, to check they're run in order.
+		Add(2, updateAll(&calls, 2, map[string]interface{}{"$rename": map[string]interface{}{"name": "fullName"}}),
+			updateAll(&calls, -2, map[string]interface{}{"$rename": map[string]interface{}{"fullName": "name"}})).
+		Add(1, updateAll(&calls, 1, map[string]interface{}{"$set": map[string]interface{}{"status": "active"}}),
+			updateAll(&calls, -1, map[string]interface{}{"$unset": map[string]interface{}{"status": ""}}))
+	assertVersion(t, m, 0)
+
+	if err := m.Migrate(ctx); err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+	assertVersion(t, m, 2)
+	testutil.AssertDocumentExists(t, users, map[string]interface{}{"_id": 1, "fullName": "Alice", "status": "active"})
+
+	// Migrations that have been applied aren't run again.
+	if err := m.Migrate(ctx); err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+
+	if err := m.Rollback(ctx, 1); err != nil {
+		t.Fatalf("Rollback: %v", err)
+	}
+	assertVersion(t, m, 1)
+	if err := m.Rollback(ctx, 0); err != nil {
+		t.Fatalf("Rollback: %v", err)
+	}
+	assertVersion(t, m, 0)
+	testutil.AssertDocumentExists(t, users, map[string]interface{}{"_id": 1, "name": "Alice", "status": map[string]interface{}{"$exists": false}})
+
+	if expected := []int{1, 2, -2, -1}; !reflect.DeepEqual(calls, expected) {
+		t.Errorf("got calls %v, expected %v", calls, expected)
+	}
+}
+
+func TestMigratorFailure(t *testing.T) {
+	ctx := context.Background()
+	errFailed := errors.New("failed")
+	db := testutil.NewTestDB(t)
+	users := db.CollectionMust("users")
+	testutil.SeedCollection(t, users, map[string]interface{}{"_id": 1})
+
+	var calls []int
+	m := mingodb.NewMigrator(db).
+		Collection("users").
+		Add(1, updateAll(&calls, 1, map[string]interface{}{"$set": map[string]interface{}{"a": 1}}), nil).
+		Add(2, func(ctx context.Context, col *mingodb.Collection) error {
+			if _, err := col.UpdateMany(ctx, nil, map[string]interface{}{"$set": map[string]interface{}{"b": 2}}); err != nil {
+				return err
+			}
+			return errFailed
+		}, nil)
+
+	// The failed migration's writes are rolled back.
+	if err := m.Migrate(ctx); !errors.Is(err, errFailed) {
+		t.Errorf("Migrate returned %v, expected the migration's error", err)
+	}
+	assertVersion(t, m, 1)
+	testutil.AssertDocumentExists(t, users, map[string]interface{}{"_id": 1, "a": 1, "b": map[string]interface{}{"$exists": false}})
+
+	if err := m.Rollback(ctx, 0); !errors.Is(err, mingodb.ErrInvalidMigration) {
+		t.Errorf("Rollback returned %v, expected ErrInvalidMigration without a down function", err)
+	}
+	assertVersion(t, m, 1)
+}
+
+func TestMigratorInvalid(t *testing.T) {
+	ctx := context.Background()
+	db := testutil.NewTestDB(t)
+	up := func(context.Context, *mingodb.Collection) error { return nil }
+	tests := []struct {
+		name string
+		m    *mingodb.Migrator
+	}{
+		{"NoCollection", mingodb.NewMigrator(db).Add(1, up, nil)},
+		{"ZeroVersion", mingodb.NewMigrator(db).Collection("users").Add(0, up, nil)},
+		{"NoUp", mingodb.NewMigrator(db).Collection("users").Add(1, nil, nil)},
+		{"Duplicate", mingodb.NewMigrator(db).Collection("users").Add(1, up, nil).Add(1, up, nil)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.m.Migrate(ctx); !errors.Is(err, mingodb.ErrInvalidMigration) {
+				t.Errorf("Migrate returned %v, expected ErrInvalidMigration", err)
+			}
+			if err := tt.m.Rollback(ctx, 0); !errors.Is(err, mingodb.ErrInvalidMigration) {
+				t.Errorf("Rollback returned %v, expected ErrInvalidMigration", err)
+			}
+		})
+	}
+}
@@ -1,11 +1,14 @@
 package mingodb
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"os"
 	"reflect"
+	"sync"
 	"time"
 
-	"github.com/fatih/structs"
 	bolt "go.etcd.io/bbolt"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -15,53 +18,265 @@ import (
 type Database struct {
 	Path string
 
-	db *bolt.DB
+	db       *bolt.DB
+	mode     os.FileMode   // The mode the file was opened with, for Restore
+	options  *bolt.Options // The options the file was opened with, for Restore
+	memory   bool          // Whether the database was opened by OpenMemory
+	readOnly bool          // Whether the database was opened by OpenReadOnly
+	mu       sync.Mutex    // Guards ttl
+	ttl      *ttlWorker
+
+	ids IDGenerator // Generates _ids, if set (see Collection.idGenerator)
+
+	watchMu  sync.Mutex // Guards watchers
+	watchers map[string]map[*watcher]struct{}
+
+	handlesMu sync.Mutex // Guards handles
+	handles   map[string]*Collection
 }
 
 // Open creates a new database connection at the path specified.
 // If the path does not exist, it will be created.
+//
+// Open also starts the TTL worker, which deletes expired documents
+// every DefaultTTLInterval (see StartTTLWorker).
 func Open(path string) (*Database, error) {
-	db, err := bolt.Open(path, 0666, &bolt.Options{Timeout: 3 * time.Second, ReadOnly: false})
+	return OpenWithOptions(path, nil)
+}
+
+// OpenOptions configures the bbolt database opened by OpenWithOptions.
+// The zero value uses the same settings as Open.
+type OpenOptions struct {
+	// FileMode is the mode the database file is created with.
+	// Defaults to 0666 (before the umask).
+	FileMode os.FileMode
+
+	// Timeout is how long to wait for the lock on the database file,
+	// which is held by any other process that has it open. Defaults to
+	// DefaultOpenTimeout. A negative Timeout waits indefinitely.
+	Timeout time.Duration
+
+	// InitialMmapSize is the initial size, in bytes, of the database's
+	// memory map. Setting it to more than the size of the database
+	// avoids remapping the file as it grows, which blocks writes
+	// while read transactions are open.
+	InitialMmapSize int
+
+	// NoSync skips syncing the file to disk after each commit. Writes
+	// are faster, but committed changes may be lost if the system
+	// crashes.
+	NoSync bool
+
+	// NoFreelistSync skips writing the list of free pages to disk,
+	// which speeds up writes but makes opening the database slower,
+	// as the list is rebuilt by scanning the file.
+	NoFreelistSync bool
+
+	// Codec is the format documents are stored in. Defaults to
+	// BSONCodec. A database must always be opened with the same codec.
+	Codec Codec
+
+	// ReadOnly opens an existing database without allowing any writes
+	// to it. See OpenReadOnly.
+	ReadOnly bool
+
+	// IDGenerator generates the _id of documents inserted without one.
+	// Defaults to ObjectIDGenerator. It can be overridden for a single
+	// collection with CollectionOptions.
+	IDGenerator IDGenerator
+}
+
+// DefaultOpenTimeout is how long Open waits for the lock on the
+// database file.
+const DefaultOpenTimeout = 3 * time.Second
+
+// OpenWithOptions is like Open but configures the underlying bbolt
+// database. A nil opts is the same as Open.
+func OpenWithOptions(path string, opts *OpenOptions) (*Database, error) {
+	var o OpenOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.FileMode == 0 {
+		o.FileMode = 0666
+	}
+	switch {
+	case o.Timeout == 0:
+		o.Timeout = DefaultOpenTimeout
+	case o.Timeout < 0:
+		o.Timeout = 0 // bbolt waits indefinitely without a timeout.
+	}
+
+	options := &bolt.Options{
+		Timeout:         o.Timeout,
+		InitialMmapSize: o.InitialMmapSize,
+		NoSync:          o.NoSync,
+		NoFreelistSync:  o.NoFreelistSync,
+		ReadOnly:        o.ReadOnly,
+	}
+	db, err := bolt.Open(path, o.FileMode, options)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrOpeningDatabase, err)
+	}
+	if o.Codec != nil {
+		if _, ok := o.Codec.(BSONCodec); !ok {
+			codecs.Store(db, o.Codec)
+		}
+	}
+	d := &Database{Path: path, db: db, mode: o.FileMode, options: options, readOnly: o.ReadOnly, ids: o.IDGenerator}
+	d.StartTTLWorker(DefaultTTLInterval)
+	return d, nil
+}
+
+// OpenMemory creates a new, empty database that only lasts until it's
+// closed. It's the recommended way to create databases for tests:
+//
+//	db, err := mingodb.OpenMemory()
+//	if err != nil {
+//		t.Fatal(err)
+//	}
+//	defer db.Close()
+//
+// bbolt needs a file to map into memory, so the database is stored in
+// a temporary file in os.TempDir, which is deleted by Close.
+func OpenMemory() (*Database, error) {
+	f, err := os.CreateTemp("", "mingodb-*.db")
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrOpeningDatabase, err)
+	}
+	path := f.Name()
+	f.Close()
+
+	db, err := Open(path)
 	if err != nil {
-		return nil, ErrOpeningDatabase
+		os.Remove(path)
+		return nil, err
 	}
-	return &Database{Path: path, db: db}, nil
+	db.memory = true
+	return db, nil
+}
+
+// OpenReadOnly opens an existing database without allowing any
+// writes to it. Methods that write to the database return ErrReadOnly,
+// and the TTL worker isn't started.
+//
+// The database can't be opened while another process has it open with
+// Open, as bbolt locks the file, so OpenReadOnly waits up to
+// DefaultOpenTimeout for the lock to be released before failing.
+//
+// Use OpenWithOptions with ReadOnly set to open a database stored with
+// a different Codec.
+func OpenReadOnly(path string) (*Database, error) {
+	return OpenWithOptions(path, &OpenOptions{ReadOnly: true})
+}
+
+// IsMemory reports whether the database was opened by OpenMemory.
+func (db *Database) IsMemory() bool {
+	return db.memory
 }
 
 // Close closes the database connection and cleans up any resources.
 // Will block until all pending operations have completed.
 func (db *Database) Close() error {
-	return db.db.Close()
+	db.StopTTLWorker()
+	db.closeWatchers()
+	err := db.db.Close()
+	codecs.Delete(db.db)
+	forgetSchemas(db.db)
+	if err != nil {
+		return err
+	}
+	if db.memory {
+		return os.Remove(db.Path)
+	}
+	return nil
+}
+
+// view runs fn in a read-only transaction.
+func (db *Database) view(fn func(tx *bolt.Tx) error) error {
+	return boltError(db.db.View(fn))
+}
+
+// update runs fn in a read-write transaction. Returns ErrReadOnly
+// if the database was opened by OpenReadOnly.
+func (db *Database) update(fn func(tx *bolt.Tx) error) error {
+	if db.readOnly {
+		return ErrReadOnly
+	}
+	return boltError(db.db.Update(fn))
+}
+
+// boltError translates errors returned by bolt into the
+// package's own errors.
+func boltError(err error) error {
+	if errors.Is(err, bolt.ErrDatabaseNotOpen) {
+		return ErrDatabaseClosed
+	}
+	return err
 }
 
 // Collection returns a DB collection object with the
 // specified name. If the collection does not exist,
-// it will be created.
-func (db *Database) Collection(name string) (*Collection, error) {
-	// Is the collection name empty?
+// it will be created, unless the database is read-only,
+// in which case ErrCollectionNotFound is returned.
+//
+// Names that the database uses for its own buckets, "__indexes",
+// "__idx" and names starting with "__seq_", "__schema_", "__meta_" or
+// "__history_", are reserved and return ErrInvalidCollectionName.
+//
+// Every call with the same name returns the same Collection, the
+// collection's handle, so settings made through one call, such as
+// Use, EnableSoftDelete or SetValidator, apply wherever the collection
+// is used, including in Transactions, Snapshots and the TTL worker.
+// Optional CollectionOptions are applied to the handle each time they
+// are passed, so middleware passed to two calls is registered twice.
+func (db *Database) Collection(name string, opts ...CollectionOptions) (*Collection, error) {
+	// Is the collection name empty or reserved?
 	if name == "" {
 		return nil, ErrEmptyBucketName
 	}
+	if isInternalBucket(name) {
+		return nil, fmt.Errorf("%s: %w", name, ErrInvalidCollectionName)
+	}
+	o := mergeCollectionOptions(opts)
+	c := *db.handle(name)
+	if o.IDGenerator != nil {
+		c.ids = o.IDGenerator
+	}
+
+	// Is the database read-only? If so, the collection must exist.
+	if db.readOnly {
+		err := db.view(func(tx *bolt.Tx) error {
+			_, err := c.bucket(tx)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		return db.register(name, o), nil
+	}
 
 	// If not, create it.
-	err := db.db.Update(func(tx *bolt.Tx) error {
-		_, err := tx.CreateBucketIfNotExists([]byte(name))
-		return err
+	err := db.update(func(tx *bolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists([]byte(name)); err != nil {
+			return err
+		}
+		return c.prepareSequence(tx)
 	})
 	if err != nil {
 		return nil, err
 	}
 
-	// Return the collection object.
-	return &Collection{db: db, name: name}, nil
+	// Return the collection's handle.
+	return db.register(name, o), nil
 }
 
 // CollectionMust returns a DB collection object with the
 // specified name. If the collection does not exist,
 // it will be created. Note: This function wraps Collection()
 // and panics if an error is returned.
-func (db *Database) CollectionMust(name string) *Collection {
-	c, err := db.Collection(name)
+func (db *Database) CollectionMust(name string, opts ...CollectionOptions) *Collection {
+	c, err := db.Collection(name, opts...)
 	if err != nil {
 		panic(err)
 	}
@@ -72,6 +287,45 @@ func (db *Database) CollectionMust(name string) *Collection {
 type Collection struct {
 	db   *Database
 	name string
+	txn  txRunner    // Transaction or Snapshot the collection belongs to, if any
+	ids  IDGenerator // Overrides the database's IDGenerator, if set
+
+	softDelete string // Field set by soft deletes, if enabled
+	versioning bool   // Whether old versions are kept (see EnableVersioning)
+
+	middleware []Middleware                           // Called around operations (see Use)
+	validator  func(doc map[string]interface{}) error // Validates stored documents, if set
+}
+
+// register returns the named collection's handle, creating it if
+// there isn't one yet, and applies o to it.
+func (db *Database) register(name string, o CollectionOptions) *Collection {
+	db.handlesMu.Lock()
+	defer db.handlesMu.Unlock()
+	c, ok := db.handles[name]
+	if !ok {
+		if db.handles == nil {
+			db.handles = make(map[string]*Collection)
+		}
+		c = &Collection{db: db, name: name}
+		db.handles[name] = c
+	}
+	if o.IDGenerator != nil {
+		c.ids = o.IDGenerator
+	}
+	c.middleware = append(c.middleware, o.Middleware...)
+	return c
+}
+
+// handle returns the named collection's handle or, if Database.Collection
+// hasn't returned one yet, a Collection with the default settings.
+func (db *Database) handle(name string) *Collection {
+	db.handlesMu.Lock()
+	defer db.handlesMu.Unlock()
+	if c, ok := db.handles[name]; ok {
+		return c
+	}
+	return &Collection{db: db, name: name}
 }
 
 // Name returns the name of the collection.
@@ -84,96 +338,293 @@ func (c *Collection) Database() *Database {
 	return c.db
 }
 
-// Drop deletes the collection.
+// Drop deletes the collection, along with its indexes.
 func (c *Collection) Drop() error {
-	return c.db.db.Update(func(tx *bolt.Tx) error {
-		return tx.DeleteBucket([]byte(c.name))
+	return c.write(func(tx *bolt.Tx) error {
+		err := tx.DeleteBucket([]byte(c.name))
+		if errors.Is(err, bolt.ErrBucketNotFound) {
+			return fmt.Errorf("%s: %w", c.name, ErrCollectionNotFound)
+		}
+		if err != nil {
+			return err
+		}
+		if err := dropSequence(tx, c.name); err != nil {
+			return err
+		}
+		if err := dropSchema(tx, c.name); err != nil {
+			return err
+		}
+		if err := dropMeta(tx, c.name); err != nil {
+			return err
+		}
+		if err := dropHistory(tx, c.name); err != nil {
+			return err
+		}
+		return c.dropIndexes(tx)
 	})
 }
 
-// InsertOne inserts a single document into the collection.
-// Returns the _id of the inserted document (if generated by the
-// DB, will be of type primitive.ObjectID).
-//
-// Expects doc to be either a struct or a map[string]interface{}.
-// Note that if doc is a struct, only expored fields will be stored.
-func (c *Collection) InsertOne(doc interface{}) (InsertID, error) {
-	// Validate the document. Is it a struct or a map?
-	t := reflect.TypeOf(doc)
-	if t.Kind() != reflect.Struct && t.Kind() != reflect.Map {
-		return nil, ErrInvalidType
+// read runs fn in a read-only transaction or, if the collection
+// belongs to a Transaction or a Snapshot, in its transaction.
+func (c *Collection) read(fn func(tx *bolt.Tx) error) error {
+	if c.txn != nil {
+		return c.txn.run(fn, false)
 	}
+	return c.db.view(fn)
+}
 
-	// If it's a struct, convert it to a map.
-	var m map[string]interface{}
-	if t.Kind() == reflect.Struct {
-		m = structs.Map(doc)
+// write runs fn in a read-write transaction or, if the collection
+// belongs to a Transaction, in that transaction.
+func (c *Collection) write(fn func(tx *bolt.Tx) error) error {
+	if c.txn != nil {
+		return c.txn.run(fn, true)
 	}
-	if t.Kind() == reflect.Map {
-		var ok bool
+	return c.db.update(fn)
+}
 
-		// Can the map be converted to a map[string]interface{}?
-		m, ok = doc.(map[string]interface{})
-		if !ok {
-			return nil, ErrInvalidType
-		}
+// bucket returns the collection's bucket within tx. The database's
+// own buckets aren't collections, so they're never returned.
+func (c *Collection) bucket(tx *bolt.Tx) (*bolt.Bucket, error) {
+	if isInternalBucket(c.name) {
+		return nil, fmt.Errorf("%s: %w", c.name, ErrInvalidCollectionName)
 	}
-
-	// Check if doc has an _id field.
-	// If not, generate one and add it to the doc.
-	id, ok := m["_id"]
-	if !ok {
-		id = primitive.NewObjectID()
-		m["_id"] = id
+	b := tx.Bucket([]byte(c.name))
+	if b == nil {
+		return nil, fmt.Errorf("%s: %w", c.name, ErrCollectionNotFound)
 	}
+	return b, nil
+}
+
+// writeTx is a collection's bucket within a read-write transaction.
+// Its methods keep the collection's indexes up to date, so every
+// write to the bucket should go through them.
+type writeTx struct {
+	c       *Collection
+	tx      *bolt.Tx
+	b       *bolt.Bucket
+	indexes []*index
+	op      *operation // The operation the writes are part of, if any
+}
 
-	// Validate the id and marshal it into bytes.
-	_, bid, err := bson.MarshalValue(id) // Also returns id's reflect type. Not currently used.
+// writeTx returns the collection's bucket within tx, ready for writing
+// as part of ctx's operation.
+func (c *Collection) writeTx(ctx context.Context, tx *bolt.Tx) (*writeTx, error) {
+	b, err := c.bucket(tx)
 	if err != nil {
 		return nil, err
 	}
-
-	// Marshal the document into bytes.
-	bdoc, err := bson.Marshal(m)
+	indexes, err := c.indexes(tx)
 	if err != nil {
 		return nil, err
 	}
+	return &writeTx{c: c, tx: tx, b: b, indexes: indexes, op: operationFrom(ctx)}, nil
+}
 
-	// Insert the document.
-	err = c.db.db.Update(func(tx *bolt.Tx) error {
-		b := tx.Bucket([]byte(c.name))
-		return b.Put(
-			bid,
-			bdoc,
-		)
-	})
+// insert stores a new document. Returns ErrDuplicateKey if a
+// document with the same _id already exists.
+func (w *writeTx) insert(id interface{}, key, data []byte) error {
+	if w.b.Get(key) != nil {
+		return fmt.Errorf("_id %v: %w", id, ErrDuplicateKey)
+	}
+	if err := w.makeRoom(); err != nil {
+		return err
+	}
+	if err := w.put(key, data); err != nil {
+		return err
+	}
+	w.publish("insert", nil, data)
+	w.written(id)
+	return nil
+}
+
+// update stores the updated version of the document under key and
+// returns the document as it was stored (see change).
+func (w *writeTx) update(key, data []byte) ([]byte, error) {
+	return w.change("update", key, data)
+}
+
+// replace stores a replacement for the document under key and
+// returns the document as it was stored (see change).
+func (w *writeTx) replace(key, data []byte) ([]byte, error) {
+	return w.change("replace", key, data)
+}
+
+// change stores a new version of the document under key and
+// publishes an op event to the collection's watchers. The document's
+// VersionField is incremented (see nextVersion) and the document is
+// returned as it was stored.
+func (w *writeTx) change(op string, key, data []byte) ([]byte, error) {
+	old := w.b.Get(key)
+	if old != nil {
+		var err error
+		if data, err = w.nextVersion(old, data); err != nil {
+			return nil, err
+		}
+		if w.c.versioning {
+			if err := w.recordHistory(op, key, old); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := w.put(key, data); err != nil {
+		return nil, err
+	}
+	w.publish(op, old, data)
+	if w.op != nil {
+		var id interface{}
+		if err := bson.Raw(data).Lookup("_id").Unmarshal(&id); err != nil {
+			return nil, err
+		}
+		w.written(id)
+	}
+	return data, nil
+}
+
+// nextVersion returns data with its VersionField set to one more than
+// the version of the document it replaces, old, unless data already
+// has a later version. Documents without a version have version 0.
+func (w *writeTx) nextVersion(old, data []byte) ([]byte, error) {
+	_, prev, err := decodeDocument(w.tx, old)
 	if err != nil {
 		return nil, err
 	}
+	version, _ := toInt(prev[VersionField])
 
-	// Return the _id of the inserted document.
-	return id, nil
+	var doc map[string]interface{}
+	if err := bson.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if v, _ := toInt(doc[VersionField]); v > version {
+		return data, nil
+	}
+	doc[VersionField] = version + 1
+	return bson.Marshal(doc)
+}
+
+// put stores a document under key, replacing any existing document.
+func (w *writeTx) put(key, data []byte) error {
+	if err := w.validate(data); err != nil {
+		return err
+	}
+	if len(w.indexes) > 0 {
+		if err := w.unindex(key); err != nil {
+			return err
+		}
+		var doc map[string]interface{}
+		if err := bson.Unmarshal(data, &doc); err != nil {
+			return err
+		}
+		for _, idx := range w.indexes {
+			if err := idx.add(key, doc); err != nil {
+				return err
+			}
+		}
+	}
+	stored, err := encodeDocument(w.tx, data)
+	if err != nil {
+		return err
+	}
+	return w.b.Put(key, stored)
+}
+
+// delete deletes the document stored under key, if there is one.
+func (w *writeTx) delete(key []byte) error {
+	old := w.b.Get(key)
+	if old == nil {
+		return nil
+	}
+	if w.c.versioning {
+		if err := w.recordHistory("delete", key, old); err != nil {
+			return err
+		}
+	}
+	if err := w.unindex(key); err != nil {
+		return err
+	}
+	w.publish("delete", old, nil)
+	if w.op != nil {
+		_, doc, err := decodeDocument(w.tx, old)
+		if err != nil {
+			return err
+		}
+		w.written(doc["_id"])
+	}
+	return w.b.Delete(key)
 }
 
-func (c *Collection) GetByID(id interface{}) (interface{}, error) {
-	_, bid, err := bson.MarshalValue(id)
+// unindex removes the document stored under key, if there is one,
+// from the collection's indexes.
+func (w *writeTx) unindex(key []byte) error {
+	old := w.b.Get(key)
+	if old == nil || len(w.indexes) == 0 {
+		return nil
+	}
+	_, doc, err := decodeDocument(w.tx, old)
 	if err != nil {
+		return err
+	}
+	for _, idx := range w.indexes {
+		if err := idx.remove(key, doc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// InsertOne inserts a single document into the collection.
+// Returns the _id of the inserted document (if generated by the
+// DB, will be of type primitive.ObjectID unless the collection has
+// another IDGenerator).
+//
+// Expects doc to be either a struct or a map[string]interface{}.
+// Note that if doc is a struct, only expored fields will be stored.
+func (c *Collection) InsertOne(ctx context.Context, doc interface{}) (_ InsertID, err error) {
+	ctx, end := c.startOperation(ctx, "insertOne", nil, nil)
+	defer func() { end(err) }()
+	if err := c.beforeInsert(ctx, doc); err != nil {
 		return nil, err
 	}
 
-	var doc []byte
-	err = c.db.db.View(func(tx *bolt.Tx) error {
-		b := tx.Bucket([]byte(c.name))
-		doc = b.Get(bid)
-		if doc == nil {
-			return errors.New("document not found")
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var id interface{}
+	err = c.write(func(tx *bolt.Tx) error {
+		w, err := c.writeTx(ctx, tx)
+		if err != nil {
+			return err
 		}
-		return nil
+
+		// Convert the document, generate an _id if needed and
+		// marshal it into bytes.
+		var bid, bdoc []byte
+		id, bid, bdoc, err = prepareDocument(doc, w.newID, w.key)
+		if err != nil {
+			return err
+		}
+
+		// Insert the document.
+		return w.insert(id, bid, bdoc)
 	})
 	if err != nil {
 		return nil, err
 	}
 
+	// Return the _id of the inserted document.
+	c.afterInsert(ctx, doc, id)
+	return id, nil
+}
+
+// GetByID returns the document with the specified _id.
+func (c *Collection) GetByID(ctx context.Context, id interface{}) (_ interface{}, err error) {
+	ctx, end := c.startOperation(ctx, "getByID", id, nil)
+	defer func() { end(err) }()
+	doc, err := c.getByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
 	var m map[string]interface{}
 	err = bson.Unmarshal(doc, &m)
 	if err != nil {
@@ -183,73 +634,943 @@ func (c *Collection) GetByID(id interface{}) (interface{}, error) {
 	return m, nil
 }
 
-// InsertMany inserts multiple documents into the collection.
-// Returns an array of the inserted documents' _id values
-// (If generated by the DB, will be of type primitive.ObjectID).
-func (c *Collection) InsertMany(docs []interface{}) ([]InsertID, error) {
-	return nil, nil
+// GetByIDInto decodes the document with the specified _id into
+// result, which should be a pointer to a struct or a map.
+func (c *Collection) GetByIDInto(ctx context.Context, id interface{}, result interface{}) (err error) {
+	ctx, end := c.startOperation(ctx, "getByID", id, nil)
+	defer func() { end(err) }()
+	doc, err := c.getByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	return bson.Unmarshal(doc, result)
 }
 
-// Find returns (up to) multiple documents from the collection based on the
-// filter provided.
-func (c *Collection) Find(filter interface{}) (MultiResult, interface{}, error) {
+// getByID returns the raw BSON of the document with the specified _id.
+func (c *Collection) getByID(ctx context.Context, id interface{}) ([]byte, error) {
+	if _, _, err := bson.MarshalValue(id); err != nil {
+		return nil, err
+	}
 
-	// filter not yet implimented, this will return everything
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 
 	var doc []byte
-	var m map[string]interface{}
-	var m2 []map[string]interface{}
-	err := c.db.db.View(func(tx *bolt.Tx) error {
-		b := tx.Bucket([]byte(c.name))
-		b.ForEach(func(k, v []byte) error {
-			doc = v
-			err := bson.Unmarshal(doc, &m)
+	err := c.read(func(tx *bolt.Tx) error {
+		b, err := c.bucket(tx)
+		if err != nil {
+			return err
+		}
+		bid, err := documentKey(tx, c.name, id)
+		if err != nil {
+			return err
+		}
+		v := b.Get(bid)
+		if v == nil {
+			return fmt.Errorf("_id %v: %w", id, ErrNoDocuments)
+		}
+		data, m, err := decodeDocument(tx, v)
+		if err != nil {
+			return err
+		}
+		if c.deleted(m) {
+			return fmt.Errorf("_id %v: %w", id, ErrNoDocuments)
+		}
+		doc = append([]byte(nil), data...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// GetByIDs returns the documents with the specified _ids, in the
+// same order as the ids. A missing document is returned as nil.
+//
+// All documents are fetched in a single transaction.
+func (c *Collection) GetByIDs(ctx context.Context, ids []interface{}) (_ []interface{}, err error) {
+	ctx, end := c.startOperation(ctx, "getByIDs", nil, nil)
+	defer func() { end(err) }()
+	for i, id := range ids {
+		if _, _, err := bson.MarshalValue(id); err != nil {
+			return nil, fmt.Errorf("_id %d: %w", i, err)
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	docs := make([]interface{}, len(ids))
+	err = c.read(func(tx *bolt.Tx) error {
+		b, err := c.bucket(tx)
+		if err != nil {
+			return err
+		}
+		for i, id := range ids {
+			k, err := documentKey(tx, c.name, id)
 			if err != nil {
 				return err
 			}
-			m2 = append(m2, m)
-			return nil
-		})
+			v := b.Get(k)
+			if v == nil {
+				continue
+			}
+			_, m, err := decodeDocument(tx, v)
+			if err != nil {
+				return err
+			}
+			if !c.deleted(m) {
+				docs[i] = m
+			}
+		}
 		return nil
 	})
 	if err != nil {
-		return MultiResult{ResultCount: 0}, nil, err
+		return nil, err
 	}
-
-	return MultiResult{ResultCount: len(m2)}, m2, nil
+	return docs, nil
 }
 
-// FindOne returns the first document (if any) that matches the filter.
-func (c *Collection) FindOne(filter interface{}, result interface{}) (*SingleResult, error) {
-	return nil, nil
-}
+// InsertMany inserts multiple documents into the collection.
+// Returns an array of the inserted documents' _id values
+// (If generated by the DB, will be of type primitive.ObjectID unless
+// the collection has another IDGenerator).
+//
+// All documents are inserted in a single transaction. If any
+// document is invalid, none of the documents are inserted.
+func (c *Collection) InsertMany(ctx context.Context, docs []interface{}) (_ []InsertID, err error) {
+	ctx, end := c.startOperation(ctx, "insertMany", nil, nil)
+	defer func() { end(err) }()
+	for i, doc := range docs {
+		if err := c.beforeInsert(ctx, doc); err != nil {
+			return nil, fmt.Errorf("document %d: %w", i, err)
+		}
+	}
 
-// CountDocuments returns the number of documents that match the filter.
-func (c *Collection) CountDocuments(filter interface{}) (int, error) {
-	return 0, nil
-}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 
-// UpdateOne
-func (c *Collection) UpdateOne(doc interface{}) (*UpdateResult, error) {
-	return nil, nil
-}
+	ids := make([]InsertID, len(docs))
+	err = c.write(func(tx *bolt.Tx) error {
+		w, err := c.writeTx(ctx, tx)
+		if err != nil {
+			return err
+		}
+		for i, doc := range docs {
+			// Has the operation been cancelled?
+			if err := ctx.Err(); err != nil {
+				return err
+			}
 
-// UpdateMany
-func (c *Collection) UpdateMany(docs []interface{}) (*UpdateResult, error) {
-	return nil, nil
-}
+			// Convert and marshal the document.
+			id, bid, bdoc, err := prepareDocument(doc, w.newID, w.key)
+			if err != nil {
+				return fmt.Errorf("document %d: %w", i, err)
+			}
 
-// DeleteOne deletes a single document into the collection based on the filter
-func (c *Collection) DeleteOne(filter interface{}) (*DeleteResult, error) {
-	return nil, nil
-}
+			// Store it. Returning an error rolls back the
+			// whole transaction.
+			if err := w.insert(id, bid, bdoc); err != nil {
+				return fmt.Errorf("document %d: %w", i, err)
+			}
+			ids[i] = id
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
 
-// DeleteMany inserts multiple documents into the collection.
-func (c *Collection) DeleteMany(filter []interface{}) (*DeleteResult, error) {
-	return nil, nil
+	// Return the _ids in the same order as the documents.
+	for i, doc := range docs {
+		c.afterInsert(ctx, doc, ids[i])
+	}
+	return ids, nil
 }
 
-// // Aggregate
-// func (c *Collection) Aggregate(pipeline interface{}) (*MultiResult, error) {
-// 	return nil, nil
-// }
+// Find returns (up to) multiple documents from the collection based on the
+// filter provided.
+//
+// The filter should be a struct or a map[string]interface{}. A document
+// matches if every field in the filter is present in the document with
+// an equal value. A nil filter matches every document.
+//
+// Nested fields and array elements can be matched with dot-notation,
+// such as {"address.city": "London"} or {"scores.0": 100}.
+//
+// Fields can also be matched with comparison operators, for example
+// {"age": {"$gte": 18, "$lt": 65}}. Numbers are compared by value,
+// strings lexicographically and dates chronologically.
+//
+// If one of the collection's indexes covers the filter's equality
+// conditions, the documents are found through the index rather than
+// by scanning the whole collection. See Explain.
+//
+// Optional FindOptions can be used to skip, limit, sort and project
+// the results.
+func (c *Collection) Find(ctx context.Context, filter interface{}, opts ...FindOptions) (_ *MultiResult, err error) {
+	ctx, end := c.startOperation(ctx, "find", filter, nil)
+	defer func() { end(err) }()
+	f, err := parseFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	docs, total, err := c.find(ctx, c.visible(f), mergeFindOptions(opts), true)
+	if err != nil {
+		return nil, err
+	}
+	return &MultiResult{ResultCount: len(docs), TotalMatched: total, data: docs}, nil
+}
+
+// FindOne returns the first document (if any) that matches the filter.
+// The filter follows the same rules as Find.
+//
+// If no document matches, the returned SingleResult's Decode method
+// will return ErrNoDocuments. Optional FindOptions follow the same
+// rules as Find; Limit is ignored.
+func (c *Collection) FindOne(ctx context.Context, filter interface{}, opts ...FindOptions) (_ *SingleResult, err error) {
+	ctx, end := c.startOperation(ctx, "findOne", filter, nil)
+	defer func() { end(err) }()
+	f, err := parseFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	o := mergeFindOptions(opts)
+	o.Limit = 1
+
+	docs, _, err := c.find(ctx, c.visible(f), o, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(docs) == 0 {
+		return &SingleResult{err: ErrNoDocuments}, nil
+	}
+	return &SingleResult{data: docs[0]}, nil
+}
+
+// find returns the raw BSON of the documents that match the filter,
+// after sorting, skipping, limiting and projecting them according to
+// o, along with the total number of matching documents.
+//
+// If countAll is false, the scan stops as soon as enough documents
+// have been found and the total is not accurate.
+func (c *Collection) find(ctx context.Context, f map[string]interface{}, o FindOptions, countAll bool) ([][]byte, int, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	var matches []match
+	var total int
+	err := c.read(func(tx *bolt.Tx) error {
+		b, err := c.bucket(tx)
+		if err != nil {
+			return err
+		}
+		indexes, err := c.indexes(tx)
+		if err != nil {
+			return err
+		}
+		matches, total, err = findMatches(ctx, b, indexes, f, o, countAll)
+		return err
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	docs := make([][]byte, len(matches))
+	for i, m := range matches {
+		docs[i] = m.data
+	}
+	return docs, total, nil
+}
+
+// findMatches returns the documents in the bucket that match the
+// filter, after sorting, skipping, limiting and projecting them
+// according to o, along with the total number of matching documents.
+// The data of each match holds the projected document. The documents
+// are found through one of the bucket's indexes if o selects one.
+//
+// If countAll is false, the scan stops as soon as enough documents
+// have been found and the total is not accurate.
+func findMatches(ctx context.Context, b *bolt.Bucket, indexes []*index, f map[string]interface{}, o FindOptions, countAll bool) ([]match, int, error) {
+	include, err := validateProjection(o.Projection)
+	if err != nil {
+		return nil, 0, err
+	}
+	scan, err := planScan(indexes, f, o)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Sorted results can only be paginated once every matching
+	// document has been found. Otherwise, skip and limit can be
+	// applied during the scan.
+	// Text search results are sorted by score.
+	text := scan != nil && scan.text
+	sorted := len(o.Sort) > 0 || text
+
+	var matches []match
+	var total int
+	err = scanPlan(ctx, b, scan, f, func(k, v []byte, doc map[string]interface{}) (bool, error) {
+		total++
+		if !sorted {
+			if total <= o.Skip {
+				return true, nil
+			}
+			if o.Limit > 0 && len(matches) >= o.Limit {
+				return countAll, nil
+			}
+		}
+
+		// Bolt's keys and values are only valid for the life
+		// of the transaction, so copy them.
+		matches = append(matches, match{
+			key:  append([]byte(nil), k...),
+			data: append([]byte(nil), v...),
+			doc:  doc,
+		})
+		return true, nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if sorted {
+		if len(o.Sort) > 0 {
+			sortMatches(matches, o.Sort)
+		} else {
+			sortByScore(matches, scan)
+		}
+		matches = o.paginate(matches)
+	}
+
+	// Project the documents, if requested.
+	if len(o.Projection) > 0 {
+		for i := range matches {
+			matches[i].data, err = bson.Marshal(projectDocument(matches[i].doc, o.Projection, include))
+			if err != nil {
+				return nil, 0, err
+			}
+		}
+	}
+	return matches, total, nil
+}
+
+// CountDocuments returns the number of documents that match the filter.
+// The filter follows the same rules as Find.
+func (c *Collection) CountDocuments(ctx context.Context, filter interface{}) (_ int, err error) {
+	ctx, end := c.startOperation(ctx, "countDocuments", filter, nil)
+	defer func() { end(err) }()
+	f, err := parseFilter(filter)
+	if err != nil {
+		return 0, err
+	}
+	f = c.visible(f)
+
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	var n int
+	err = c.read(func(tx *bolt.Tx) error {
+		b, err := c.bucket(tx)
+		if err != nil {
+			return err
+		}
+
+		// If there's no filter, every document matches so there's
+		// no need to decode them.
+		if len(f) == 0 {
+			n = countKeys(tx, b)
+			return nil
+		}
+
+		return scanMatches(ctx, b, f, func(k, v []byte, doc map[string]interface{}) (bool, error) {
+			n++
+			return true, nil
+		})
+	})
+	if err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// Distinct returns the unique values of a field across the documents
+// that match the filter. The filter follows the same rules as Find.
+//
+// The field can use dot-notation to refer to a nested field, such as
+// "address.city". If the field holds an array, each of its elements
+// is treated as a separate value.
+func (c *Collection) Distinct(ctx context.Context, field string, filter interface{}) (_ []interface{}, err error) {
+	ctx, end := c.startOperation(ctx, "distinct", filter, nil)
+	defer func() { end(err) }()
+	f, err := parseFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	f = c.visible(f)
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	values := []interface{}{}
+	add := func(v interface{}) {
+		for _, seen := range values {
+			if reflect.DeepEqual(seen, v) {
+				return
+			}
+		}
+		values = append(values, v)
+	}
+
+	err = c.read(func(tx *bolt.Tx) error {
+		b, err := c.bucket(tx)
+		if err != nil {
+			return err
+		}
+		return scanMatches(ctx, b, f, func(k, v []byte, doc map[string]interface{}) (bool, error) {
+			val, ok := lookupPath(doc, field)
+			if !ok {
+				return true, nil
+			}
+			if arr, ok := val.(primitive.A); ok {
+				for _, elem := range arr {
+					add(elem)
+				}
+				return true, nil
+			}
+			add(val)
+			return true, nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// UpdateOne applies the update to the first document that matches
+// the filter. The filter follows the same rules as Find.
+//
+// The update should be a document of update operators, for example:
+//
+//	map[string]interface{}{"$set": map[string]interface{}{"field": value}}
+//
+// No two operators may change the same field, or a field and a field
+// embedded in it, as the order they're applied in isn't defined; such
+// updates return ErrInvalidUpdate.
+func (c *Collection) UpdateOne(ctx context.Context, filter interface{}, update interface{}) (_ *UpdateResult, err error) {
+	ctx, end := c.startOperation(ctx, "updateOne", filter, update)
+	defer func() { end(err) }()
+	return c.update(ctx, filter, update, false)
+}
+
+// UpdateMany applies the update to every document that matches the
+// filter. The filter and update follow the same rules as UpdateOne.
+//
+// All documents are updated in a single transaction. If any update
+// fails, none of the documents are modified.
+func (c *Collection) UpdateMany(ctx context.Context, filter interface{}, update interface{}) (_ *UpdateResult, err error) {
+	ctx, end := c.startOperation(ctx, "updateMany", filter, update)
+	defer func() { end(err) }()
+	return c.update(ctx, filter, update, true)
+}
+
+// update applies the update to the first document that matches the
+// filter or, if many is true, to every document that matches.
+func (c *Collection) update(ctx context.Context, filter interface{}, update interface{}, many bool) (*UpdateResult, error) {
+	if err := c.beforeUpdate(ctx, filter, update); err != nil {
+		return nil, err
+	}
+
+	f, err := parseFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	u, err := parseUpdate(update)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := c.updateMatches(ctx, c.visible(f), u, many)
+	if err != nil {
+		return nil, err
+	}
+	c.afterUpdate(ctx, res)
+	return res, nil
+}
+
+// updateMatches applies the parsed update to the first document that
+// matches the parsed filter or, if many is true, to every document
+// that matches.
+func (c *Collection) updateMatches(ctx context.Context, f, u map[string]interface{}, many bool) (*UpdateResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	res := &UpdateResult{}
+	err := c.write(func(tx *bolt.Tx) error {
+		w, err := c.writeTx(ctx, tx)
+		if err != nil {
+			return err
+		}
+
+		// Find the matching documents. The bucket can't be
+		// modified during the scan so hold on to them.
+		var matches []match
+		err = scanMatches(ctx, w.b, f, func(k, v []byte, doc map[string]interface{}) (bool, error) {
+			matches = append(matches, match{
+				key:  append([]byte(nil), k...),
+				data: append([]byte(nil), v...),
+				doc:  doc,
+			})
+			return many, nil
+		})
+		if err != nil {
+			return err
+		}
+
+		// Apply the update to each document.
+		for _, m := range matches {
+			res.MatchedCount++
+			_, modified, err := updateMatch(w, m, u)
+			if err != nil {
+				return err
+			}
+			if modified {
+				res.UpdateCount++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// UpsertOne applies the update to the first document that matches
+// the filter or, if no document matches, inserts a new one. The filter
+// and update follow the same rules as UpdateOne.
+//
+// The inserted document is built from the filter's equality conditions
+// with the update applied to it. It's given a new _id unless the filter
+// or update sets one.
+func (c *Collection) UpsertOne(ctx context.Context, filter interface{}, update interface{}) (_ *UpsertResult, err error) {
+	ctx, end := c.startOperation(ctx, "upsertOne", filter, update)
+	defer func() { end(err) }()
+	if err := c.beforeUpdate(ctx, filter, update); err != nil {
+		return nil, err
+	}
+
+	f, err := parseFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	u, err := parseUpdate(update)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	res := &UpsertResult{}
+	err = c.write(func(tx *bolt.Tx) error {
+		w, err := c.writeTx(ctx, tx)
+		if err != nil {
+			return err
+		}
+
+		// Find the first matching document.
+		var m *match
+		err = scanMatches(ctx, w.b, c.visible(f), func(k, v []byte, doc map[string]interface{}) (bool, error) {
+			m = &match{key: append([]byte(nil), k...), data: append([]byte(nil), v...), doc: doc}
+			return false, nil
+		})
+		if err != nil {
+			return err
+		}
+
+		// If there is one, update it.
+		if m != nil {
+			res.MatchedCount = 1
+			_, modified, err := updateMatch(w, *m, u)
+			if modified {
+				res.UpdateCount = 1
+			}
+			return err
+		}
+
+		// Otherwise, build a new document and insert it.
+		doc := equalityFields(f)
+		if err := applyUpdate(doc, u); err != nil {
+			return err
+		}
+		id, bid, bdoc, err := prepareDocument(doc, w.newID, w.key)
+		if err != nil {
+			return err
+		}
+		if err := w.insert(id, bid, bdoc); err != nil {
+			return err
+		}
+		res.Upserted = true
+		res.UpsertedID = id
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	c.afterUpdate(ctx, &res.UpdateResult)
+	return res, nil
+}
+
+// ReplaceOne replaces the first document that matches the filter
+// with the replacement document. The filter follows the same rules
+// as Find and the replacement the same rules as InsertOne.
+//
+// The replaced document keeps its _id. Returns ErrInvalidDocument
+// if the replacement has a different _id.
+func (c *Collection) ReplaceOne(ctx context.Context, filter interface{}, replacement interface{}) (_ *UpdateResult, err error) {
+	ctx, end := c.startOperation(ctx, "replaceOne", filter, replacement)
+	defer func() { end(err) }()
+	if err := c.beforeUpdate(ctx, filter, replacement); err != nil {
+		return nil, err
+	}
+
+	f, err := parseFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	r, err := toDocument(replacement)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidDocument, err)
+	}
+	r, err = normalizeDocument(r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidDocument, err)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	res := &UpdateResult{}
+	err = c.write(func(tx *bolt.Tx) error {
+		w, err := c.writeTx(ctx, tx)
+		if err != nil {
+			return err
+		}
+
+		// Find the first matching document.
+		var m *match
+		err = scanMatches(ctx, w.b, c.visible(f), func(k, v []byte, doc map[string]interface{}) (bool, error) {
+			m = &match{key: append([]byte(nil), k...), doc: doc}
+			return false, nil
+		})
+		if err != nil || m == nil {
+			return err
+		}
+		res.MatchedCount = 1
+
+		// The replacement must keep the original _id.
+		id := m.doc["_id"]
+		if rid, ok := r["_id"]; ok && !valuesEqual(rid, id) {
+			return fmt.Errorf("%w: _id cannot be modified", ErrInvalidDocument)
+		}
+		r["_id"] = id
+
+		// Keep the document's version, unless the replacement sets it.
+		if v, ok := m.doc[VersionField]; ok {
+			if _, ok := r[VersionField]; !ok {
+				r[VersionField] = v
+			}
+		}
+
+		// Is it any different?
+		if reflect.DeepEqual(m.doc, r) {
+			return nil
+		}
+		bdoc, err := bson.Marshal(r)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidDocument, err)
+		}
+		if _, err := w.replace(m.key, bdoc); err != nil {
+			return err
+		}
+		res.UpdateCount = 1
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	c.afterUpdate(ctx, res)
+	return res, nil
+}
+
+// FindOneAndUpdate applies the update to the first document that
+// matches the filter and returns the document. The filter and update
+// follow the same rules as UpdateOne.
+//
+// By default the document is returned as it was before the update.
+// Set ReturnDocument to After to return the updated document instead.
+// If no document matches, the returned SingleResult's Decode method
+// will return ErrNoDocuments.
+func (c *Collection) FindOneAndUpdate(ctx context.Context, filter interface{}, update interface{}, opts ...FindOneAndUpdateOptions) (_ *SingleResult, err error) {
+	ctx, end := c.startOperation(ctx, "findOneAndUpdate", filter, update)
+	defer func() { end(err) }()
+	if err := c.beforeUpdate(ctx, filter, update); err != nil {
+		return nil, err
+	}
+
+	f, err := parseFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	u, err := parseUpdate(update)
+	if err != nil {
+		return nil, err
+	}
+	o := mergeFindOneAndUpdateOptions(opts)
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var data []byte
+	res := &UpdateResult{}
+	err = c.write(func(tx *bolt.Tx) error {
+		w, err := c.writeTx(ctx, tx)
+		if err != nil {
+			return err
+		}
+
+		// Find the first matching document.
+		var m *match
+		err = scanMatches(ctx, w.b, c.visible(f), func(k, v []byte, doc map[string]interface{}) (bool, error) {
+			m = &match{key: append([]byte(nil), k...), data: append([]byte(nil), v...), doc: doc}
+			return false, nil
+		})
+		if err != nil || m == nil {
+			return err
+		}
+		res.MatchedCount = 1
+
+		// Apply the update.
+		bdoc, modified, err := updateMatch(w, *m, u)
+		if err != nil {
+			return err
+		}
+		if modified {
+			res.UpdateCount = 1
+		}
+
+		// Return the requested version of the document.
+		data = m.data
+		if o.ReturnDocument == After {
+			data = bdoc
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	c.afterUpdate(ctx, res)
+
+	if data == nil {
+		return &SingleResult{err: ErrNoDocuments}, nil
+	}
+	return &SingleResult{data: data}, nil
+}
+
+// FindOneAndDelete deletes the first document that matches the filter
+// and returns it. The filter follows the same rules as Find and the
+// optional FindOptions select which document is deleted and which
+// of its fields are returned; Limit is ignored.
+//
+// If no document matches, the returned SingleResult's Decode method
+// will return ErrNoDocuments.
+func (c *Collection) FindOneAndDelete(ctx context.Context, filter interface{}, opts ...FindOptions) (_ *SingleResult, err error) {
+	ctx, end := c.startOperation(ctx, "findOneAndDelete", filter, nil)
+	defer func() { end(err) }()
+	if err := c.beforeDelete(ctx, filter); err != nil {
+		return nil, err
+	}
+
+	f, err := parseFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	o := mergeFindOptions(opts)
+	o.Limit = 1
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var data []byte
+	err = c.write(func(tx *bolt.Tx) error {
+		w, err := c.writeTx(ctx, tx)
+		if err != nil {
+			return err
+		}
+
+		// Find the document, then delete it.
+		matches, _, err := findMatches(ctx, w.b, w.indexes, c.visible(f), o, false)
+		if err != nil || len(matches) == 0 {
+			return err
+		}
+		m := matches[0]
+		data = m.data
+		if c.softDelete == "" {
+			return w.delete(m.key)
+		}
+
+		// Soft delete is enabled, so mark it as deleted instead.
+		return w.markDeleted(m.key, m.doc)
+	})
+	if err != nil {
+		return nil, err
+	}
+	res := &DeleteResult{}
+	if data != nil {
+		res.DeleteCount = 1
+	}
+	c.afterDelete(ctx, res)
+
+	if data == nil {
+		return &SingleResult{err: ErrNoDocuments}, nil
+	}
+	return &SingleResult{data: data}, nil
+}
+
+// DeleteOne deletes the first document that matches the filter.
+// The filter follows the same rules as Find. Alternatively, filter
+// can be a bare _id value (e.g. a primitive.ObjectID) to delete a
+// document by its _id.
+//
+// If no document matches, DeleteCount will be 0.
+func (c *Collection) DeleteOne(ctx context.Context, filter interface{}) (_ *DeleteResult, err error) {
+	ctx, end := c.startOperation(ctx, "deleteOne", filter, nil)
+	defer func() { end(err) }()
+	return c.delete(ctx, filter, false, false)
+}
+
+// delete deletes the first document that matches the filter or, if
+// many is true, every document that matches. The documents are
+// soft-deleted if soft delete is enabled, unless hard is true.
+func (c *Collection) delete(ctx context.Context, filter interface{}, many, hard bool) (*DeleteResult, error) {
+	if err := c.beforeDelete(ctx, filter); err != nil {
+		return nil, err
+	}
+
+	var res *DeleteResult
+	var err error
+	if c.softDelete != "" && !hard {
+		res, err = c.markDeleted(ctx, filter, many)
+	} else {
+		res, err = c.remove(ctx, filter, many)
+	}
+	if err != nil {
+		return nil, err
+	}
+	c.afterDelete(ctx, res)
+	return res, nil
+}
+
+// remove removes the first document that matches the filter or, if
+// many is true, every document that matches.
+func (c *Collection) remove(ctx context.Context, filter interface{}, many bool) (*DeleteResult, error) {
+	// Is the filter a bare _id?
+	if isIDFilter(filter) {
+		if _, _, err := bson.MarshalValue(filter); err != nil {
+			return nil, err
+		}
+
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		res := &DeleteResult{}
+		err := c.write(func(tx *bolt.Tx) error {
+			w, err := c.writeTx(ctx, tx)
+			if err != nil {
+				return err
+			}
+			bid, err := w.key(filter)
+			if err != nil {
+				return err
+			}
+			if w.b.Get(bid) == nil {
+				return nil
+			}
+			res.DeleteCount = 1
+			return w.delete(bid)
+		})
+		if err != nil {
+			return nil, err
+		}
+		return res, nil
+	}
+
+	f, err := parseFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	res := &DeleteResult{}
+	err = c.write(func(tx *bolt.Tx) error {
+		w, err := c.writeTx(ctx, tx)
+		if err != nil {
+			return err
+		}
+
+		// Collect the keys of the matching documents. The bucket
+		// can't be modified during the scan.
+		var keys [][]byte
+		err = scanMatches(ctx, w.b, f, func(k, v []byte, doc map[string]interface{}) (bool, error) {
+			keys = append(keys, append([]byte(nil), k...))
+			return many, nil
+		})
+		if err != nil {
+			return err
+		}
+
+		// Delete them.
+		for _, k := range keys {
+			if err := w.delete(k); err != nil {
+				return err
+			}
+			res.DeleteCount++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// DeleteMany deletes every document that matches the filter. The
+// filter follows the same rules as DeleteOne. A nil or empty filter
+// deletes every document in the collection.
+//
+// All documents are deleted in a single transaction.
+func (c *Collection) DeleteMany(ctx context.Context, filter interface{}) (_ *DeleteResult, err error) {
+	ctx, end := c.startOperation(ctx, "deleteMany", filter, nil)
+	defer func() { end(err) }()
+	return c.delete(ctx, filter, true, false)
+}
@@ -0,0 +1,618 @@
+package mingodb_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/korrbit/mingodb"
+	"github.com/korrbit/mingodb/testutil"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestInsertMany(t *testing.T) {
+	ctx := context.Background()
+	db := testutil.NewTestDB(t)
+	c := db.CollectionMust("users")
+
+	type user struct {
+		Name string
+	}
+	ids, err := c.InsertMany(ctx, []interface{}{
+		user{Name: "Alice"},
+		map[string]interface{}{"_id": "bob", "Name": "Bob"},
+	})
+	if err != nil {
+		t.Fatalf("InsertMany: %v", err)
+	}
+	if len(ids) != 2 {
+		t.Fatalf("got %d ids, expected 2", len(ids))
+	}
+	if _, ok := ids[0].(primitive.ObjectID); !ok {
+		t.Errorf("generated _id is a %T, expected a primitive.ObjectID", ids[0])
+	}
+	if ids[1] != "bob" {
+		t.Errorf("got _id %v, expected bob", ids[1])
+	}
+	testutil.AssertDocumentCount(t, c, nil, 2)
+	testutil.AssertDocumentExists(t, c, map[string]interface{}{"_id": ids[0], "Name": "Alice"})
+}
+
+func TestInsertManyIsAtomic(t *testing.T) {
+	ctx := context.Background()
+	db := testutil.NewTestDB(t)
+	c := db.CollectionMust("users")
+
+	_, err := c.InsertMany(ctx, []interface{}{
+		map[string]interface{}{"_id": 1},
+		map[string]interface{}{"_id": 1},
+	})
+	if !errors.Is(err, mingodb.ErrDuplicateKey) {
+		t.Fatalf("InsertMany returned %v, expected ErrDuplicateKey", err)
+	}
+	testutil.AssertDocumentCount(t, c, nil, 0)
+}
+
+// people returns a collection holding three people.
+func people(t *testing.T) *mingodb.Collection {
+	t.Helper()
+	c := testutil.NewTestDB(t).CollectionMust("people")
+	testutil.SeedCollection(t, c,
+		map[string]interface{}{"_id": 1, "name": "Alice", "age": 30, "city": "Paris"},
+		map[string]interface{}{"_id": 2, "name": "Bob", "age": 25, "city": "London"},
+		map[string]interface{}{"_id": 3, "name": "Carol", "age": 35, "city": "Paris"},
+	)
+	return c
+}
+
+// resultIDs returns the _ids of the documents in res, in order.
+func resultIDs(t *testing.T, res *mingodb.MultiResult) []int32 {
+	t.Helper()
+	ids := []int32{}
+	for res.Next() {
+		var doc struct {
+			ID int32 `bson:"_id"`
+		}
+		if err := res.Decode(&doc); err != nil {
+			t.Fatalf("Decode: %v", err)
+		}
+		ids = append(ids, doc.ID)
+	}
+	return ids
+}
+
+// findIDs returns the _ids of the documents that Find returns.
+func findIDs(t *testing.T, c *mingodb.Collection, filter interface{}, opts ...mingodb.FindOptions) []int32 {
+	t.Helper()
+	res, err := c.Find(context.Background(), filter, opts...)
+	if err != nil {
+		t.Fatalf("Find: %v", err)
+	}
+	return resultIDs(t, res)
+}
+
+func TestFind(t *testing.T) {
+	c := people(t)
+	tests := []struct {
+		name     string
+		filter   interface{}
+		expected []int32
+	}{
+		{"nil", nil, []int32{1, 2, 3}},
+		{"one field", map[string]interface{}{"city": "Paris"}, []int32{1, 3}},
+		{"every field must match", map[string]interface{}{"city": "Paris", "age": 35}, []int32{3}},
+		{"no match", map[string]interface{}{"city": "Rome"}, []int32{}},
+		{"missing field", map[string]interface{}{"country": "France"}, []int32{}},
+		{"struct", struct {
+			City string `bson:"city"`
+		}{"London"}, []int32{2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := findIDs(t, c, tt.filter); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("got %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFindOne(t *testing.T) {
+	ctx := context.Background()
+	c := people(t)
+
+	res, err := c.FindOne(ctx, map[string]interface{}{"city": "Paris"})
+	if err != nil {
+		t.Fatalf("FindOne: %v", err)
+	}
+	var doc struct {
+		ID   int32  `bson:"_id"`
+		Name string `bson:"name"`
+	}
+	if err := res.Decode(&doc); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if doc.ID != 1 || doc.Name != "Alice" {
+		t.Errorf("got %+v, expected Alice", doc)
+	}
+
+	res, err = c.FindOne(ctx, map[string]interface{}{"city": "Rome"})
+	if err != nil {
+		t.Fatalf("FindOne: %v", err)
+	}
+	if res == nil {
+		t.Fatal("FindOne returned a nil result")
+	}
+	if err := res.Decode(&doc); !errors.Is(err, mingodb.ErrNoDocuments) {
+		t.Errorf("Decode returned %v, expected ErrNoDocuments", err)
+	}
+}
+
+func TestMultiResultCursor(t *testing.T) {
+	c := people(t)
+	res, err := c.Find(context.Background(), map[string]interface{}{"city": "Paris"})
+	if err != nil {
+		t.Fatalf("Find: %v", err)
+	}
+	defer res.Close()
+
+	var doc map[string]interface{}
+	if err := res.Decode(&doc); !errors.Is(err, mingodb.ErrNoCurrentDocument) {
+		t.Errorf("Decode before Next returned %v, expected ErrNoCurrentDocument", err)
+	}
+	var names []string
+	for res.Next() {
+		if err := res.Decode(&doc); err != nil {
+			t.Fatalf("Decode: %v", err)
+		}
+		names = append(names, doc["name"].(string))
+	}
+	if !reflect.DeepEqual(names, []string{"Alice", "Carol"}) {
+		t.Errorf("got %v, expected [Alice Carol]", names)
+	}
+	if res.Next() {
+		t.Error("Next returned true after the last document")
+	}
+	if res.ResultCount != 2 {
+		t.Errorf("ResultCount is %d, expected 2", res.ResultCount)
+	}
+}
+
+func TestCountDocuments(t *testing.T) {
+	c := people(t)
+	testutil.AssertDocumentCount(t, c, nil, 3)
+	testutil.AssertDocumentCount(t, c, map[string]interface{}{}, 3)
+	testutil.AssertDocumentCount(t, c, map[string]interface{}{"city": "Paris"}, 2)
+	testutil.AssertDocumentCount(t, c, map[string]interface{}{"city": "Rome"}, 0)
+}
+
+func TestUpdateOne(t *testing.T) {
+	ctx := context.Background()
+	c := people(t)
+
+	res, err := c.UpdateOne(ctx, map[string]interface{}{"city": "Paris"}, map[string]interface{}{
+		"$set": map[string]interface{}{"city": "Lyon", "country": "France"},
+	})
+	if err != nil {
+		t.Fatalf("UpdateOne: %v", err)
+	}
+	if res.MatchedCount != 1 || res.UpdateCount != 1 {
+		t.Errorf("got %+v, expected one document matched and updated", res)
+	}
+	testutil.AssertDocumentExists(t, c, map[string]interface{}{"_id": 1, "name": "Alice", "city": "Lyon", "country": "France"})
+	testutil.AssertDocumentExists(t, c, map[string]interface{}{"_id": 3, "city": "Paris"})
+
+	res, err = c.UpdateOne(ctx, map[string]interface{}{"city": "Rome"}, map[string]interface{}{
+		"$set": map[string]interface{}{"city": "Milan"},
+	})
+	if err != nil {
+		t.Fatalf("UpdateOne: %v", err)
+	}
+	if res.MatchedCount != 0 || res.UpdateCount != 0 {
+		t.Errorf("got %+v, expected no documents matched", res)
+	}
+}
+
+func TestUpdateOneInvalidUpdate(t *testing.T) {
+	c := people(t)
+	_, err := c.UpdateOne(context.Background(), map[string]interface{}{"_id": 1}, map[string]interface{}{"city": "Lyon"})
+	if !errors.Is(err, mingodb.ErrInvalidUpdate) {
+		t.Errorf("UpdateOne returned %v, expected ErrInvalidUpdate", err)
+	}
+}
+
+func TestUpdateMany(t *testing.T) {
+	ctx := context.Background()
+	c := people(t)
+
+	// Alice is already 30, so only Carol is modified.
+	res, err := c.UpdateMany(ctx, map[string]interface{}{"city": "Paris"}, map[string]interface{}{
+		"$set": map[string]interface{}{"age": 30},
+	})
+	if err != nil {
+		t.Fatalf("UpdateMany: %v", err)
+	}
+	if res.MatchedCount != 2 || res.UpdateCount != 1 {
+		t.Errorf("got %+v, expected 2 documents matched and 1 updated", res)
+	}
+	testutil.AssertDocumentCount(t, c, map[string]interface{}{"age": 30}, 2)
+}
+
+func TestDeleteOne(t *testing.T) {
+	ctx := context.Background()
+	c := people(t)
+
+	res, err := c.DeleteOne(ctx, map[string]interface{}{"city": "Paris"})
+	if err != nil {
+		t.Fatalf("DeleteOne: %v", err)
+	}
+	if res.DeleteCount != 1 {
+		t.Errorf("deleted %d documents, expected 1", res.DeleteCount)
+	}
+	if got := findIDs(t, c, nil); !reflect.DeepEqual(got, []int32{2, 3}) {
+		t.Errorf("left %v, expected [2 3]", got)
+	}
+
+	// A bare _id.
+	if res, err = c.DeleteOne(ctx, 2); err != nil {
+		t.Fatalf("DeleteOne: %v", err)
+	}
+	if res.DeleteCount != 1 {
+		t.Errorf("deleted %d documents, expected 1", res.DeleteCount)
+	}
+
+	if res, err = c.DeleteOne(ctx, map[string]interface{}{"city": "Rome"}); err != nil {
+		t.Fatalf("DeleteOne: %v", err)
+	}
+	if res.DeleteCount != 0 {
+		t.Errorf("deleted %d documents, expected 0", res.DeleteCount)
+	}
+	testutil.AssertDocumentCount(t, c, nil, 1)
+}
+
+func TestDeleteMany(t *testing.T) {
+	ctx := context.Background()
+	c := people(t)
+
+	res, err := c.DeleteMany(ctx, map[string]interface{}{"city": "Paris"})
+	if err != nil {
+		t.Fatalf("DeleteMany: %v", err)
+	}
+	if res.DeleteCount != 2 {
+		t.Errorf("deleted %d documents, expected 2", res.DeleteCount)
+	}
+	testutil.AssertDocumentCount(t, c, nil, 1)
+
+	if res, err = c.DeleteMany(ctx, nil); err != nil {
+		t.Fatalf("DeleteMany: %v", err)
+	}
+	if res.DeleteCount != 1 {
+		t.Errorf("deleted %d documents, expected 1", res.DeleteCount)
+	}
+	testutil.AssertDocumentCount(t, c, nil, 0)
+}
+
+func TestCancelledContext(t *testing.T) {
+	c := people(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := c.InsertOne(ctx, map[string]interface{}{"_id": 4}); !errors.Is(err, context.Canceled) {
+		t.Errorf("InsertOne returned %v, expected context.Canceled", err)
+	}
+	if _, err := c.Find(ctx, nil); !errors.Is(err, context.Canceled) {
+		t.Errorf("Find returned %v, expected context.Canceled", err)
+	}
+	if _, err := c.CountDocuments(ctx, map[string]interface{}{"city": "Paris"}); !errors.Is(err, context.Canceled) {
+		t.Errorf("CountDocuments returned %v, expected context.Canceled", err)
+	}
+	if _, err := c.DeleteMany(ctx, nil); !errors.Is(err, context.Canceled) {
+		t.Errorf("DeleteMany returned %v, expected context.Canceled", err)
+	}
+	testutil.AssertDocumentCount(t, c, nil, 3)
+}
+
+func TestReplaceOne(t *testing.T) {
+	ctx := context.Background()
+	c := people(t)
+
+	res, err := c.ReplaceOne(ctx, map[string]interface{}{"name": "Bob"}, map[string]interface{}{"name": "Robert"})
+	if err != nil {
+		t.Fatalf("ReplaceOne: %v", err)
+	}
+	if res.MatchedCount != 1 || res.UpdateCount != 1 {
+		t.Errorf("got %+v, expected one document matched and updated", res)
+	}
+	var doc map[string]interface{}
+	if err := c.GetByIDInto(ctx, 2, &doc); err != nil {
+		t.Fatalf("GetByIDInto: %v", err)
+	}
+	expected := map[string]interface{}{"_id": int32(2), "name": "Robert", mingodb.VersionField: int64(2)}
+	if !reflect.DeepEqual(doc, expected) {
+		t.Errorf("got %v, expected %v", doc, expected)
+	}
+
+	_, err = c.ReplaceOne(ctx, map[string]interface{}{"_id": 1}, map[string]interface{}{"_id": 5, "name": "Alice"})
+	if !errors.Is(err, mingodb.ErrInvalidDocument) {
+		t.Errorf("ReplaceOne returned %v, expected ErrInvalidDocument", err)
+	}
+	testutil.AssertDocumentExists(t, c, map[string]interface{}{"_id": 1, "city": "Paris"})
+}
+
+func TestFindOneAndUpdate(t *testing.T) {
+	ctx := context.Background()
+	set := map[string]interface{}{"$set": map[string]interface{}{"age": 31}}
+	tests := []struct {
+		name     string
+		opts     []mingodb.FindOneAndUpdateOptions
+		expected int32
+	}{
+		{"default", nil, 30},
+		{"before", []mingodb.FindOneAndUpdateOptions{{ReturnDocument: mingodb.Before}}, 30},
+		{"after", []mingodb.FindOneAndUpdateOptions{{ReturnDocument: mingodb.After}}, 31},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := people(t)
+			res, err := c.FindOneAndUpdate(ctx, map[string]interface{}{"_id": 1}, set, tt.opts...)
+			if err != nil {
+				t.Fatalf("FindOneAndUpdate: %v", err)
+			}
+			var doc struct {
+				Age int32 `bson:"age"`
+			}
+			if err := res.Decode(&doc); err != nil {
+				t.Fatalf("Decode: %v", err)
+			}
+			if doc.Age != tt.expected {
+				t.Errorf("returned age %d, expected %d", doc.Age, tt.expected)
+			}
+			testutil.AssertDocumentExists(t, c, map[string]interface{}{"_id": 1, "age": 31})
+		})
+	}
+
+	c := people(t)
+	res, err := c.FindOneAndUpdate(ctx, map[string]interface{}{"city": "Rome"}, set)
+	if err != nil {
+		t.Fatalf("FindOneAndUpdate: %v", err)
+	}
+	if err := res.Decode(&map[string]interface{}{}); !errors.Is(err, mingodb.ErrNoDocuments) {
+		t.Errorf("Decode returned %v, expected ErrNoDocuments", err)
+	}
+}
+
+func TestFindOneAndDelete(t *testing.T) {
+	ctx := context.Background()
+	c := people(t)
+
+	// The oldest person in Paris.
+	res, err := c.FindOneAndDelete(ctx, map[string]interface{}{"city": "Paris"}, mingodb.FindOptions{
+		Sort: []mingodb.SortField{{Field: "age", Dir: -1}},
+	})
+	if err != nil {
+		t.Fatalf("FindOneAndDelete: %v", err)
+	}
+	var doc struct {
+		Name string `bson:"name"`
+	}
+	if err := res.Decode(&doc); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if doc.Name != "Carol" {
+		t.Errorf("deleted %s, expected Carol", doc.Name)
+	}
+	if got := findIDs(t, c, nil); !reflect.DeepEqual(got, []int32{1, 2}) {
+		t.Errorf("left %v, expected [1 2]", got)
+	}
+
+	if res, err = c.FindOneAndDelete(ctx, map[string]interface{}{"city": "Rome"}); err != nil {
+		t.Fatalf("FindOneAndDelete: %v", err)
+	}
+	if err := res.Decode(&doc); !errors.Is(err, mingodb.ErrNoDocuments) {
+		t.Errorf("Decode returned %v, expected ErrNoDocuments", err)
+	}
+}
+
+func TestUpsertOne(t *testing.T) {
+	ctx := context.Background()
+	c := people(t)
+	set := map[string]interface{}{"$set": map[string]interface{}{"age": 40}}
+
+	res, err := c.UpsertOne(ctx, map[string]interface{}{"name": "Alice"}, set)
+	if err != nil {
+		t.Fatalf("UpsertOne: %v", err)
+	}
+	if res.Upserted || res.MatchedCount != 1 || res.UpdateCount != 1 {
+		t.Errorf("got %+v, expected Alice to be updated", res)
+	}
+	testutil.AssertDocumentExists(t, c, map[string]interface{}{"_id": 1, "age": 40})
+
+	if res, err = c.UpsertOne(ctx, map[string]interface{}{"name": "Dave", "city": "Rome"}, set); err != nil {
+		t.Fatalf("UpsertOne: %v", err)
+	}
+	if !res.Upserted || res.MatchedCount != 0 {
+		t.Errorf("got %+v, expected Dave to be inserted", res)
+	}
+	if _, ok := res.UpsertedID.(primitive.ObjectID); !ok {
+		t.Errorf("UpsertedID is a %T, expected a primitive.ObjectID", res.UpsertedID)
+	}
+	testutil.AssertDocumentExists(t, c, map[string]interface{}{"_id": res.UpsertedID, "name": "Dave", "city": "Rome", "age": 40})
+	testutil.AssertDocumentCount(t, c, nil, 4)
+}
+
+func TestDistinct(t *testing.T) {
+	ctx := context.Background()
+	c := testutil.NewTestDB(t).CollectionMust("items")
+	testutil.SeedCollection(t, c,
+		map[string]interface{}{"_id": 1, "tags": []interface{}{"a", "b"}, "address": map[string]interface{}{"city": "Paris"}},
+		map[string]interface{}{"_id": 2, "tags": "b", "address": map[string]interface{}{"city": "Lyon"}},
+		map[string]interface{}{"_id": 3, "tags": []interface{}{"c"}, "address": map[string]interface{}{"city": "Paris"}},
+	)
+	tests := []struct {
+		name     string
+		field    string
+		filter   interface{}
+		expected []interface{}
+	}{
+		{"arrays", "tags", nil, []interface{}{"a", "b", "c"}},
+		{"nested field", "address.city", nil, []interface{}{"Paris", "Lyon"}},
+		{"filter", "tags", map[string]interface{}{"address.city": "Paris"}, []interface{}{"a", "b", "c"}},
+		{"missing field", "name", nil, []interface{}{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := c.Distinct(ctx, tt.field, tt.filter)
+			if err != nil {
+				t.Fatalf("Distinct: %v", err)
+			}
+			if len(values) != len(tt.expected) || (len(values) > 0 && !reflect.DeepEqual(values, tt.expected)) {
+				t.Errorf("got %v, expected %v", values, tt.expected)
+			}
+		})
+	}
+}
+
+func TestGetByIDs(t *testing.T) {
+	c := people(t)
+	docs, err := c.GetByIDs(context.Background(), []interface{}{3, 4, 1})
+	if err != nil {
+		t.Fatalf("GetByIDs: %v", err)
+	}
+	if len(docs) != 3 {
+		t.Fatalf("got %d documents, expected 3", len(docs))
+	}
+	if docs[1] != nil {
+		t.Errorf("got %v for a missing _id, expected nil", docs[1])
+	}
+	for i, name := range map[int]string{0: "Carol", 2: "Alice"} {
+		doc, ok := docs[i].(map[string]interface{})
+		if !ok || doc["name"] != name {
+			t.Errorf("document %d is %v, expected %s", i, docs[i], name)
+		}
+	}
+}
+
+func TestGetByIDInto(t *testing.T) {
+	ctx := context.Background()
+	c := people(t)
+
+	type person struct {
+		ID   int32  `bson:"_id"`
+		Name string `bson:"name"`
+		Age  int    `bson:"age"`
+	}
+	var p person
+	if err := c.GetByIDInto(ctx, 2, &p); err != nil {
+		t.Fatalf("GetByIDInto: %v", err)
+	}
+	if expected := (person{ID: 2, Name: "Bob", Age: 25}); p != expected {
+		t.Errorf("got %+v, expected %+v", p, expected)
+	}
+	if err := c.GetByIDInto(ctx, 4, &p); !errors.Is(err, mingodb.ErrNoDocuments) {
+		t.Errorf("GetByIDInto returned %v, expected ErrNoDocuments", err)
+	}
+}
+
+func TestOpenMemory(t *testing.T) {
+	db, err := mingodb.OpenMemory()
+	if err != nil {
+		t.Fatalf("OpenMemory: %v", err)
+	}
+	if !db.IsMemory() {
+		t.Error("IsMemory returned false for an in-memory database")
+	}
+	testutil.SeedCollection(t, db.CollectionMust("items"), map[string]interface{}{"_id": 1})
+	if err := db.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if _, err := os.Stat(db.Path); !os.IsNotExist(err) {
+		t.Errorf("database file still exists after Close: %v", err)
+	}
+
+	file, err := mingodb.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer file.Close()
+	if file.IsMemory() {
+		t.Error("IsMemory returned true for a database opened with Open")
+	}
+}
+
+func TestOpenReadOnly(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "test.db")
+	db, err := mingodb.Open(path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	testutil.SeedCollection(t, db.CollectionMust("items"), map[string]interface{}{"_id": 1})
+	if err := db.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	db, err = mingodb.OpenReadOnly(path)
+	if err != nil {
+		t.Fatalf("OpenReadOnly: %v", err)
+	}
+	defer db.Close()
+	if _, err := db.Collection("missing"); !errors.Is(err, mingodb.ErrCollectionNotFound) {
+		t.Errorf("Collection returned %v, expected ErrCollectionNotFound", err)
+	}
+	c := db.CollectionMust("items")
+	testutil.AssertDocumentCount(t, c, nil, 1)
+
+	writes := map[string]func() error{
+		"InsertOne": func() error {
+			_, err := c.InsertOne(ctx, map[string]interface{}{"_id": 2})
+			return err
+		},
+		"UpdateOne": func() error {
+			_, err := c.UpdateOne(ctx, map[string]interface{}{"_id": 1}, map[string]interface{}{"$set": map[string]interface{}{"a": 1}})
+			return err
+		},
+		"DeleteOne": func() error {
+			_, err := c.DeleteOne(ctx, 1)
+			return err
+		},
+		"Drop": c.Drop,
+	}
+	for name, write := range writes {
+		if err := write(); !errors.Is(err, mingodb.ErrReadOnly) {
+			t.Errorf("%s returned %v, expected ErrReadOnly", name, err)
+		}
+	}
+	testutil.AssertDocumentCount(t, c, nil, 1)
+}
+
+func TestOpenWithOptions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	db, err := mingodb.OpenWithOptions(path, &mingodb.OpenOptions{
+		FileMode:        0600,
+		InitialMmapSize: 1 << 20,
+		NoSync:          true,
+		NoFreelistSync:  true,
+	})
+	if err != nil {
+		t.Fatalf("OpenWithOptions: %v", err)
+	}
+	defer db.Close()
+	testutil.SeedCollection(t, db.CollectionMust("items"), map[string]interface{}{"_id": 1})
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Stat: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("database file has mode %v, expected 0600", info.Mode().Perm())
+	}
+
+	// The file is locked by db.
+	_, err = mingodb.OpenWithOptions(path, &mingodb.OpenOptions{Timeout: 10 * time.Millisecond})
+	if !errors.Is(err, mingodb.ErrOpeningDatabase) {
+		t.Errorf("OpenWithOptions returned %v, expected ErrOpeningDatabase", err)
+	}
+}
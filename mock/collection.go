@@ -0,0 +1,529 @@
+package mock
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/korrbit/mingodb"
+)
+
+// MockCollection is a mingodb.CollectionIface that records its calls
+// and returns configurable results, for tests of code that uses a
+// collection:
+//
+//	users := &mock.MockCollection{
+//		FindOneFunc: func(ctx context.Context, filter interface{}, opts ...mingodb.FindOptions) (*mingodb.SingleResult, error) {
+//			return mingodb.NewSingleResult(map[string]interface{}{"name": "Alice"})
+//		},
+//	}
+//
+// Each method records its call, then calls the Func field of the same
+// name if it's set. Otherwise it returns zero results: empty results
+// for the methods that return a result, such as a MultiResult with no
+// documents or an UpdateResult with no counts, a SingleResult whose
+// Decode returns mingodb.ErrNoDocuments, and a nil error.
+//
+// A MockCollection can be used by multiple goroutines, as long as its
+// Func fields aren't changed while it's in use.
+type MockCollection struct {
+	// The Func fields are called by the methods of the same name.
+	NameFunc                func() string
+	DatabaseFunc            func() *mingodb.Database
+	DropFunc                func() error
+	StatsFunc               func(ctx context.Context) (*mingodb.CollectionStats, error)
+	UseFunc                 func(mw ...mingodb.Middleware)
+	SetValidatorFunc        func(fn func(doc map[string]interface{}) error)
+	SetJSONSchemaFunc       func(schema []byte) error
+	SetCapFunc              func(maxDocuments int) error
+	EnableSoftDeleteFunc    func(deletedAtField string)
+	EnableVersioningFunc    func()
+	FindFunc                func(ctx context.Context, filter interface{}, opts ...mingodb.FindOptions) (*mingodb.MultiResult, error)
+	FindOneFunc             func(ctx context.Context, filter interface{}, opts ...mingodb.FindOptions) (*mingodb.SingleResult, error)
+	FindWithDeletedFunc     func(ctx context.Context, filter interface{}, opts ...mingodb.FindOptions) (*mingodb.MultiResult, error)
+	GetByIDFunc             func(ctx context.Context, id interface{}) (interface{}, error)
+	GetByIDIntoFunc         func(ctx context.Context, id interface{}, result interface{}) error
+	GetByIDsFunc            func(ctx context.Context, ids []interface{}) ([]interface{}, error)
+	CountDocumentsFunc      func(ctx context.Context, filter interface{}) (int, error)
+	DistinctFunc            func(ctx context.Context, field string, filter interface{}) ([]interface{}, error)
+	AggregateFunc           func(ctx context.Context, pipeline mingodb.Pipeline) (*mingodb.MultiResult, error)
+	MapReduceFunc           func(ctx context.Context, mapFn func(doc map[string]interface{}) []mingodb.KeyValue, reduceFn func(key interface{}, values []interface{}) interface{}, out string) error
+	ExplainFunc             func(ctx context.Context, filter interface{}, opts ...mingodb.FindOptions) (mingodb.QueryPlan, error)
+	QueryFunc               func() *mingodb.Query
+	WatchFunc               func(ctx context.Context, pipeline []interface{}) (<-chan mingodb.ChangeEvent, error)
+	GetHistoryFunc          func(ctx context.Context, id interface{}, opts ...mingodb.HistoryOptions) (*mingodb.MultiResult, error)
+	InsertOneFunc           func(ctx context.Context, doc interface{}) (mingodb.InsertID, error)
+	InsertManyFunc          func(ctx context.Context, docs []interface{}) ([]mingodb.InsertID, error)
+	UpdateOneFunc           func(ctx context.Context, filter interface{}, update interface{}) (*mingodb.UpdateResult, error)
+	UpdateManyFunc          func(ctx context.Context, filter interface{}, update interface{}) (*mingodb.UpdateResult, error)
+	UpsertOneFunc           func(ctx context.Context, filter interface{}, update interface{}) (*mingodb.UpsertResult, error)
+	ReplaceOneFunc          func(ctx context.Context, filter interface{}, replacement interface{}) (*mingodb.UpdateResult, error)
+	OptimisticUpdateFunc    func(ctx context.Context, filter interface{}, update interface{}, version int64) (*mingodb.UpdateResult, error)
+	FindOneAndUpdateFunc    func(ctx context.Context, filter interface{}, update interface{}, opts ...mingodb.FindOneAndUpdateOptions) (*mingodb.SingleResult, error)
+	FindOneAndDeleteFunc    func(ctx context.Context, filter interface{}, opts ...mingodb.FindOptions) (*mingodb.SingleResult, error)
+	DeleteOneFunc           func(ctx context.Context, filter interface{}) (*mingodb.DeleteResult, error)
+	DeleteManyFunc          func(ctx context.Context, filter interface{}) (*mingodb.DeleteResult, error)
+	HardDeleteFunc          func(ctx context.Context, filter interface{}) (*mingodb.DeleteResult, error)
+	BulkWriteFunc           func(ctx context.Context, ops []mingodb.WriteOperation, opts ...mingodb.BulkWriteOptions) (*mingodb.BulkWriteResult, error)
+	RollbackFunc            func(ctx context.Context, id interface{}, version int) error
+	CreateIndexFunc         func(ctx context.Context, key string, opts ...mingodb.IndexOptions) (string, error)
+	CreateCompoundIndexFunc func(ctx context.Context, keys []mingodb.IndexKey, opts ...mingodb.IndexOptions) (string, error)
+	CreateTextIndexFunc     func(ctx context.Context, fields []string, opts ...mingodb.TextIndexOptions) (string, error)
+	CreateTTLIndexFunc      func(ctx context.Context, field string, expiry time.Duration) (string, error)
+	ListIndexesFunc         func(ctx context.Context) ([]mingodb.IndexInfo, error)
+	ExportFunc              func(ctx context.Context, w io.Writer, opts ...mingodb.ExportOptions) (int, error)
+	ImportFunc              func(ctx context.Context, r io.Reader, opts ...mingodb.ImportOptions) (int, error)
+	ExportCSVFunc           func(ctx context.Context, w io.Writer, fields []string, opts ...mingodb.FindOptions) error
+	ImportCSVFunc           func(ctx context.Context, r io.Reader, opts ...mingodb.CSVImportOptions) (int, error)
+
+	mu    sync.Mutex
+	calls []Call
+}
+
+var _ mingodb.CollectionIface = (*MockCollection)(nil)
+
+// Calls returns the calls made to the mock, in order.
+func (m *MockCollection) Calls() []Call {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Call(nil), m.calls...)
+}
+
+// CallCount returns the number of calls made to the method.
+func (m *MockCollection) CallCount(method string) int {
+	return countCalls(m.Calls(), method)
+}
+
+// Reset forgets the calls made to the mock.
+func (m *MockCollection) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = nil
+}
+
+// record records a call to the mock.
+func (m *MockCollection) record(method string, args ...interface{}) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = append(m.calls, Call{Method: method, Args: args})
+}
+
+// Name records the call and calls NameFunc, if it's set.
+func (m *MockCollection) Name() string {
+	m.record("Name")
+	if m.NameFunc != nil {
+		return m.NameFunc()
+	}
+	return ""
+}
+
+// Database records the call and calls DatabaseFunc, if it's set.
+func (m *MockCollection) Database() *mingodb.Database {
+	m.record("Database")
+	if m.DatabaseFunc != nil {
+		return m.DatabaseFunc()
+	}
+	return nil
+}
+
+// Drop records the call and calls DropFunc, if it's set.
+func (m *MockCollection) Drop() error {
+	m.record("Drop")
+	if m.DropFunc != nil {
+		return m.DropFunc()
+	}
+	return nil
+}
+
+// Stats records the call and calls StatsFunc, if it's set.
+func (m *MockCollection) Stats(ctx context.Context) (*mingodb.CollectionStats, error) {
+	m.record("Stats", ctx)
+	if m.StatsFunc != nil {
+		return m.StatsFunc(ctx)
+	}
+	return &mingodb.CollectionStats{}, nil
+}
+
+// Use records the call and calls UseFunc, if it's set.
+func (m *MockCollection) Use(mw ...mingodb.Middleware) {
+	m.record("Use", mw)
+	if m.UseFunc != nil {
+		m.UseFunc(mw...)
+	}
+}
+
+// SetValidator records the call and calls SetValidatorFunc, if it's set.
+func (m *MockCollection) SetValidator(fn func(doc map[string]interface{}) error) {
+	m.record("SetValidator", fn)
+	if m.SetValidatorFunc != nil {
+		m.SetValidatorFunc(fn)
+	}
+}
+
+// SetJSONSchema records the call and calls SetJSONSchemaFunc, if it's set.
+func (m *MockCollection) SetJSONSchema(schema []byte) error {
+	m.record("SetJSONSchema", schema)
+	if m.SetJSONSchemaFunc != nil {
+		return m.SetJSONSchemaFunc(schema)
+	}
+	return nil
+}
+
+// SetCap records the call and calls SetCapFunc, if it's set.
+func (m *MockCollection) SetCap(maxDocuments int) error {
+	m.record("SetCap", maxDocuments)
+	if m.SetCapFunc != nil {
+		return m.SetCapFunc(maxDocuments)
+	}
+	return nil
+}
+
+// EnableSoftDelete records the call and calls EnableSoftDeleteFunc, if it's set.
+func (m *MockCollection) EnableSoftDelete(deletedAtField string) {
+	m.record("EnableSoftDelete", deletedAtField)
+	if m.EnableSoftDeleteFunc != nil {
+		m.EnableSoftDeleteFunc(deletedAtField)
+	}
+}
+
+// EnableVersioning records the call and calls EnableVersioningFunc, if it's set.
+func (m *MockCollection) EnableVersioning() {
+	m.record("EnableVersioning")
+	if m.EnableVersioningFunc != nil {
+		m.EnableVersioningFunc()
+	}
+}
+
+// Find records the call and calls FindFunc, if it's set.
+func (m *MockCollection) Find(ctx context.Context, filter interface{}, opts ...mingodb.FindOptions) (*mingodb.MultiResult, error) {
+	m.record("Find", ctx, filter, opts)
+	if m.FindFunc != nil {
+		return m.FindFunc(ctx, filter, opts...)
+	}
+	return &mingodb.MultiResult{}, nil
+}
+
+// FindOne records the call and calls FindOneFunc, if it's set.
+func (m *MockCollection) FindOne(ctx context.Context, filter interface{}, opts ...mingodb.FindOptions) (*mingodb.SingleResult, error) {
+	m.record("FindOne", ctx, filter, opts)
+	if m.FindOneFunc != nil {
+		return m.FindOneFunc(ctx, filter, opts...)
+	}
+	return noDocuments(), nil
+}
+
+// FindWithDeleted records the call and calls FindWithDeletedFunc, if it's set.
+func (m *MockCollection) FindWithDeleted(ctx context.Context, filter interface{}, opts ...mingodb.FindOptions) (*mingodb.MultiResult, error) {
+	m.record("FindWithDeleted", ctx, filter, opts)
+	if m.FindWithDeletedFunc != nil {
+		return m.FindWithDeletedFunc(ctx, filter, opts...)
+	}
+	return &mingodb.MultiResult{}, nil
+}
+
+// GetByID records the call and calls GetByIDFunc, if it's set.
+func (m *MockCollection) GetByID(ctx context.Context, id interface{}) (interface{}, error) {
+	m.record("GetByID", ctx, id)
+	if m.GetByIDFunc != nil {
+		return m.GetByIDFunc(ctx, id)
+	}
+	return nil, nil
+}
+
+// GetByIDInto records the call and calls GetByIDIntoFunc, if it's set.
+func (m *MockCollection) GetByIDInto(ctx context.Context, id interface{}, result interface{}) error {
+	m.record("GetByIDInto", ctx, id, result)
+	if m.GetByIDIntoFunc != nil {
+		return m.GetByIDIntoFunc(ctx, id, result)
+	}
+	return nil
+}
+
+// GetByIDs records the call and calls GetByIDsFunc, if it's set.
+func (m *MockCollection) GetByIDs(ctx context.Context, ids []interface{}) ([]interface{}, error) {
+	m.record("GetByIDs", ctx, ids)
+	if m.GetByIDsFunc != nil {
+		return m.GetByIDsFunc(ctx, ids)
+	}
+	return nil, nil
+}
+
+// CountDocuments records the call and calls CountDocumentsFunc, if it's set.
+func (m *MockCollection) CountDocuments(ctx context.Context, filter interface{}) (int, error) {
+	m.record("CountDocuments", ctx, filter)
+	if m.CountDocumentsFunc != nil {
+		return m.CountDocumentsFunc(ctx, filter)
+	}
+	return 0, nil
+}
+
+// Distinct records the call and calls DistinctFunc, if it's set.
+func (m *MockCollection) Distinct(ctx context.Context, field string, filter interface{}) ([]interface{}, error) {
+	m.record("Distinct", ctx, field, filter)
+	if m.DistinctFunc != nil {
+		return m.DistinctFunc(ctx, field, filter)
+	}
+	return nil, nil
+}
+
+// Aggregate records the call and calls AggregateFunc, if it's set.
+func (m *MockCollection) Aggregate(ctx context.Context, pipeline mingodb.Pipeline) (*mingodb.MultiResult, error) {
+	m.record("Aggregate", ctx, pipeline)
+	if m.AggregateFunc != nil {
+		return m.AggregateFunc(ctx, pipeline)
+	}
+	return &mingodb.MultiResult{}, nil
+}
+
+// MapReduce records the call and calls MapReduceFunc, if it's set.
+func (m *MockCollection) MapReduce(ctx context.Context, mapFn func(doc map[string]interface{}) []mingodb.KeyValue, reduceFn func(key interface{}, values []interface{}) interface{}, out string) error {
+	m.record("MapReduce", ctx, mapFn, reduceFn, out)
+	if m.MapReduceFunc != nil {
+		return m.MapReduceFunc(ctx, mapFn, reduceFn, out)
+	}
+	return nil
+}
+
+// Explain records the call and calls ExplainFunc, if it's set.
+func (m *MockCollection) Explain(ctx context.Context, filter interface{}, opts ...mingodb.FindOptions) (mingodb.QueryPlan, error) {
+	m.record("Explain", ctx, filter, opts)
+	if m.ExplainFunc != nil {
+		return m.ExplainFunc(ctx, filter, opts...)
+	}
+	return mingodb.QueryPlan{}, nil
+}
+
+// Query records the call and calls QueryFunc, if it's set.
+func (m *MockCollection) Query() *mingodb.Query {
+	m.record("Query")
+	if m.QueryFunc != nil {
+		return m.QueryFunc()
+	}
+	return nil
+}
+
+// Watch records the call and calls WatchFunc, if it's set.
+func (m *MockCollection) Watch(ctx context.Context, pipeline []interface{}) (<-chan mingodb.ChangeEvent, error) {
+	m.record("Watch", ctx, pipeline)
+	if m.WatchFunc != nil {
+		return m.WatchFunc(ctx, pipeline)
+	}
+	return closedEvents(), nil
+}
+
+// GetHistory records the call and calls GetHistoryFunc, if it's set.
+func (m *MockCollection) GetHistory(ctx context.Context, id interface{}, opts ...mingodb.HistoryOptions) (*mingodb.MultiResult, error) {
+	m.record("GetHistory", ctx, id, opts)
+	if m.GetHistoryFunc != nil {
+		return m.GetHistoryFunc(ctx, id, opts...)
+	}
+	return &mingodb.MultiResult{}, nil
+}
+
+// InsertOne records the call and calls InsertOneFunc, if it's set.
+func (m *MockCollection) InsertOne(ctx context.Context, doc interface{}) (mingodb.InsertID, error) {
+	m.record("InsertOne", ctx, doc)
+	if m.InsertOneFunc != nil {
+		return m.InsertOneFunc(ctx, doc)
+	}
+	return nil, nil
+}
+
+// InsertMany records the call and calls InsertManyFunc, if it's set.
+func (m *MockCollection) InsertMany(ctx context.Context, docs []interface{}) ([]mingodb.InsertID, error) {
+	m.record("InsertMany", ctx, docs)
+	if m.InsertManyFunc != nil {
+		return m.InsertManyFunc(ctx, docs)
+	}
+	return nil, nil
+}
+
+// UpdateOne records the call and calls UpdateOneFunc, if it's set.
+func (m *MockCollection) UpdateOne(ctx context.Context, filter interface{}, update interface{}) (*mingodb.UpdateResult, error) {
+	m.record("UpdateOne", ctx, filter, update)
+	if m.UpdateOneFunc != nil {
+		return m.UpdateOneFunc(ctx, filter, update)
+	}
+	return &mingodb.UpdateResult{}, nil
+}
+
+// UpdateMany records the call and calls UpdateManyFunc, if it's set.
+func (m *MockCollection) UpdateMany(ctx context.Context, filter interface{}, update interface{}) (*mingodb.UpdateResult, error) {
+	m.record("UpdateMany", ctx, filter, update)
+	if m.UpdateManyFunc != nil {
+		return m.UpdateManyFunc(ctx, filter, update)
+	}
+	return &mingodb.UpdateResult{}, nil
+}
+
+// UpsertOne records the call and calls UpsertOneFunc, if it's set.
+func (m *MockCollection) UpsertOne(ctx context.Context, filter interface{}, update interface{}) (*mingodb.UpsertResult, error) {
+	m.record("UpsertOne", ctx, filter, update)
+	if m.UpsertOneFunc != nil {
+		return m.UpsertOneFunc(ctx, filter, update)
+	}
+	return &mingodb.UpsertResult{}, nil
+}
+
+// ReplaceOne records the call and calls ReplaceOneFunc, if it's set.
+func (m *MockCollection) ReplaceOne(ctx context.Context, filter interface{}, replacement interface{}) (*mingodb.UpdateResult, error) {
+	m.record("ReplaceOne", ctx, filter, replacement)
+	if m.ReplaceOneFunc != nil {
+		return m.ReplaceOneFunc(ctx, filter, replacement)
+	}
+	return &mingodb.UpdateResult{}, nil
+}
+
+// OptimisticUpdate records the call and calls OptimisticUpdateFunc, if it's set.
+func (m *MockCollection) OptimisticUpdate(ctx context.Context, filter interface{}, update interface{}, version int64) (*mingodb.UpdateResult, error) {
+	m.record("OptimisticUpdate", ctx, filter, update, version)
+	if m.OptimisticUpdateFunc != nil {
+		return m.OptimisticUpdateFunc(ctx, filter, update, version)
+	}
+	return &mingodb.UpdateResult{}, nil
+}
+
+// FindOneAndUpdate records the call and calls FindOneAndUpdateFunc, if it's set.
+func (m *MockCollection) FindOneAndUpdate(ctx context.Context, filter interface{}, update interface{}, opts ...mingodb.FindOneAndUpdateOptions) (*mingodb.SingleResult, error) {
+	m.record("FindOneAndUpdate", ctx, filter, update, opts)
+	if m.FindOneAndUpdateFunc != nil {
+		return m.FindOneAndUpdateFunc(ctx, filter, update, opts...)
+	}
+	return noDocuments(), nil
+}
+
+// FindOneAndDelete records the call and calls FindOneAndDeleteFunc, if it's set.
+func (m *MockCollection) FindOneAndDelete(ctx context.Context, filter interface{}, opts ...mingodb.FindOptions) (*mingodb.SingleResult, error) {
+	m.record("FindOneAndDelete", ctx, filter, opts)
+	if m.FindOneAndDeleteFunc != nil {
+		return m.FindOneAndDeleteFunc(ctx, filter, opts...)
+	}
+	return noDocuments(), nil
+}
+
+// DeleteOne records the call and calls DeleteOneFunc, if it's set.
+func (m *MockCollection) DeleteOne(ctx context.Context, filter interface{}) (*mingodb.DeleteResult, error) {
+	m.record("DeleteOne", ctx, filter)
+	if m.DeleteOneFunc != nil {
+		return m.DeleteOneFunc(ctx, filter)
+	}
+	return &mingodb.DeleteResult{}, nil
+}
+
+// DeleteMany records the call and calls DeleteManyFunc, if it's set.
+func (m *MockCollection) DeleteMany(ctx context.Context, filter interface{}) (*mingodb.DeleteResult, error) {
+	m.record("DeleteMany", ctx, filter)
+	if m.DeleteManyFunc != nil {
+		return m.DeleteManyFunc(ctx, filter)
+	}
+	return &mingodb.DeleteResult{}, nil
+}
+
+// HardDelete records the call and calls HardDeleteFunc, if it's set.
+func (m *MockCollection) HardDelete(ctx context.Context, filter interface{}) (*mingodb.DeleteResult, error) {
+	m.record("HardDelete", ctx, filter)
+	if m.HardDeleteFunc != nil {
+		return m.HardDeleteFunc(ctx, filter)
+	}
+	return &mingodb.DeleteResult{}, nil
+}
+
+// BulkWrite records the call and calls BulkWriteFunc, if it's set.
+func (m *MockCollection) BulkWrite(ctx context.Context, ops []mingodb.WriteOperation, opts ...mingodb.BulkWriteOptions) (*mingodb.BulkWriteResult, error) {
+	m.record("BulkWrite", ctx, ops, opts)
+	if m.BulkWriteFunc != nil {
+		return m.BulkWriteFunc(ctx, ops, opts...)
+	}
+	return &mingodb.BulkWriteResult{}, nil
+}
+
+// Rollback records the call and calls RollbackFunc, if it's set.
+func (m *MockCollection) Rollback(ctx context.Context, id interface{}, version int) error {
+	m.record("Rollback", ctx, id, version)
+	if m.RollbackFunc != nil {
+		return m.RollbackFunc(ctx, id, version)
+	}
+	return nil
+}
+
+// CreateIndex records the call and calls CreateIndexFunc, if it's set.
+func (m *MockCollection) CreateIndex(ctx context.Context, key string, opts ...mingodb.IndexOptions) (string, error) {
+	m.record("CreateIndex", ctx, key, opts)
+	if m.CreateIndexFunc != nil {
+		return m.CreateIndexFunc(ctx, key, opts...)
+	}
+	return "", nil
+}
+
+// CreateCompoundIndex records the call and calls CreateCompoundIndexFunc, if it's set.
+func (m *MockCollection) CreateCompoundIndex(ctx context.Context, keys []mingodb.IndexKey, opts ...mingodb.IndexOptions) (string, error) {
+	m.record("CreateCompoundIndex", ctx, keys, opts)
+	if m.CreateCompoundIndexFunc != nil {
+		return m.CreateCompoundIndexFunc(ctx, keys, opts...)
+	}
+	return "", nil
+}
+
+// CreateTextIndex records the call and calls CreateTextIndexFunc, if it's set.
+func (m *MockCollection) CreateTextIndex(ctx context.Context, fields []string, opts ...mingodb.TextIndexOptions) (string, error) {
+	m.record("CreateTextIndex", ctx, fields, opts)
+	if m.CreateTextIndexFunc != nil {
+		return m.CreateTextIndexFunc(ctx, fields, opts...)
+	}
+	return "", nil
+}
+
+// CreateTTLIndex records the call and calls CreateTTLIndexFunc, if it's set.
+func (m *MockCollection) CreateTTLIndex(ctx context.Context, field string, expiry time.Duration) (string, error) {
+	m.record("CreateTTLIndex", ctx, field, expiry)
+	if m.CreateTTLIndexFunc != nil {
+		return m.CreateTTLIndexFunc(ctx, field, expiry)
+	}
+	return "", nil
+}
+
+// ListIndexes records the call and calls ListIndexesFunc, if it's set.
+func (m *MockCollection) ListIndexes(ctx context.Context) ([]mingodb.IndexInfo, error) {
+	m.record("ListIndexes", ctx)
+	if m.ListIndexesFunc != nil {
+		return m.ListIndexesFunc(ctx)
+	}
+	return nil, nil
+}
+
+// Export records the call and calls ExportFunc, if it's set.
+func (m *MockCollection) Export(ctx context.Context, w io.Writer, opts ...mingodb.ExportOptions) (int, error) {
+	m.record("Export", ctx, w, opts)
+	if m.ExportFunc != nil {
+		return m.ExportFunc(ctx, w, opts...)
+	}
+	return 0, nil
+}
+
+// Import records the call and calls ImportFunc, if it's set.
+func (m *MockCollection) Import(ctx context.Context, r io.Reader, opts ...mingodb.ImportOptions) (int, error) {
+	m.record("Import", ctx, r, opts)
+	if m.ImportFunc != nil {
+		return m.ImportFunc(ctx, r, opts...)
+	}
+	return 0, nil
+}
+
+// ExportCSV records the call and calls ExportCSVFunc, if it's set.
+func (m *MockCollection) ExportCSV(ctx context.Context, w io.Writer, fields []string, opts ...mingodb.FindOptions) error {
+	m.record("ExportCSV", ctx, w, fields, opts)
+	if m.ExportCSVFunc != nil {
+		return m.ExportCSVFunc(ctx, w, fields, opts...)
+	}
+	return nil
+}
+
+// ImportCSV records the call and calls ImportCSVFunc, if it's set.
+func (m *MockCollection) ImportCSV(ctx context.Context, r io.Reader, opts ...mingodb.CSVImportOptions) (int, error) {
+	m.record("ImportCSV", ctx, r, opts)
+	if m.ImportCSVFunc != nil {
+		return m.ImportCSVFunc(ctx, r, opts...)
+	}
+	return 0, nil
+}
@@ -0,0 +1,98 @@
+package mock_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/korrbit/mingodb"
+	"github.com/korrbit/mingodb/mock"
+	"github.com/korrbit/mingodb/testutil"
+)
+
+// activeUsers is application code that depends on CollectionIface.
+func activeUsers(ctx context.Context, users mingodb.CollectionIface) (int, error) {
+	return users.CountDocuments(ctx, map[string]interface{}{"active": true})
+}
+
+func TestMockCollection(t *testing.T) {
+	ctx := context.Background()
+	errFailed := errors.New("failed")
+	m := &mock.MockCollection{
+		CountDocumentsFunc: func(ctx context.Context, filter interface{}) (int, error) {
+			return 3, nil
+		},
+		InsertOneFunc: func(ctx context.Context, doc interface{}) (mingodb.InsertID, error) {
+			return nil, errFailed
+		},
+	}
+
+	n, err := activeUsers(ctx, m)
+	if err != nil || n != 3 {
+		t.Errorf("activeUsers returned %d, %v, expected 3", n, err)
+	}
+	doc := map[string]interface{}{"name": "Alice"}
+	if _, err := m.InsertOne(ctx, doc); !errors.Is(err, errFailed) {
+		t.Errorf("InsertOne returned %v, expected InsertOneFunc's error", err)
+	}
+
+	expected := []mock.Call{
+		{Method: "CountDocuments", Args: []interface{}{ctx, map[string]interface{}{"active": true}}},
+		{Method: "InsertOne", Args: []interface{}{ctx, doc}},
+	}
+	if calls := m.Calls(); !reflect.DeepEqual(calls, expected) {
+		t.Errorf("got calls %+v, expected %+v", calls, expected)
+	}
+	if n := m.CallCount("InsertOne"); n != 1 {
+		t.Errorf("got %d calls to InsertOne, expected 1", n)
+	}
+	m.Reset()
+	if calls := m.Calls(); len(calls) != 0 {
+		t.Errorf("got calls %+v after Reset, expected none", calls)
+	}
+}
+
+func TestMockCollectionDefaults(t *testing.T) {
+	ctx := context.Background()
+	m := &mock.MockCollection{}
+
+	res, err := m.Find(ctx, nil)
+	if err != nil || res.Next() {
+		t.Errorf("Find returned %v, expected no documents", err)
+	}
+	one, err := m.FindOne(ctx, nil)
+	if err != nil {
+		t.Fatalf("FindOne: %v", err)
+	}
+	if err := one.Decode(&map[string]interface{}{}); !errors.Is(err, mingodb.ErrNoDocuments) {
+		t.Errorf("Decode returned %v, expected ErrNoDocuments", err)
+	}
+	update, err := m.UpdateMany(ctx, nil, nil)
+	if err != nil || *update != (mingodb.UpdateResult{}) {
+		t.Errorf("UpdateMany returned %+v, %v, expected an empty result", update, err)
+	}
+	events, err := m.Watch(ctx, nil)
+	if err != nil {
+		t.Fatalf("Watch: %v", err)
+	}
+	if _, ok := <-events; ok {
+		t.Error("Watch returned an open channel, expected a closed one")
+	}
+	if n := len(m.Calls()); n != 4 {
+		t.Errorf("got %d calls, expected 4", n)
+	}
+}
+
+func TestCollectionIface(t *testing.T) {
+	// A real collection can be used in place of the mock.
+	users := testutil.NewTestDB(t).CollectionMust("users")
+	testutil.SeedCollection(t, users,
+		map[string]interface{}{"active": true},
+		map[string]interface{}{"active": false},
+	)
+	n, err := activeUsers(context.Background(), users)
+	if err != nil || n != 1 {
+		t.Errorf("activeUsers returned %d, %v, expected 1", n, err)
+	}
+}
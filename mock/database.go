@@ -0,0 +1,136 @@
+package mock
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/korrbit/mingodb"
+)
+
+// errNoBeginTx is returned by MockDatabase.BeginTx if BeginTxFunc
+// isn't set, as a Transaction can only be started by a database.
+var errNoBeginTx = errors.New("mock: BeginTx called without a BeginTxFunc")
+
+// MockDatabase is a mingodb.DatabaseIface that records its calls and
+// returns configurable results, like MockCollection.
+//
+// Unless CollectionFunc is set, Collection returns a MockCollection
+// for each name, the same one each time it's passed the name (see
+// MockDatabase.MockCollection). Unless BeginTxFunc is set, BeginTx
+// returns an error, as a Transaction can only be started by a
+// database.
+type MockDatabase struct {
+	// The Func fields are called by the methods of the same name.
+	CollectionFunc      func(name string) (mingodb.CollectionIface, error)
+	ListCollectionsFunc func(ctx context.Context) ([]string, error)
+	BeginTxFunc         func(ctx context.Context) (*mingodb.Transaction, error)
+	StatsFunc           func() (*mingodb.DatabaseStats, error)
+	CloseFunc           func() error
+
+	mu          sync.Mutex
+	calls       []Call
+	collections map[string]*MockCollection
+}
+
+var _ mingodb.DatabaseIface = (*MockDatabase)(nil)
+
+// Calls returns the calls made to the mock, in order. Calls made to
+// its MockCollections aren't included.
+func (m *MockDatabase) Calls() []Call {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Call(nil), m.calls...)
+}
+
+// CallCount returns the number of calls made to the method.
+func (m *MockDatabase) CallCount(method string) int {
+	return countCalls(m.Calls(), method)
+}
+
+// Reset forgets the calls made to the mock.
+func (m *MockDatabase) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = nil
+}
+
+// record records a call to the mock.
+func (m *MockDatabase) record(method string, args ...interface{}) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = append(m.calls, Call{Method: method, Args: args})
+}
+
+// MockCollection returns the MockCollection that Collection returns
+// for the name, creating it if needed, so that a test can set its Func
+// fields or check its calls. Its Name returns the name.
+func (m *MockDatabase) MockCollection(name string) *MockCollection {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	c, ok := m.collections[name]
+	if !ok {
+		c = &MockCollection{NameFunc: func() string { return name }}
+		if m.collections == nil {
+			m.collections = make(map[string]*MockCollection)
+		}
+		m.collections[name] = c
+	}
+	return c
+}
+
+// Collection records the call and calls CollectionFunc, if it's set.
+// Otherwise it returns the name's MockCollection.
+func (m *MockDatabase) Collection(name string) (mingodb.CollectionIface, error) {
+	m.record("Collection", name)
+	if m.CollectionFunc != nil {
+		return m.CollectionFunc(name)
+	}
+	return m.MockCollection(name), nil
+}
+
+// ListCollections records the call and calls ListCollectionsFunc, if
+// it's set. Otherwise it returns the names of the MockCollections, in
+// sorted order.
+func (m *MockDatabase) ListCollections(ctx context.Context) ([]string, error) {
+	m.record("ListCollections", ctx)
+	if m.ListCollectionsFunc != nil {
+		return m.ListCollectionsFunc(ctx)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	names := make([]string, 0, len(m.collections))
+	for name := range m.collections {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// BeginTx records the call and calls BeginTxFunc, if it's set.
+func (m *MockDatabase) BeginTx(ctx context.Context) (*mingodb.Transaction, error) {
+	m.record("BeginTx", ctx)
+	if m.BeginTxFunc != nil {
+		return m.BeginTxFunc(ctx)
+	}
+	return nil, errNoBeginTx
+}
+
+// Stats records the call and calls StatsFunc, if it's set.
+func (m *MockDatabase) Stats() (*mingodb.DatabaseStats, error) {
+	m.record("Stats")
+	if m.StatsFunc != nil {
+		return m.StatsFunc()
+	}
+	return &mingodb.DatabaseStats{}, nil
+}
+
+// Close records the call and calls CloseFunc, if it's set.
+func (m *MockDatabase) Close() error {
+	m.record("Close")
+	if m.CloseFunc != nil {
+		return m.CloseFunc()
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package mock_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/korrbit/mingodb"
+	"github.com/korrbit/mingodb/mock"
+	"github.com/korrbit/mingodb/testutil"
+)
+
+// countAll is application code that depends on DatabaseIface.
+func countAll(ctx context.Context, db mingodb.DatabaseIface) (int, error) {
+	names, err := db.ListCollections(ctx)
+	if err != nil {
+		return 0, err
+	}
+	var total int
+	for _, name := range names {
+		c, err := db.Collection(name)
+		if err != nil {
+			return 0, err
+		}
+		n, err := c.CountDocuments(ctx, nil)
+		if err != nil {
+			return 0, err
+		}
+		total += n
+	}
+	return total, nil
+}
+
+func TestMockDatabase(t *testing.T) {
+	ctx := context.Background()
+	db := &mock.MockDatabase{}
+	for name, n := range map[string]int{"users": 2, "posts": 3} {
+		n := n
+		db.MockCollection(name).CountDocumentsFunc = func(context.Context, interface{}) (int, error) {
+			return n, nil
+		}
+	}
+
+	total, err := countAll(ctx, db)
+	if err != nil || total != 5 {
+		t.Errorf("countAll returned %d, %v, expected 5", total, err)
+	}
+	expected := []mock.Call{
+		{Method: "ListCollections", Args: []interface{}{ctx}},
+		{Method: "Collection", Args: []interface{}{"posts"}},
+		{Method: "Collection", Args: []interface{}{"users"}},
+	}
+	if calls := db.Calls(); !reflect.DeepEqual(calls, expected) {
+		t.Errorf("got calls %+v, expected %+v", calls, expected)
+	}
+
+	// Collection returns the same MockCollection for a name.
+	c, err := db.Collection("users")
+	if err != nil {
+		t.Fatalf("Collection: %v", err)
+	}
+	if c != db.MockCollection("users") || c.Name() != "users" {
+		t.Errorf("Collection returned %v, expected the users MockCollection", c)
+	}
+	if n := db.MockCollection("users").CallCount("CountDocuments"); n != 1 {
+		t.Errorf("got %d calls to CountDocuments, expected 1", n)
+	}
+}
+
+func TestMockDatabaseDefaults(t *testing.T) {
+	ctx := context.Background()
+	db := &mock.MockDatabase{}
+	if _, err := db.BeginTx(ctx); err == nil {
+		t.Error("BeginTx succeeded without a BeginTxFunc")
+	}
+	if stats, err := db.Stats(); err != nil || stats == nil {
+		t.Errorf("Stats returned %v, %v, expected empty stats", stats, err)
+	}
+	if err := db.Close(); err != nil {
+		t.Errorf("Close: %v", err)
+	}
+
+	errFailed := errors.New("failed")
+	db.CloseFunc = func() error { return errFailed }
+	if err := db.Close(); !errors.Is(err, errFailed) {
+		t.Errorf("Close returned %v, expected CloseFunc's error", err)
+	}
+	if n := db.CallCount("Close"); n != 2 {
+		t.Errorf("got %d calls to Close, expected 2", n)
+	}
+}
+
+func TestDatabaseIface(t *testing.T) {
+	// A real database can be used in place of the mock.
+	db := testutil.NewTestDB(t)
+	testutil.SeedCollection(t, db.CollectionMust("users"), map[string]interface{}{"_id": 1})
+	testutil.SeedCollection(t, db.CollectionMust("posts"), map[string]interface{}{"_id": 1}, map[string]interface{}{"_id": 2})
+	total, err := countAll(context.Background(), db.Iface())
+	if err != nil || total != 3 {
+		t.Errorf("countAll returned %d, %v, expected 3", total, err)
+	}
+}
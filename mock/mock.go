@@ -0,0 +1,36 @@
+// Package mock provides mocks of MingoDB's interfaces, so that code
+// that depends on mingodb.CollectionIface or mingodb.DatabaseIface can
+// be tested without a database.
+package mock
+
+import "github.com/korrbit/mingodb"
+
+// Call is a call made to a mock.
+type Call struct {
+	Method string        // The method's name, such as "InsertOne"
+	Args   []interface{} // The arguments, including the context
+}
+
+// countCalls returns the number of calls to the method.
+func countCalls(calls []Call, method string) int {
+	var n int
+	for _, c := range calls {
+		if c.Method == method {
+			n++
+		}
+	}
+	return n
+}
+
+// noDocuments returns a SingleResult without a document.
+func noDocuments() *mingodb.SingleResult {
+	r, _ := mingodb.NewSingleResult(nil)
+	return r
+}
+
+// closedEvents returns a closed channel of change events.
+func closedEvents() <-chan mingodb.ChangeEvent {
+	c := make(chan mingodb.ChangeEvent)
+	close(c)
+	return c
+}
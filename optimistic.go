@@ -0,0 +1,101 @@
+package mingodb
+
+import (
+	"context"
+	"fmt"
+
+	bolt "go.etcd.io/bbolt"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// VersionField is the field that holds a document's version. It's set
+// to 1 on documents inserted without one, and incremented each time the
+// document is updated or replaced, whichever method writes it.
+// Documents stored without a version, such as those written before
+// versions were kept, have version 0 until they're next written.
+//
+// The field is stored in the documents, so it's returned by reads, but
+// it's left out when documents are checked against the collection's
+// JSON Schema.
+const VersionField = "__version"
+
+// OptimisticUpdate applies the update to the first document that matches
+// the filter, but only if the document's version is still version. The
+// filter and update follow the same rules as UpdateOne.
+//
+// Read a document, note its version and pass the version back with the
+// update: if another write has changed the version in the meantime,
+// ErrVersionConflict is returned and nothing is written. Otherwise the
+// version is incremented along with the update, as it is by every
+// other write. Documents without a version are treated as having
+// version 0 (see VersionField).
+func (c *Collection) OptimisticUpdate(ctx context.Context, filter interface{}, update interface{}, version int64) (_ *UpdateResult, err error) {
+	ctx, end := c.startOperation(ctx, "optimisticUpdate", filter, update)
+	defer func() { end(err) }()
+	if err := c.beforeUpdate(ctx, filter, update); err != nil {
+		return nil, err
+	}
+
+	f, err := parseFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	u, err := parseUpdate(update)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	res := &UpdateResult{}
+	err = c.write(func(tx *bolt.Tx) error {
+		w, err := c.writeTx(ctx, tx)
+		if err != nil {
+			return err
+		}
+
+		// Find the first matching document.
+		var m *match
+		err = scanMatches(ctx, w.b, c.visible(f), func(k, v []byte, doc map[string]interface{}) (bool, error) {
+			m = &match{key: append([]byte(nil), k...), doc: doc}
+			return false, nil
+		})
+		if err != nil || m == nil {
+			return err
+		}
+		res.MatchedCount = 1
+
+		// Has it changed since it was read?
+		var current int64
+		if v, ok := m.doc[VersionField]; ok {
+			if current, ok = toInt(v); !ok {
+				return fmt.Errorf("%w: %s must be an integer", ErrTypeMismatch, VersionField)
+			}
+		}
+		if current != version {
+			return fmt.Errorf("_id %v: expected version %d, found %d: %w", m.doc["_id"], version, current, ErrVersionConflict)
+		}
+
+		// Apply the update and bump the version.
+		if err := applyUpdate(m.doc, u); err != nil {
+			return err
+		}
+		m.doc[VersionField] = current + 1
+		bdoc, err := bson.Marshal(m.doc)
+		if err != nil {
+			return err
+		}
+		if _, err := w.update(m.key, bdoc); err != nil {
+			return err
+		}
+		res.UpdateCount = 1
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	c.afterUpdate(ctx, res)
+	return res, nil
+}
@@ -0,0 +1,93 @@
+package mingodb_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/korrbit/mingodb"
+	"github.com/korrbit/mingodb/testutil"
+)
+
+// version returns the VersionField of the document with the _id, and
+// whether it has one.
+func version(t *testing.T, c *mingodb.Collection, id interface{}) (int64, bool) {
+	t.Helper()
+	var doc map[string]interface{}
+	if err := c.GetByIDInto(context.Background(), id, &doc); err != nil {
+		t.Fatalf("GetByIDInto: %v", err)
+	}
+	v, ok := doc[mingodb.VersionField]
+	if !ok {
+		return 0, false
+	}
+	switch n := v.(type) {
+	case int32:
+		return int64(n), true
+	case int64:
+		return n, true
+	}
+	t.Fatalf("%s is a %T", mingodb.VersionField, v)
+	return 0, false
+}
+
+func TestOptimisticUpdate(t *testing.T) {
+	ctx := context.Background()
+	db := testutil.NewTestDB(t)
+	c := db.CollectionMust("items")
+	testutil.SeedCollection(t, c, map[string]interface{}{"_id": 1, "n": 1})
+	if v, _ := version(t, c, 1); v != 1 {
+		t.Fatalf("inserted document has version %d, expected 1", v)
+	}
+
+	// Another write since version 1 was read.
+	inc := map[string]interface{}{"$inc": map[string]interface{}{"n": 1}}
+	if _, err := c.UpdateOne(ctx, map[string]interface{}{"_id": 1}, inc); err != nil {
+		t.Fatalf("UpdateOne: %v", err)
+	}
+	if _, err := c.OptimisticUpdate(ctx, map[string]interface{}{"_id": 1}, inc, 1); !errors.Is(err, mingodb.ErrVersionConflict) {
+		t.Fatalf("OptimisticUpdate returned %v, expected ErrVersionConflict", err)
+	}
+
+	res, err := c.OptimisticUpdate(ctx, map[string]interface{}{"_id": 1}, inc, 2)
+	if err != nil {
+		t.Fatalf("OptimisticUpdate: %v", err)
+	}
+	if res.UpdateCount != 1 {
+		t.Errorf("updated %d documents, expected 1", res.UpdateCount)
+	}
+	if v, _ := version(t, c, 1); v != 3 {
+		t.Errorf("document has version %d, expected 3", v)
+	}
+}
+
+func TestOptimisticUpdateVersionZero(t *testing.T) {
+	ctx := context.Background()
+	db := testutil.NewTestDB(t)
+	c := db.CollectionMust("items")
+	testutil.SeedCollection(t, c, map[string]interface{}{"_id": 1, "n": 1})
+
+	// Inserted documents start at version 1, so a write made after
+	// reading a document without a version is still a conflict.
+	inc := map[string]interface{}{"$inc": map[string]interface{}{"n": 1}}
+	if _, err := c.UpdateOne(ctx, map[string]interface{}{"_id": 1}, inc); err != nil {
+		t.Fatalf("UpdateOne: %v", err)
+	}
+	if _, err := c.OptimisticUpdate(ctx, map[string]interface{}{"_id": 1}, inc, 0); !errors.Is(err, mingodb.ErrVersionConflict) {
+		t.Errorf("OptimisticUpdate returned %v, expected ErrVersionConflict", err)
+	}
+}
+
+func TestVersionFieldIgnoredBySchema(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	c := db.CollectionMust("items")
+	schema := `{
+		"type": "object",
+		"properties": {"_id": {}, "name": {"type": "string"}},
+		"additionalProperties": false
+	}`
+	if err := c.SetJSONSchema([]byte(schema)); err != nil {
+		t.Fatalf("SetJSONSchema: %v", err)
+	}
+	testutil.SeedCollection(t, c, map[string]interface{}{"_id": 1, "name": "Alice"})
+}
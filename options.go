@@ -0,0 +1,371 @@
+package mingodb
+
+import "time"
+
+// FindOptions configures the results returned by Find and FindOne.
+type FindOptions struct {
+	// Sort orders the results by the given fields, in order.
+	Sort []SortField
+
+	// Limit is the maximum number of documents to return.
+	// A value of 0 means no limit.
+	Limit int
+
+	// Skip is the number of matching documents to skip before
+	// returning results.
+	Skip int
+
+	// Projection selects the fields to return. Either list the
+	// fields to include with 1 (the _id is included unless it's
+	// excluded with 0) or list the fields to exclude with 0.
+	// Inclusions and exclusions can't be mixed.
+	Projection map[string]int
+
+	// UseIndex is the name of an index to find the documents with,
+	// rather than letting Find pick one (see Explain). The index is
+	// scanned for the filter's equality conditions on its leading
+	// fields, or scanned in full if there aren't any. Documents that
+	// aren't in the index, such as those missing the field of a sparse
+	// index, aren't returned.
+	UseIndex string
+}
+
+// SortField is a field to sort results by.
+type SortField struct {
+	Field string
+	Dir   int // 1 for ascending order, -1 for descending order
+}
+
+// mergeFindOptions combines opts into a single FindOptions.
+// Later options override earlier ones.
+func mergeFindOptions(opts []FindOptions) FindOptions {
+	var o FindOptions
+	for _, opt := range opts {
+		if opt.Sort != nil {
+			o.Sort = opt.Sort
+		}
+		if opt.Limit != 0 {
+			o.Limit = opt.Limit
+		}
+		if opt.Skip != 0 {
+			o.Skip = opt.Skip
+		}
+		if opt.Projection != nil {
+			o.Projection = opt.Projection
+		}
+		if opt.UseIndex != "" {
+			o.UseIndex = opt.UseIndex
+		}
+	}
+	return o
+}
+
+// paginate applies the skip and limit options to docs.
+//
+// Note that sorted results are currently paginated after the whole
+// collection has been scanned. Using an index to sort and limit
+// results is a future optimization.
+func (o FindOptions) paginate(matches []match) []match {
+	if o.Skip > 0 {
+		if o.Skip >= len(matches) {
+			return nil
+		}
+		matches = matches[o.Skip:]
+	}
+	if o.Limit > 0 && o.Limit < len(matches) {
+		matches = matches[:o.Limit]
+	}
+	return matches
+}
+
+// validateProjection checks that the projection either only includes
+// or only excludes fields (other than _id, which can always be
+// excluded). Returns true if the projection includes fields.
+func validateProjection(proj map[string]int) (bool, error) {
+	var include, exclude bool
+	for k, v := range proj {
+		if v != 0 {
+			include = true
+		} else if k != "_id" {
+			exclude = true
+		}
+	}
+	if include && exclude {
+		return false, ErrInvalidProjection
+	}
+	return include, nil
+}
+
+// projectDocument returns a copy of doc containing only the fields
+// selected by the projection.
+func projectDocument(doc map[string]interface{}, proj map[string]int, include bool) map[string]interface{} {
+	out := make(map[string]interface{})
+	if include {
+		// Keep the listed fields, plus the _id unless it's excluded.
+		for k, v := range proj {
+			if val, ok := doc[k]; ok && v != 0 {
+				out[k] = val
+			}
+		}
+		if v, ok := proj["_id"]; !ok || v != 0 {
+			if id, ok := doc["_id"]; ok {
+				out["_id"] = id
+			}
+		}
+		return out
+	}
+
+	// Drop the listed fields.
+	for k, v := range doc {
+		if _, ok := proj[k]; !ok {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// ReturnDocument specifies which version of a document is
+// returned by FindOneAndUpdate.
+type ReturnDocument int
+
+const (
+	// Before returns the document as it was before the update.
+	Before ReturnDocument = iota
+	// After returns the document as it is after the update.
+	After
+)
+
+// FindOneAndUpdateOptions configures FindOneAndUpdate.
+type FindOneAndUpdateOptions struct {
+	// ReturnDocument selects whether the document is returned
+	// as it was before or after the update. Defaults to Before.
+	ReturnDocument ReturnDocument
+}
+
+// mergeFindOneAndUpdateOptions combines opts into a single
+// FindOneAndUpdateOptions. Later options override earlier ones.
+func mergeFindOneAndUpdateOptions(opts []FindOneAndUpdateOptions) FindOneAndUpdateOptions {
+	var o FindOneAndUpdateOptions
+	for _, opt := range opts {
+		if opt.ReturnDocument != Before {
+			o.ReturnDocument = opt.ReturnDocument
+		}
+	}
+	return o
+}
+
+// CopyOptions configures CopyCollection.
+type CopyOptions struct {
+	// IncludeIndexes copies the source collection's indexes as well
+	// as its documents.
+	IncludeIndexes bool
+}
+
+// mergeCopyOptions combines opts into a single CopyOptions.
+func mergeCopyOptions(opts []CopyOptions) CopyOptions {
+	var o CopyOptions
+	for _, opt := range opts {
+		if opt.IncludeIndexes {
+			o.IncludeIndexes = true
+		}
+	}
+	return o
+}
+
+// CollectionOptions configures the Collection returned by
+// Database.Collection.
+type CollectionOptions struct {
+	// IDGenerator generates the _id of documents inserted without
+	// one, overriding the database's OpenOptions.IDGenerator.
+	IDGenerator IDGenerator
+
+	// Middleware is registered with the collection, as if by
+	// Collection.Use.
+	Middleware []Middleware
+}
+
+// mergeCollectionOptions combines opts into a single
+// CollectionOptions. Later options override earlier ones, apart from
+// Middleware, which is appended.
+func mergeCollectionOptions(opts []CollectionOptions) CollectionOptions {
+	var o CollectionOptions
+	for _, opt := range opts {
+		if opt.IDGenerator != nil {
+			o.IDGenerator = opt.IDGenerator
+		}
+		o.Middleware = append(o.Middleware, opt.Middleware...)
+	}
+	return o
+}
+
+// HistoryOptions configures Collection.GetHistory.
+type HistoryOptions struct {
+	// Limit is the maximum number of versions to return. 0 means
+	// no limit.
+	Limit int
+
+	// NewestFirst returns the most recent versions first, rather
+	// than the oldest.
+	NewestFirst bool
+}
+
+// mergeHistoryOptions combines opts into a single HistoryOptions.
+// Later limits override earlier ones.
+func mergeHistoryOptions(opts []HistoryOptions) HistoryOptions {
+	var o HistoryOptions
+	for _, opt := range opts {
+		if opt.Limit != 0 {
+			o.Limit = opt.Limit
+		}
+		if opt.NewestFirst {
+			o.NewestFirst = true
+		}
+	}
+	return o
+}
+
+// UnwindOptions configures UnwindStage.
+type UnwindOptions struct {
+	// PreserveNullAndEmptyArrays outputs the documents where the field
+	// is missing, null or an empty array unchanged, rather than
+	// dropping them.
+	PreserveNullAndEmptyArrays bool
+}
+
+// mergeUnwindOptions combines opts into a single UnwindOptions.
+func mergeUnwindOptions(opts []UnwindOptions) UnwindOptions {
+	var o UnwindOptions
+	for _, opt := range opts {
+		if opt.PreserveNullAndEmptyArrays {
+			o.PreserveNullAndEmptyArrays = true
+		}
+	}
+	return o
+}
+
+// BucketOptions configures BucketStage.
+type BucketOptions struct {
+	// Default is the _id of the bucket for documents whose value is
+	// outside the boundaries. Without one, such documents are an error.
+	Default interface{}
+
+	// Output is the accumulators computed for every bucket, as for
+	// $group, such as {"total": {"$sum": "$amount"}}. It defaults to
+	// {"count": {"$sum": 1}}.
+	Output map[string]interface{}
+}
+
+// mergeBucketOptions combines opts into a single BucketOptions.
+func mergeBucketOptions(opts []BucketOptions) BucketOptions {
+	var o BucketOptions
+	for _, opt := range opts {
+		if opt.Default != nil {
+			o.Default = opt.Default
+		}
+		if opt.Output != nil {
+			o.Output = opt.Output
+		}
+	}
+	return o
+}
+
+// ExportFormat is the format of the documents written by
+// Collection.Export and read by Collection.Import.
+type ExportFormat int
+
+const (
+	// ExportJSON writes each document as a line of relaxed Extended
+	// JSON, which keeps the types of values such as ObjectIDs and
+	// dates, but not the size of integers.
+	ExportJSON ExportFormat = iota
+
+	// ExportBSON writes the documents' BSON one after the other, as
+	// mongodump does, which keeps the types of every value.
+	ExportBSON
+)
+
+// ExportOptions configures Collection.Export.
+type ExportOptions struct {
+	// Format is the format to write the documents in. Defaults to
+	// ExportJSON.
+	Format ExportFormat
+
+	// Filter selects the documents to export, following the same
+	// rules as Find. A nil Filter exports every document.
+	Filter interface{}
+
+	// Since only exports the documents whose _id is an ObjectID
+	// created after Since, to the second, for incremental exports.
+	// The zero Since exports documents whatever their _id.
+	Since time.Time
+}
+
+// mergeExportOptions combines opts into a single ExportOptions.
+// Later options override earlier ones.
+func mergeExportOptions(opts []ExportOptions) ExportOptions {
+	var o ExportOptions
+	for _, opt := range opts {
+		if opt.Format != ExportJSON {
+			o.Format = opt.Format
+		}
+		if opt.Filter != nil {
+			o.Filter = opt.Filter
+		}
+		if !opt.Since.IsZero() {
+			o.Since = opt.Since
+		}
+	}
+	return o
+}
+
+// ImportOptions configures Collection.Import.
+type ImportOptions struct {
+	// Format is the format the documents were exported in. Defaults
+	// to ExportJSON.
+	Format ExportFormat
+
+	// Replace replaces the documents whose _id is already in the
+	// collection, as when importing an incremental export, rather
+	// than failing with ErrDuplicateKey.
+	Replace bool
+}
+
+// mergeImportOptions combines opts into a single ImportOptions.
+// Later formats override earlier ones.
+func mergeImportOptions(opts []ImportOptions) ImportOptions {
+	var o ImportOptions
+	for _, opt := range opts {
+		if opt.Format != ExportJSON {
+			o.Format = opt.Format
+		}
+		if opt.Replace {
+			o.Replace = true
+		}
+	}
+	return o
+}
+
+// CSVImportOptions configures Collection.ImportCSV.
+type CSVImportOptions struct {
+	// Schema maps columns to the type their values are converted to:
+	// "string", "int", "double", "bool", "date" (RFC 3339) or
+	// "objectId". Values of other columns are stored as strings. Empty
+	// values of typed columns, other than "string" ones, are left out
+	// of the document.
+	Schema map[string]string
+}
+
+// mergeCSVImportOptions combines opts into a single CSVImportOptions.
+// Later types for a column override earlier ones.
+func mergeCSVImportOptions(opts []CSVImportOptions) CSVImportOptions {
+	var o CSVImportOptions
+	for _, opt := range opts {
+		for col, typ := range opt.Schema {
+			if o.Schema == nil {
+				o.Schema = make(map[string]string)
+			}
+			o.Schema[col] = typ
+		}
+	}
+	return o
+}
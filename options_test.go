@@ -0,0 +1,97 @@
+package mingodb_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/korrbit/mingodb"
+)
+
+func TestFindOptions(t *testing.T) {
+	ctx := context.Background()
+	c := people(t)
+	byAge := mingodb.FindOptions{Sort: []mingodb.SortField{{Field: "age", Dir: -1}}}
+
+	if got := findIDs(t, c, nil, byAge); !reflect.DeepEqual(got, []int32{3, 1, 2}) {
+		t.Errorf("Find returned %v, expected [3 1 2]", got)
+	}
+
+	res, err := c.FindOne(ctx, nil, byAge)
+	if err != nil {
+		t.Fatalf("FindOne: %v", err)
+	}
+	var doc struct {
+		ID int32 `bson:"_id"`
+	}
+	if err := res.Decode(&doc); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if doc.ID != 3 {
+		t.Errorf("FindOne returned _id %d, expected 3", doc.ID)
+	}
+}
+
+func TestLimitAndSkip(t *testing.T) {
+	ctx := context.Background()
+	c := people(t)
+	tests := []struct {
+		name     string
+		opts     mingodb.FindOptions
+		expected []int32
+	}{
+		{"limit", mingodb.FindOptions{Limit: 2}, []int32{1, 2}},
+		{"skip", mingodb.FindOptions{Skip: 1}, []int32{2, 3}},
+		{"page", mingodb.FindOptions{Skip: 1, Limit: 1}, []int32{2}},
+		{"past the end", mingodb.FindOptions{Skip: 5}, []int32{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := c.Find(ctx, nil, tt.opts)
+			if err != nil {
+				t.Fatalf("Find: %v", err)
+			}
+			if res.ResultCount != len(tt.expected) || res.TotalMatched != 3 {
+				t.Errorf("ResultCount is %d and TotalMatched is %d, expected %d and 3", res.ResultCount, res.TotalMatched, len(tt.expected))
+			}
+			if got := resultIDs(t, res); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("got %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestProjection(t *testing.T) {
+	ctx := context.Background()
+	c := people(t)
+	tests := []struct {
+		name       string
+		projection map[string]int
+		expected   map[string]interface{}
+	}{
+		{"include", map[string]int{"name": 1}, map[string]interface{}{"_id": int32(1), "name": "Alice"}},
+		{"include without _id", map[string]int{"name": 1, "_id": 0}, map[string]interface{}{"name": "Alice"}},
+		{"exclude", map[string]int{"age": 0, "city": 0, mingodb.VersionField: 0}, map[string]interface{}{"_id": int32(1), "name": "Alice"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := c.FindOne(ctx, map[string]interface{}{"_id": 1}, mingodb.FindOptions{Projection: tt.projection})
+			if err != nil {
+				t.Fatalf("FindOne: %v", err)
+			}
+			var doc map[string]interface{}
+			if err := res.Decode(&doc); err != nil {
+				t.Fatalf("Decode: %v", err)
+			}
+			if !reflect.DeepEqual(doc, tt.expected) {
+				t.Errorf("got %v, expected %v", doc, tt.expected)
+			}
+		})
+	}
+
+	_, err := c.Find(ctx, nil, mingodb.FindOptions{Projection: map[string]int{"name": 1, "age": 0}})
+	if !errors.Is(err, mingodb.ErrInvalidProjection) {
+		t.Errorf("Find returned %v, expected ErrInvalidProjection", err)
+	}
+}
@@ -0,0 +1,233 @@
+package mingodb
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	bolt "go.etcd.io/bbolt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// indexScan is a scan of the documents in an index whose keys
+// start with prefix.
+type indexScan struct {
+	idx    *index
+	prefix []byte
+	fields int      // Number of index fields covered by prefix
+	text   bool     // Whether the scan is a text search
+	terms  []string // Words searched for by a text search
+}
+
+// QueryPlan describes how the documents that match a filter are found.
+// It's returned by Explain.
+type QueryPlan struct {
+	IndexUsed   bool     // Whether the documents are found through an index
+	IndexName   string   // Name of the index, if one is used
+	IndexFields []string // Fields looked up in the index, if any
+}
+
+// Explain returns the plan that Find would use to find the documents
+// that match the filter with the given options.
+func (c *Collection) Explain(ctx context.Context, filter interface{}, opts ...FindOptions) (QueryPlan, error) {
+	f, err := parseFilter(filter)
+	if err != nil {
+		return QueryPlan{}, err
+	}
+	o := mergeFindOptions(opts)
+
+	if err := ctx.Err(); err != nil {
+		return QueryPlan{}, err
+	}
+
+	var plan QueryPlan
+	err = c.read(func(tx *bolt.Tx) error {
+		if _, err := c.bucket(tx); err != nil {
+			return err
+		}
+		indexes, err := c.indexes(tx)
+		if err != nil {
+			return err
+		}
+		s, err := planScan(indexes, f, o)
+		if err != nil || s == nil {
+			return err
+		}
+		plan.IndexUsed = true
+		plan.IndexName = s.idx.Name
+		for _, k := range s.idx.Keys[:s.fields] {
+			plan.IndexFields = append(plan.IndexFields, k.Field)
+		}
+		return nil
+	})
+	if err != nil {
+		return QueryPlan{}, err
+	}
+	return plan, nil
+}
+
+// planScan returns the index scan to use to find the documents that
+// match the filter, or nil if the whole collection should be scanned.
+//
+// A $text filter always uses the collection's text index, and the
+// index selected with FindOptions.UseIndex is always used otherwise.
+// Otherwise, the index that can look up the most of the filter's
+// equality conditions is used, preferring a unique index whose fields
+// are all covered, as it matches at most one document.
+func planScan(indexes []*index, f map[string]interface{}, o FindOptions) (*indexScan, error) {
+	// A text search always uses the text index.
+	if arg, ok := f["$text"]; ok {
+		for _, idx := range indexes {
+			if idx.Text {
+				terms, err := textSearch(arg, idx)
+				if err != nil {
+					return nil, err
+				}
+				return &indexScan{idx: idx, text: true, terms: terms}, nil
+			}
+		}
+		return nil, fmt.Errorf("%w: $text requires a text index", ErrIndexNotFound)
+	}
+
+	if o.UseIndex != "" {
+		for _, idx := range indexes {
+			if idx.Name == o.UseIndex {
+				return idx.scan(f)
+			}
+		}
+		return nil, fmt.Errorf("%s: %w", o.UseIndex, ErrIndexNotFound)
+	}
+
+	var best *indexScan
+	for _, idx := range indexes {
+		s, err := idx.scan(f)
+		if err != nil {
+			return nil, err
+		}
+		if s.fields > 0 && (best == nil || s.betterThan(best)) {
+			best = s
+		}
+	}
+	return best, nil
+}
+
+// betterThan reports whether s is likely to scan fewer documents
+// than other.
+func (s *indexScan) betterThan(other *indexScan) bool {
+	if s.unique() != other.unique() {
+		return s.unique()
+	}
+	if s.fields != other.fields {
+		return s.fields > other.fields
+	}
+	// Prefer the index with fewer fields left over.
+	return len(s.idx.Keys) < len(other.idx.Keys)
+}
+
+// unique reports whether s can match at most one document.
+func (s *indexScan) unique() bool {
+	return s.idx.Unique && s.fields == len(s.idx.Keys)
+}
+
+// scan returns a scan of the index that only covers the keys that
+// can match the filter. The scan's prefix is built from the filter's
+// equality conditions on the index's leading fields; if the filter
+// doesn't have one for the first field, the whole index is scanned.
+func (idx *index) scan(f map[string]interface{}) (*indexScan, error) {
+	s := &indexScan{idx: idx}
+	if idx.TTL || idx.Text {
+		// Not keyed on field values, so there's nothing to look up.
+		return s, nil
+	}
+	for _, k := range idx.Keys {
+		v, ok := equalityValue(f[k.Field])
+		if !ok {
+			break
+		}
+		enc, err := encodeIndexValue(v)
+		if err != nil {
+			return nil, err
+		}
+		s.prefix = append(s.prefix, enc...)
+		s.fields++
+	}
+	return s, nil
+}
+
+// equalityValue returns the value a filter condition requires a field
+// to be equal to, if it can be looked up in an index. Arrays can't,
+// as an array field is indexed by its elements rather than as a whole.
+func equalityValue(cond interface{}) (interface{}, bool) {
+	if cond == nil {
+		return nil, false
+	}
+	if m, ok := cond.(map[string]interface{}); ok && isOperatorDocument(m) {
+		v, ok := m["$eq"]
+		if !ok || len(m) != 1 {
+			return nil, false
+		}
+		cond = v
+	}
+	if _, ok := cond.(primitive.A); ok {
+		return nil, false
+	}
+	return cond, true
+}
+
+// scanPlan calls fn for each document that matches the filter, like
+// scanMatches, but finds the documents through the index scan if
+// there is one. Documents found through an index are passed in the
+// order of the index's keys.
+func scanPlan(ctx context.Context, b *bolt.Bucket, s *indexScan, filter map[string]interface{}, fn func(k, v []byte, doc map[string]interface{}) (bool, error)) error {
+	if s == nil {
+		return scanMatches(ctx, b, filter, fn)
+	}
+	if s.text {
+		return scanText(ctx, b, s, filter, fn)
+	}
+
+	// A document is indexed once for every element of an indexed
+	// array, so skip the ones that have already been seen.
+	seen := make(map[string]bool)
+	c := s.idx.b.Cursor()
+	for ik, iv := c.Seek(s.prefix); ik != nil && bytes.HasPrefix(ik, s.prefix); ik, iv = c.Next() {
+		var e indexEntry
+		if err := bson.Unmarshal(iv, &e); err != nil {
+			return err
+		}
+		for _, k := range e.Keys {
+			// Has the scan been cancelled?
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			if seen[string(k)] {
+				continue
+			}
+			seen[string(k)] = true
+
+			v := b.Get(k)
+			if v == nil {
+				continue
+			}
+			countScanned(ctx)
+			data, doc, err := decodeDocument(b.Tx(), v)
+			if err != nil {
+				return err
+			}
+			ok, err := matchesFilter(doc, filter)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				continue
+			}
+			more, err := fn(k, data, doc)
+			if err != nil || !more {
+				return err
+			}
+		}
+	}
+	return nil
+}
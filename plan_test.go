@@ -0,0 +1,72 @@
+package mingodb_test
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/korrbit/mingodb"
+	"github.com/korrbit/mingodb/testutil"
+)
+
+func TestExplain(t *testing.T) {
+	ctx := context.Background()
+	c := people(t)
+	if _, err := c.CreateIndex(ctx, "city"); err != nil {
+		t.Fatalf("CreateIndex: %v", err)
+	}
+	if _, err := c.CreateIndex(ctx, "name", mingodb.IndexOptions{Unique: true}); err != nil {
+		t.Fatalf("CreateIndex: %v", err)
+	}
+	tests := []struct {
+		name     string
+		filter   map[string]interface{}
+		expected mingodb.QueryPlan
+	}{
+		{"no filter", nil, mingodb.QueryPlan{}},
+		{"unindexed field", map[string]interface{}{"age": 30}, mingodb.QueryPlan{}},
+		{"range", map[string]interface{}{"city": map[string]interface{}{"$gt": "A"}}, mingodb.QueryPlan{}},
+		{"equality", map[string]interface{}{"city": "Paris", "age": 30}, mingodb.QueryPlan{IndexUsed: true, IndexName: "city_1", IndexFields: []string{"city"}}},
+		{"unique index", map[string]interface{}{"city": "Paris", "name": "Carol"}, mingodb.QueryPlan{IndexUsed: true, IndexName: "name_1", IndexFields: []string{"name"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan, err := c.Explain(ctx, tt.filter)
+			if err != nil {
+				t.Fatalf("Explain: %v", err)
+			}
+			if !reflect.DeepEqual(plan, tt.expected) {
+				t.Errorf("got %+v, expected %+v", plan, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFindWithIndex(t *testing.T) {
+	ctx := context.Background()
+	c := people(t)
+	if _, err := c.CreateIndex(ctx, "city"); err != nil {
+		t.Fatalf("CreateIndex: %v", err)
+	}
+
+	// The index follows updates and deletes.
+	if _, err := c.UpdateOne(ctx, map[string]interface{}{"_id": 2}, map[string]interface{}{"$set": map[string]interface{}{"city": "Paris"}}); err != nil {
+		t.Fatalf("UpdateOne: %v", err)
+	}
+	if _, err := c.DeleteOne(ctx, 1); err != nil {
+		t.Fatalf("DeleteOne: %v", err)
+	}
+
+	filter := map[string]interface{}{"city": "Paris", "age": map[string]interface{}{"$gt": 30}}
+	if got := findIDs(t, c, filter); !reflect.DeepEqual(got, []int32{3}) {
+		t.Errorf("got %v, expected [3]", got)
+	}
+	// Documents found through an index are in the index's order.
+	got := findIDs(t, c, map[string]interface{}{"city": "Paris"})
+	sort.Slice(got, func(i, j int) bool { return got[i] < got[j] })
+	if !reflect.DeepEqual(got, []int32{2, 3}) {
+		t.Errorf("got %v, expected [2 3]", got)
+	}
+	testutil.AssertDocumentCount(t, c, map[string]interface{}{"city": "London"}, 0)
+}
@@ -0,0 +1,159 @@
+package mingodb
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// DefaultProfileLogSize is the default number of entries kept by a
+// ProfilingMiddleware.
+const DefaultProfileLogSize = 100
+
+// ProfileEntry describes a slow operation recorded by
+// ProfilingMiddleware.
+type ProfileEntry struct {
+	Time        time.Time     // When the operation started
+	Operation   string        // The operation's name, such as "find" or "updateMany"
+	Filter      interface{}   // The filter, sanitized (see ProfilingMiddleware)
+	Update      interface{}   // The update or replacement, sanitized
+	Duration    time.Duration // How long the operation took
+	DocsScanned int           // Number of documents read to find the matches
+}
+
+// ProfilingMiddleware records the operations that take longer than a
+// threshold, to help find the filters that need an index. Register it
+// with Collection.Use. It's an OperationMiddleware, so reads are
+// recorded as well as writes, whether they succeed or fail.
+//
+// The filters and updates of the recorded entries are sanitized: their
+// field names and operators are kept, but their values are replaced
+// with "?".
+type ProfilingMiddleware struct {
+	BaseMiddleware
+
+	// Threshold is the duration above which operations are recorded.
+	Threshold time.Duration
+
+	// MaxEntries is the number of entries kept. Once it's reached, the
+	// oldest entry is dropped for each new one. Defaults to
+	// DefaultProfileLogSize.
+	MaxEntries int
+
+	mu      sync.Mutex
+	entries []ProfileEntry
+}
+
+// NewProfilingMiddleware returns a ProfilingMiddleware that records
+// the operations that take longer than threshold.
+func NewProfilingMiddleware(threshold time.Duration) *ProfilingMiddleware {
+	return &ProfilingMiddleware{Threshold: threshold, MaxEntries: DefaultProfileLogSize}
+}
+
+// Log returns the recorded entries, oldest first.
+func (m *ProfilingMiddleware) Log() []ProfileEntry {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]ProfileEntry(nil), m.entries...)
+}
+
+// Reset clears the recorded entries.
+func (m *ProfilingMiddleware) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries = nil
+}
+
+// StartOperation returns ctx. The operation's start time is tracked
+// by the collection.
+func (m *ProfilingMiddleware) StartOperation(ctx context.Context, _ *Collection, _ string) context.Context {
+	return ctx
+}
+
+// EndOperation records the operation if it was slow.
+func (m *ProfilingMiddleware) EndOperation(ctx context.Context, _ error) {
+	m.record(ctx)
+}
+
+// record adds an entry for ctx's operation if it took longer than
+// the threshold.
+func (m *ProfilingMiddleware) record(ctx context.Context) {
+	op := operationFrom(ctx)
+	if op == nil {
+		return
+	}
+	d := time.Since(op.start)
+	if d <= m.Threshold {
+		return
+	}
+	e := ProfileEntry{
+		Time:        op.start,
+		Operation:   op.name,
+		Filter:      sanitizeValue(op.filter),
+		Update:      sanitizeValue(op.update),
+		Duration:    d,
+		DocsScanned: op.scanned,
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	max := m.MaxEntries
+	if max <= 0 {
+		max = DefaultProfileLogSize
+	}
+	if len(m.entries) >= max {
+		m.entries = append(m.entries[:0], m.entries[len(m.entries)-max+1:]...)
+	}
+	m.entries = append(m.entries, e)
+}
+
+// sanitizeValue returns a copy of a filter or update with its values
+// replaced by "?". Documents keep their keys and arrays keep the
+// documents they contain (as in $and), so that the shape of the query
+// is still visible.
+func sanitizeValue(v interface{}) interface{} {
+	if v == nil {
+		return nil
+	}
+	m, err := toDocument(v)
+	if err != nil {
+		return "?"
+	}
+	s := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		s[k] = sanitizeField(v)
+	}
+	return s
+}
+
+// sanitizeField sanitizes a value within a filter or update.
+func sanitizeField(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		return sanitizeValue(v)
+	case []interface{}:
+		return sanitizeArray(v)
+	case primitive.A:
+		return sanitizeArray(v)
+	}
+	return "?"
+}
+
+// sanitizeArray sanitizes each of the array's documents. It returns
+// "?" if the array doesn't contain any documents.
+func sanitizeArray(a []interface{}) interface{} {
+	var docs bool
+	s := make([]interface{}, len(a))
+	for i, v := range a {
+		if _, ok := v.(map[string]interface{}); ok {
+			docs = true
+		}
+		s[i] = sanitizeField(v)
+	}
+	if !docs {
+		return "?"
+	}
+	return s
+}
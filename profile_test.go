@@ -0,0 +1,91 @@
+package mingodb_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/korrbit/mingodb"
+)
+
+func TestProfilingMiddleware(t *testing.T) {
+	ctx := context.Background()
+	c := people(t)
+	p := mingodb.NewProfilingMiddleware(0)
+	c.Use(p)
+
+	if _, err := c.Find(ctx, map[string]interface{}{"age": map[string]interface{}{"$gt": 26}}); err != nil {
+		t.Fatalf("Find: %v", err)
+	}
+	if _, err := c.UpdateMany(ctx, map[string]interface{}{"city": "Paris"}, map[string]interface{}{"$set": map[string]interface{}{"city": "Lyon"}}); err != nil {
+		t.Fatalf("UpdateMany: %v", err)
+	}
+	log := p.Log()
+	if len(log) != 2 {
+		t.Fatalf("got %d entries, expected 2", len(log))
+	}
+	expected := []mingodb.ProfileEntry{
+		{
+			Operation:   "find",
+			Filter:      map[string]interface{}{"age": map[string]interface{}{"$gt": "?"}},
+			DocsScanned: 3,
+		},
+		{
+			Operation:   "updateMany",
+			Filter:      map[string]interface{}{"city": "?"},
+			Update:      map[string]interface{}{"$set": map[string]interface{}{"city": "?"}},
+			DocsScanned: 3,
+		},
+	}
+	for i, e := range log {
+		if e.Time.IsZero() || e.Duration <= 0 {
+			t.Errorf("entry %d has time %v and duration %v", i, e.Time, e.Duration)
+		}
+		e.Time, e.Duration = time.Time{}, 0
+		if !reflect.DeepEqual(e, expected[i]) {
+			t.Errorf("got entry %d %+v, expected %+v", i, e, expected[i])
+		}
+	}
+
+	p.Reset()
+	if log := p.Log(); len(log) != 0 {
+		t.Errorf("got %d entries after Reset, expected none", len(log))
+	}
+}
+
+func TestProfilingMiddlewareThreshold(t *testing.T) {
+	c := people(t)
+	p := mingodb.NewProfilingMiddleware(time.Hour)
+	c.Use(p)
+	if _, err := c.Find(context.Background(), nil); err != nil {
+		t.Fatalf("Find: %v", err)
+	}
+	if log := p.Log(); len(log) != 0 {
+		t.Errorf("got %d entries, expected none under the threshold", len(log))
+	}
+}
+
+func TestProfilingMiddlewareMaxEntries(t *testing.T) {
+	ctx := context.Background()
+	c := people(t)
+	p := mingodb.NewProfilingMiddleware(0)
+	p.MaxEntries = 2
+	c.Use(p)
+	for _, op := range []func() error{
+		func() error { _, err := c.Find(ctx, nil); return err },
+		func() error { _, err := c.CountDocuments(ctx, nil); return err },
+		func() error { _, err := c.Distinct(ctx, "city", nil); return err },
+	} {
+		if err := op(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var ops []string
+	for _, e := range p.Log() {
+		ops = append(ops, e.Operation)
+	}
+	if expected := []string{"countDocuments", "distinct"}; !reflect.DeepEqual(ops, expected) {
+		t.Errorf("got operations %v, expected %v", ops, expected)
+	}
+}
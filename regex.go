@@ -0,0 +1,94 @@
+package mingodb
+
+import (
+	"fmt"
+	"regexp"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// maxCachedRegexes is the maximum number of compiled regular
+// expressions kept in the cache.
+const maxCachedRegexes = 256
+
+// regexCache holds compiled regular expressions, keyed by their
+// options and pattern, so that a scan doesn't recompile the same
+// expression for every document.
+var regexCache = struct {
+	sync.Mutex
+	m map[string]*regexp.Regexp
+}{m: make(map[string]*regexp.Regexp)}
+
+// compileRegex compiles a pattern with MongoDB-style options
+// ("i", "m" and "s"), using the cache where possible.
+func compileRegex(pattern, options string) (*regexp.Regexp, error) {
+	key := options + "/" + pattern
+
+	regexCache.Lock()
+	defer regexCache.Unlock()
+	if re, ok := regexCache.m[key]; ok {
+		return re, nil
+	}
+
+	// Convert the options into flags.
+	var flags string
+	for _, o := range options {
+		switch o {
+		case 'i', 'm', 's':
+			flags += string(o)
+		default:
+			return nil, fmt.Errorf("%w: unsupported option %q", ErrInvalidRegex, o)
+		}
+	}
+	expr := pattern
+	if flags != "" {
+		expr = "(?" + flags + ")" + pattern
+	}
+
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRegex, err)
+	}
+
+	// Keep the cache from growing without bound.
+	if len(regexCache.m) >= maxCachedRegexes {
+		regexCache.m = make(map[string]*regexp.Regexp)
+	}
+	regexCache.m[key] = re
+	return re, nil
+}
+
+// matchesRegex reports whether v is a string that matches the
+// $regex operand, which is either a pattern string (with options
+// from $options) or a primitive.Regex.
+func matchesRegex(v interface{}, exists bool, arg, options interface{}) (bool, error) {
+	var pattern, opts string
+	switch r := arg.(type) {
+	case string:
+		pattern = r
+	case primitive.Regex:
+		pattern, opts = r.Pattern, r.Options
+	default:
+		return false, fmt.Errorf("%w: $regex requires a string", ErrInvalidFilter)
+	}
+	if options != nil {
+		o, ok := options.(string)
+		if !ok {
+			return false, fmt.Errorf("%w: $options requires a string", ErrInvalidFilter)
+		}
+		opts = o
+	}
+
+	re, err := compileRegex(pattern, opts)
+	if err != nil {
+		return false, err
+	}
+
+	// Only strings can match.
+	s, ok := v.(string)
+	if !exists || !ok {
+		return false, nil
+	}
+	return re.MatchString(s), nil
+}
@@ -0,0 +1,27 @@
+package mingodb_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/korrbit/mingodb"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestRegex(t *testing.T) {
+	runFilterTests(t, []filterTest{
+		{"pattern", map[string]interface{}{"name": map[string]interface{}{"$regex": "^Go"}}, []int32{1}},
+		{"case insensitive", map[string]interface{}{"name": map[string]interface{}{"$regex": "^go", "$options": "i"}}, []int32{1, 2}},
+		{"non-string field", map[string]interface{}{"age": map[string]interface{}{"$regex": "1"}}, []int32{}},
+		{"primitive.Regex", map[string]interface{}{"name": map[string]interface{}{"$regex": primitive.Regex{Pattern: "UST", Options: "i"}}}, []int32{3}},
+	})
+}
+
+func TestInvalidRegex(t *testing.T) {
+	c := people(t)
+	_, err := c.Find(context.Background(), map[string]interface{}{"name": map[string]interface{}{"$regex": "("}})
+	if !errors.Is(err, mingodb.ErrInvalidRegex) {
+		t.Errorf("Find returned %v, expected ErrInvalidRegex", err)
+	}
+}
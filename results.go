@@ -1,20 +1,113 @@
 package mingodb
 
+import (
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
 type InsertID interface{}
 
+// SingleResult holds a single document returned by a query.
 type SingleResult struct {
 	data []byte
+	err  error
 }
 
+// Decode unmarshals the document into v, which should be a pointer
+// to a struct or a map. Returns ErrNoDocuments if the query
+// didn't match a document.
+func (r *SingleResult) Decode(v interface{}) error {
+	if r.err != nil {
+		return r.err
+	}
+	return bson.Unmarshal(r.data, v)
+}
+
+// MultiResult is a cursor over the documents returned by a query.
+// Call Next to advance to each document in turn and Decode to
+// unmarshal it.
 type MultiResult struct {
-	//data []byte
-	ResultCount int // Number of returned results
+	data         [][]byte // Raw BSON of each returned document
+	pos          int      // 1-based index of the current document
+	ResultCount  int      // Number of returned results
+	TotalMatched int      // Number of matching documents, ignoring skip and limit
+}
+
+// Next advances the cursor to the next document. Returns false
+// once there are no more documents.
+func (r *MultiResult) Next() bool {
+	if r.pos >= len(r.data) {
+		return false
+	}
+	r.pos++
+	return true
+}
+
+// Decode unmarshals the current document into v, which should be
+// a pointer to a struct or a map. Next must be called before the
+// first call to Decode.
+func (r *MultiResult) Decode(v interface{}) error {
+	if r.pos < 1 || r.pos > len(r.data) {
+		return ErrNoCurrentDocument
+	}
+	return bson.Unmarshal(r.data[r.pos-1], v)
+}
+
+// Close closes the cursor. Results are currently held in memory
+// so this is a no-op, but callers should still defer it.
+func (r *MultiResult) Close() error {
+	return nil
 }
 
 type UpdateResult struct {
-	UpdateCount int // Number of rows updated
+	MatchedCount int // Number of rows matched
+	UpdateCount  int // Number of rows updated
+}
+
+// UpsertResult is the result of an UpsertOne.
+type UpsertResult struct {
+	UpdateResult
+	UpsertedID InsertID // _id of the inserted document, if any
+	Upserted   bool     // Whether a new document was inserted
 }
 
 type DeleteResult struct {
 	DeleteCount int // Number of rows deleted
 }
+
+// BulkWriteResult is the result of a BulkWrite.
+type BulkWriteResult struct {
+	InsertedCount int              // Number of documents inserted
+	MatchedCount  int              // Number of documents matched by updates
+	ModifiedCount int              // Number of documents modified by updates
+	DeletedCount  int              // Number of documents deleted
+	WriteErrors   []BulkWriteError // Errors from the failed operations
+}
+
+// NewSingleResult returns a SingleResult holding doc, such as for a
+// mock of FindOne (see package mock). If doc is nil, Decode returns
+// ErrNoDocuments.
+func NewSingleResult(doc interface{}) (*SingleResult, error) {
+	if doc == nil {
+		return &SingleResult{err: ErrNoDocuments}, nil
+	}
+	data, err := bson.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidDocument, err)
+	}
+	return &SingleResult{data: data}, nil
+}
+
+// NewMultiResult returns a MultiResult over docs, such as for a mock
+// of Find (see package mock).
+func NewMultiResult(docs ...interface{}) (*MultiResult, error) {
+	data := make([][]byte, len(docs))
+	for i, doc := range docs {
+		var err error
+		if data[i], err = bson.Marshal(doc); err != nil {
+			return nil, fmt.Errorf("%w: document %d: %v", ErrInvalidDocument, i, err)
+		}
+	}
+	return &MultiResult{data: data, ResultCount: len(data), TotalMatched: len(data)}, nil
+}
@@ -0,0 +1,102 @@
+package mingodb
+
+import (
+	crand "crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"math/rand"
+)
+
+// sampleShuffleLimit is the number of documents below which $sample
+// shuffles them all, rather than sampling them with a reservoir.
+const sampleShuffleLimit = 100
+
+// sampleStage keeps a random sample of the documents, in random
+// order:
+//
+//	{"$sample": {"size": 5}}
+//
+// If there are no more than size documents, they're all kept.
+func sampleStage(docs []map[string]interface{}, arg interface{}) ([]map[string]interface{}, error) {
+	n, err := sampleSize(arg)
+	if err != nil {
+		return nil, err
+	}
+	r, err := newSampleRand()
+	if err != nil {
+		return nil, err
+	}
+
+	// Are there few enough documents to shuffle them all?
+	if len(docs) < sampleShuffleLimit {
+		r.Shuffle(len(docs), func(i, j int) { docs[i], docs[j] = docs[j], docs[i] })
+		if n < len(docs) {
+			docs = docs[:n]
+		}
+		return docs, nil
+	}
+
+	res := newReservoir(n, r)
+	for _, doc := range docs {
+		res.add(doc)
+	}
+	return res.sample(), nil
+}
+
+// sampleSize returns the size of a $sample stage's argument.
+func sampleSize(arg interface{}) (int, error) {
+	spec, err := stageDocument(arg)
+	if err != nil {
+		return 0, err
+	}
+	n, ok := toInt(spec["size"])
+	if !ok || n <= 0 || len(spec) != 1 {
+		return 0, fmt.Errorf("%w: $sample size must be a positive integer", ErrInvalidPipeline)
+	}
+	return int(n), nil
+}
+
+// newSampleRand returns a random number generator seeded from
+// crypto/rand, so that samples differ between runs.
+func newSampleRand() (*rand.Rand, error) {
+	var seed [8]byte
+	if _, err := crand.Read(seed[:]); err != nil {
+		return nil, err
+	}
+	return rand.New(rand.NewSource(int64(binary.LittleEndian.Uint64(seed[:])))), nil
+}
+
+// reservoir keeps a uniform random sample of the documents added to
+// it, without keeping more than the sample's size in memory.
+type reservoir struct {
+	r    *rand.Rand
+	size int
+	seen int // Number of documents added
+	docs []map[string]interface{}
+}
+
+// newReservoir returns an empty reservoir of the given size.
+func newReservoir(size int, r *rand.Rand) *reservoir {
+	return &reservoir{r: r, size: size}
+}
+
+// add adds a document: it replaces a random document in the sample
+// with a probability of size/seen once the sample is full.
+func (res *reservoir) add(doc map[string]interface{}) {
+	res.seen++
+	if len(res.docs) < res.size {
+		res.docs = append(res.docs, doc)
+		return
+	}
+	if i := res.r.Intn(res.seen); i < res.size {
+		res.docs[i] = doc
+	}
+}
+
+// sample returns the sampled documents in random order. While the
+// sample is filling up, the documents are in the order they were
+// added, so they're shuffled.
+func (res *reservoir) sample() []map[string]interface{} {
+	res.r.Shuffle(len(res.docs), func(i, j int) { res.docs[i], res.docs[j] = res.docs[j], res.docs[i] })
+	return res.docs
+}
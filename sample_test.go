@@ -0,0 +1,100 @@
+package mingodb_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/korrbit/mingodb"
+	"github.com/korrbit/mingodb/testutil"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// numbered returns a collection holding n documents, with _ids 0 to
+// n-1 and even set for the even ones.
+func numbered(t *testing.T, n int) *mingodb.Collection {
+	t.Helper()
+	c := testutil.NewTestDB(t).CollectionMust("items")
+	docs := make([]interface{}, n)
+	for i := range docs {
+		docs[i] = map[string]interface{}{"_id": i, "even": i%2 == 0}
+	}
+	testutil.SeedCollection(t, c, docs...)
+	return c
+}
+
+// sampleIDs runs the pipeline and returns the _ids of the documents,
+// failing the test if any is returned twice.
+func sampleIDs(t *testing.T, c *mingodb.Collection, pipeline mingodb.Pipeline) []int32 {
+	t.Helper()
+	res, err := c.Aggregate(context.Background(), pipeline)
+	if err != nil {
+		t.Fatalf("Aggregate: %v", err)
+	}
+	ids := resultIDs(t, res)
+	seen := make(map[int32]bool)
+	for _, id := range ids {
+		if seen[id] {
+			t.Fatalf("_id %d was sampled twice in %v", id, ids)
+		}
+		seen[id] = true
+	}
+	return ids
+}
+
+func TestSampleStage(t *testing.T) {
+	small, large := numbered(t, 10), numbered(t, 250)
+	evens := mingodb.MatchStage(map[string]interface{}{"even": true})
+	tests := []struct {
+		name     string
+		c        *mingodb.Collection
+		pipeline mingodb.Pipeline
+		expected int
+		even     bool // Whether only even _ids can be sampled
+	}{
+		{"Shuffle", small, mingodb.Pipeline{mingodb.SampleStage(3)}, 3, false},
+		{"MoreThanAll", small, mingodb.Pipeline{mingodb.SampleStage(20)}, 10, false},
+		{"Reservoir", large, mingodb.Pipeline{mingodb.SampleStage(5)}, 5, false},
+		{"ReservoirAfterMatch", large, mingodb.Pipeline{evens, mingodb.SampleStage(5)}, 5, true},
+		{"ReservoirAfterStage", large, mingodb.Pipeline{mingodb.SkipStage(0), mingodb.SampleStage(200)}, 200, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ids := sampleIDs(t, tt.c, tt.pipeline)
+			if len(ids) != tt.expected {
+				t.Errorf("sampled %d documents, expected %d", len(ids), tt.expected)
+			}
+			for _, id := range ids {
+				if tt.even && id%2 != 0 {
+					t.Errorf("sampled _id %d, which doesn't match", id)
+				}
+			}
+		})
+	}
+}
+
+func TestSampleStageIsRandom(t *testing.T) {
+	c := numbered(t, 250)
+	seen := make(map[int32]bool)
+	for i := 0; i < 20; i++ {
+		seen[sampleIDs(t, c, mingodb.Pipeline{mingodb.SampleStage(1)})[0]] = true
+	}
+	if len(seen) == 1 {
+		t.Errorf("20 samples all chose the same document %v", seen)
+	}
+}
+
+func TestSampleStageInvalidSize(t *testing.T) {
+	c := numbered(t, 1)
+	for _, arg := range []interface{}{
+		bson.D{{Key: "size", Value: 0}},
+		bson.D{{Key: "size", Value: "1"}},
+		bson.D{{Key: "size", Value: 1}, {Key: "seed", Value: 1}},
+		1,
+	} {
+		_, err := c.Aggregate(context.Background(), mingodb.Pipeline{{{Key: "$sample", Value: arg}}})
+		if !errors.Is(err, mingodb.ErrInvalidPipeline) {
+			t.Errorf("$sample %v returned %v, expected ErrInvalidPipeline", arg, err)
+		}
+	}
+}
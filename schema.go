@@ -0,0 +1,189 @@
+package mingodb
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	bolt "go.etcd.io/bbolt"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// schemaKey is the key of the schema in a collection's schema bucket.
+var schemaKey = []byte("schema")
+
+// compiledSchema is a collection's JSON Schema, along with the bytes
+// it was compiled from.
+type compiledSchema struct {
+	raw    []byte
+	schema *jsonschema.Schema
+}
+
+// schemas caches the compiled JSON Schemas of collections, so that
+// they're only compiled again when they change.
+var schemas sync.Map // schemaCacheKey -> *compiledSchema
+
+// schemaCacheKey identifies a collection's cached schema.
+type schemaCacheKey struct {
+	db   *bolt.DB
+	name string
+}
+
+// SetJSONSchema sets a JSON Schema (draft-07, unless the schema's
+// $schema says otherwise) that every document stored in the
+// collection must match: inserted documents, replacements and
+// documents after an update has been applied. A write with a document
+// that doesn't match is rolled back and returns ErrSchemaValidation,
+// with the location and constraint that failed. A nil or empty schema
+// removes the collection's schema.
+//
+// The schema is stored in the database, so unlike SetValidator it
+// applies to every Collection with the same name, including after the
+// database is reopened. Returns ErrInvalidSchema if the schema can't
+// be compiled. Documents already in the collection aren't checked.
+//
+// Documents are validated as JSON: ObjectIDs are strings of hex
+// digits and dates are RFC 3339 strings (see format "date-time").
+// VersionField isn't validated.
+func (c *Collection) SetJSONSchema(schema []byte) error {
+	var compiled *jsonschema.Schema
+	if len(schema) > 0 {
+		var err error
+		if compiled, err = compileSchema(c.name, schema); err != nil {
+			return err
+		}
+	}
+
+	return c.write(func(tx *bolt.Tx) error {
+		if _, err := c.bucket(tx); err != nil {
+			return err
+		}
+		if compiled == nil {
+			return dropSchema(tx, c.name)
+		}
+		b, err := tx.CreateBucketIfNotExists([]byte(schemaBucketName(c.name)))
+		if err != nil {
+			return err
+		}
+		if err := b.Put(schemaKey, schema); err != nil {
+			return err
+		}
+		schemas.Store(schemaCacheKey{tx.DB(), c.name}, &compiledSchema{raw: append([]byte(nil), schema...), schema: compiled})
+		return nil
+	})
+}
+
+// compileSchema compiles a collection's JSON Schema.
+func compileSchema(collection string, schema []byte) (*jsonschema.Schema, error) {
+	url := "mingodb:///" + collection + ".json"
+	compiler := jsonschema.NewCompiler()
+	compiler.Draft = jsonschema.Draft7
+	if err := compiler.AddResource(url, bytes.NewReader(schema)); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSchema, err)
+	}
+	s, err := compiler.Compile(url)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSchema, err)
+	}
+	return s, nil
+}
+
+// schemaBucketName returns the name of the bucket that holds a
+// collection's JSON Schema.
+func schemaBucketName(collection string) string {
+	return "__schema_" + collection
+}
+
+// jsonSchema returns the collection's compiled JSON Schema, or nil if
+// it doesn't have one.
+func (c *Collection) jsonSchema(tx *bolt.Tx) (*jsonschema.Schema, error) {
+	b := tx.Bucket([]byte(schemaBucketName(c.name)))
+	if b == nil {
+		return nil, nil
+	}
+	raw := b.Get(schemaKey)
+
+	// Has it already been compiled?
+	key := schemaCacheKey{tx.DB(), c.name}
+	if v, ok := schemas.Load(key); ok {
+		if s := v.(*compiledSchema); bytes.Equal(s.raw, raw) {
+			return s.schema, nil
+		}
+	}
+	s, err := compileSchema(c.name, raw)
+	if err != nil {
+		return nil, err
+	}
+	schemas.Store(key, &compiledSchema{raw: append([]byte(nil), raw...), schema: s})
+	return s, nil
+}
+
+// validateSchema checks that doc matches the schema.
+func validateSchema(s *jsonschema.Schema, doc map[string]interface{}) error {
+	if err := s.Validate(jsonValue(doc)); err != nil {
+		return fmt.Errorf("%w: %v", ErrSchemaValidation, err)
+	}
+	return nil
+}
+
+// jsonValue converts a decoded BSON value into the JSON value that's
+// validated against a schema.
+func jsonValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			m[k] = jsonValue(e)
+		}
+		return m
+	case primitive.A:
+		a := make([]interface{}, len(v))
+		for i, e := range v {
+			a[i] = jsonValue(e)
+		}
+		return a
+	case primitive.ObjectID:
+		return v.Hex()
+	case primitive.DateTime:
+		return v.Time().UTC().Format(time.RFC3339Nano)
+	case nil, bool, string, int32, int64, float64:
+		return v
+	}
+	return fmt.Sprint(v)
+}
+
+// forgetSchemas removes a closed database's schemas from the cache.
+func forgetSchemas(db *bolt.DB) {
+	schemas.Range(func(k, _ interface{}) bool {
+		if k.(schemaCacheKey).db == db {
+			schemas.Delete(k)
+		}
+		return true
+	})
+}
+
+// dropSchema deletes the collection's JSON Schema, if it has one.
+func dropSchema(tx *bolt.Tx, collection string) error {
+	err := tx.DeleteBucket([]byte(schemaBucketName(collection)))
+	if errors.Is(err, bolt.ErrBucketNotFound) {
+		return nil
+	}
+	return err
+}
+
+// copySchema gives the dst collection the src collection's JSON
+// Schema, if it has one.
+func copySchema(tx *bolt.Tx, src, dst string) error {
+	from := tx.Bucket([]byte(schemaBucketName(src)))
+	if from == nil {
+		return nil
+	}
+	to, err := tx.CreateBucketIfNotExists([]byte(schemaBucketName(dst)))
+	if err != nil {
+		return err
+	}
+	return to.Put(schemaKey, from.Get(schemaKey))
+}
@@ -0,0 +1,108 @@
+package mingodb_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/korrbit/mingodb"
+	"github.com/korrbit/mingodb/testutil"
+)
+
+const personSchema = `{
+	"type": "object",
+	"required": ["name"],
+	"properties": {
+		"name": {"type": "string"},
+		"age": {"type": "integer", "minimum": 0}
+	}
+}`
+
+func TestSetJSONSchema(t *testing.T) {
+	ctx := context.Background()
+	c := testutil.NewTestDB(t).CollectionMust("people")
+	if err := c.SetJSONSchema([]byte(personSchema)); err != nil {
+		t.Fatalf("SetJSONSchema: %v", err)
+	}
+	testutil.SeedCollection(t, c, map[string]interface{}{"_id": 1, "name": "Alice", "age": 30})
+
+	tests := []struct {
+		name     string
+		write    func() error
+		mentions string // Part of the error, such as the location that failed
+	}{
+		{"InsertMissingField", func() error {
+			_, err := c.InsertOne(ctx, map[string]interface{}{"_id": 2, "age": 20})
+			return err
+		}, "missing properties: 'name'"},
+		{"InsertWrongType", func() error {
+			_, err := c.InsertOne(ctx, map[string]interface{}{"_id": 2, "name": 2})
+			return err
+		}, "/name"},
+		{"Update", func() error {
+			_, err := c.UpdateOne(ctx, map[string]interface{}{"_id": 1}, map[string]interface{}{"$set": map[string]interface{}{"age": -1}})
+			return err
+		}, "/age"},
+		{"Replace", func() error {
+			_, err := c.ReplaceOne(ctx, map[string]interface{}{"_id": 1}, map[string]interface{}{"age": 31})
+			return err
+		}, "missing properties: 'name'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.write()
+			if !errors.Is(err, mingodb.ErrSchemaValidation) {
+				t.Fatalf("got %v, expected ErrSchemaValidation", err)
+			}
+			if !strings.Contains(err.Error(), tt.mentions) {
+				t.Errorf("error %q doesn't mention %q", err, tt.mentions)
+			}
+		})
+	}
+	testutil.AssertDocumentCount(t, c, nil, 1)
+	testutil.AssertDocumentExists(t, c, map[string]interface{}{"_id": 1, "name": "Alice", "age": 30})
+
+	// An empty schema removes it.
+	if err := c.SetJSONSchema(nil); err != nil {
+		t.Fatalf("SetJSONSchema: %v", err)
+	}
+	if _, err := c.InsertOne(ctx, map[string]interface{}{"_id": 2}); err != nil {
+		t.Errorf("InsertOne: %v", err)
+	}
+}
+
+func TestSetJSONSchemaInvalid(t *testing.T) {
+	c := testutil.NewTestDB(t).CollectionMust("people")
+	if err := c.SetJSONSchema([]byte(`{"type": 1}`)); !errors.Is(err, mingodb.ErrInvalidSchema) {
+		t.Errorf("got %v, expected ErrInvalidSchema", err)
+	}
+	if err := c.SetJSONSchema([]byte(`{`)); !errors.Is(err, mingodb.ErrInvalidSchema) {
+		t.Errorf("got %v, expected ErrInvalidSchema", err)
+	}
+}
+
+func TestSetJSONSchemaPersists(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "test.db")
+	db, err := mingodb.Open(path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if err := db.CollectionMust("people").SetJSONSchema([]byte(personSchema)); err != nil {
+		t.Fatalf("SetJSONSchema: %v", err)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	db, err = mingodb.Open(path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer db.Close()
+	if _, err := db.CollectionMust("people").InsertOne(ctx, map[string]interface{}{"age": 1}); !errors.Is(err, mingodb.ErrSchemaValidation) {
+		t.Errorf("got %v, expected ErrSchemaValidation after reopening", err)
+	}
+}
@@ -0,0 +1,107 @@
+package mingodb
+
+import (
+	"context"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Snapshot is a read-only view of the database at the moment it was
+// started. Every read through a Snapshot sees the same data, whatever
+// is written to the database in the meantime.
+//
+// A Snapshot holds a read transaction open until it's closed. While it's
+// open the database file can't be resized, so writes that need to grow
+// the file block until the snapshot is closed (see
+// OpenOptions.InitialMmapSize). Keep snapshots short,
+// and don't write to the database from a goroutine that has a snapshot
+// open, as it may block forever.
+type Snapshot struct {
+	ctx  context.Context
+	db   *Database
+	tx   *bolt.Tx
+	done bool // Whether the snapshot has been closed
+}
+
+// SnapshotCollection is a collection that reads through a Snapshot.
+type SnapshotCollection struct {
+	c *Collection
+}
+
+// BeginSnapshot starts a Snapshot. It must be closed with Close.
+// Once ctx is cancelled, reads through the snapshot fail.
+func (db *Database) BeginSnapshot(ctx context.Context) (*Snapshot, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	tx, err := db.db.Begin(false)
+	if err != nil {
+		return nil, boltError(err)
+	}
+	return &Snapshot{ctx: ctx, db: db, tx: tx}, nil
+}
+
+// Collection returns the collection with the specified name within
+// the snapshot. If the collection doesn't exist, its methods return
+// ErrCollectionNotFound. The returned collection has the settings of
+// the collection's handle (see Database.Collection), so documents
+// deleted with soft delete stay hidden.
+func (s *Snapshot) Collection(name string) *SnapshotCollection {
+	c := *s.db.handle(name)
+	c.txn = s
+	return &SnapshotCollection{&c}
+}
+
+// Close releases the snapshot.
+func (s *Snapshot) Close() error {
+	if s.done {
+		return ErrTxDone
+	}
+	s.done = true
+	return boltError(s.tx.Rollback())
+}
+
+// run runs fn in the snapshot's transaction.
+func (s *Snapshot) run(fn func(tx *bolt.Tx) error, write bool) error {
+	if s.done {
+		return ErrTxDone
+	}
+	if err := s.ctx.Err(); err != nil {
+		return err
+	}
+	return boltError(fn(s.tx))
+}
+
+// Name returns the name of the collection.
+func (sc *SnapshotCollection) Name() string {
+	return sc.c.Name()
+}
+
+// Find returns the documents that match the filter. See Collection.Find.
+func (sc *SnapshotCollection) Find(ctx context.Context, filter interface{}, opts ...FindOptions) (*MultiResult, error) {
+	return sc.c.Find(ctx, filter, opts...)
+}
+
+// FindOne returns the first document that matches the filter. See
+// Collection.FindOne.
+func (sc *SnapshotCollection) FindOne(ctx context.Context, filter interface{}, opts ...FindOptions) (*SingleResult, error) {
+	return sc.c.FindOne(ctx, filter, opts...)
+}
+
+// GetByID returns the document with the given _id. See
+// Collection.GetByID.
+func (sc *SnapshotCollection) GetByID(ctx context.Context, id interface{}) (interface{}, error) {
+	return sc.c.GetByID(ctx, id)
+}
+
+// CountDocuments returns the number of documents that match the
+// filter. See Collection.CountDocuments.
+func (sc *SnapshotCollection) CountDocuments(ctx context.Context, filter interface{}) (int, error) {
+	return sc.c.CountDocuments(ctx, filter)
+}
+
+// Distinct returns the distinct values of a field. See
+// Collection.Distinct.
+func (sc *SnapshotCollection) Distinct(ctx context.Context, field string, filter interface{}) ([]interface{}, error) {
+	return sc.c.Distinct(ctx, field, filter)
+}
@@ -0,0 +1,69 @@
+package mingodb_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/korrbit/mingodb/testutil"
+)
+
+func TestSnapshotIgnoresLaterWrites(t *testing.T) {
+	ctx := context.Background()
+	db := testutil.NewTestDB(t)
+	c := db.CollectionMust("items")
+	testutil.SeedCollection(t, c, map[string]interface{}{"_id": 1})
+
+	s, err := db.BeginSnapshot(ctx)
+	if err != nil {
+		t.Fatalf("BeginSnapshot: %v", err)
+	}
+
+	// The write may need to grow the file, which waits for the
+	// snapshot to close.
+	inserted := make(chan error)
+	go func() {
+		_, err := c.InsertOne(ctx, map[string]interface{}{"_id": 2})
+		inserted <- err
+	}()
+
+	n, err := s.Collection("items").CountDocuments(ctx, nil)
+	if err != nil {
+		t.Errorf("CountDocuments: %v", err)
+	} else if n != 1 {
+		t.Errorf("counted %d documents in the snapshot, expected 1", n)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if err := <-inserted; err != nil {
+		t.Fatalf("InsertOne: %v", err)
+	}
+	testutil.AssertDocumentCount(t, c, nil, 2)
+}
+
+func TestSnapshotKeepsCollectionSettings(t *testing.T) {
+	ctx := context.Background()
+	db := testutil.NewTestDB(t)
+	c := db.CollectionMust("items")
+	c.EnableSoftDelete("deletedAt")
+	testutil.SeedCollection(t, c,
+		map[string]interface{}{"_id": 1},
+		map[string]interface{}{"_id": 2},
+	)
+	if _, err := c.DeleteOne(ctx, 1); err != nil {
+		t.Fatalf("DeleteOne: %v", err)
+	}
+
+	s, err := db.BeginSnapshot(ctx)
+	if err != nil {
+		t.Fatalf("BeginSnapshot: %v", err)
+	}
+	defer s.Close()
+	res, err := s.Collection("items").Find(ctx, nil)
+	if err != nil {
+		t.Fatalf("Find: %v", err)
+	}
+	if res.ResultCount != 1 {
+		t.Errorf("found %d documents, expected 1", res.ResultCount)
+	}
+}
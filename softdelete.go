@@ -0,0 +1,171 @@
+package mingodb
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// EnableSoftDelete makes DeleteOne, DeleteMany and FindOneAndDelete
+// set deletedAtField to the current time on the matching documents
+// rather than removing them. Every other read and write, such as Find,
+// CountDocuments, GetByID, Distinct, Aggregate, $lookup stages that
+// join the collection, MapReduce and the updates, then ignores
+// documents whose deletedAtField is set to anything other than null,
+// and deleting them again has no effect.
+//
+// Soft deletes are reported like any other delete: to middleware as
+// the delete operation, to watchers as "delete" events (see Watch) and,
+// with versioning enabled, in the history as "delete".
+//
+// Use FindWithDeleted to find soft-deleted documents and HardDelete
+// to remove documents. Like Use, soft delete applies wherever the
+// collection is used, and EnableSoftDelete should be called before the
+// collection is used by multiple goroutines.
+func (c *Collection) EnableSoftDelete(deletedAtField string) {
+	c.softDelete = deletedAtField
+}
+
+// FindWithDeleted is like Find, but also returns soft-deleted
+// documents (see EnableSoftDelete).
+func (c *Collection) FindWithDeleted(ctx context.Context, filter interface{}, opts ...FindOptions) (_ *MultiResult, err error) {
+	ctx, end := c.startOperation(ctx, "findWithDeleted", filter, nil)
+	defer func() { end(err) }()
+	f, err := parseFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	docs, total, err := c.find(ctx, f, mergeFindOptions(opts), true)
+	if err != nil {
+		return nil, err
+	}
+	return &MultiResult{ResultCount: len(docs), TotalMatched: total, data: docs}, nil
+}
+
+// HardDelete removes every document that matches the filter, whether
+// or not it has been soft-deleted. The filter follows the same rules
+// as DeleteOne.
+//
+// All documents are deleted in a single transaction.
+func (c *Collection) HardDelete(ctx context.Context, filter interface{}) (_ *DeleteResult, err error) {
+	ctx, end := c.startOperation(ctx, "hardDelete", filter, nil)
+	defer func() { end(err) }()
+	return c.delete(ctx, filter, true, true)
+}
+
+// markDeleted soft-deletes the first document that matches the
+// filter or, if many is true, every document that matches.
+func (c *Collection) markDeleted(ctx context.Context, filter interface{}, many bool) (*DeleteResult, error) {
+	if isIDFilter(filter) {
+		filter = map[string]interface{}{"_id": filter}
+	}
+	f, err := parseFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	res := &DeleteResult{}
+	err = c.write(func(tx *bolt.Tx) error {
+		w, err := c.writeTx(ctx, tx)
+		if err != nil {
+			return err
+		}
+
+		// Find the matching documents. The bucket can't be
+		// modified during the scan so hold on to them.
+		var matches []match
+		err = scanMatches(ctx, w.b, c.visible(f), func(k, v []byte, doc map[string]interface{}) (bool, error) {
+			matches = append(matches, match{key: append([]byte(nil), k...), doc: doc})
+			return many, nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, m := range matches {
+			if err := w.markDeleted(m.key, m.doc); err != nil {
+				return err
+			}
+			res.DeleteCount++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// markDeleted soft-deletes doc, the decoded document stored under key.
+// It's written as a "delete", so watchers receive a delete event and,
+// if versioning is enabled, the history records a delete.
+func (w *writeTx) markDeleted(key []byte, doc map[string]interface{}) error {
+	u, err := w.c.deletedUpdate()
+	if err != nil {
+		return err
+	}
+	if err := applyUpdate(doc, u); err != nil {
+		return err
+	}
+	data, err := bson.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	_, err = w.change("delete", key, data)
+	return err
+}
+
+// deletedUpdate returns the parsed update that soft-deletes a
+// document.
+func (c *Collection) deletedUpdate() (map[string]interface{}, error) {
+	return parseUpdate(map[string]interface{}{
+		"$set": map[string]interface{}{c.softDelete: time.Now()},
+	})
+}
+
+// visible adds a condition to the parsed filter that excludes
+// soft-deleted documents, if soft delete is enabled. The filter's
+// other conditions are kept as they are, so that they can still be
+// matched through an index.
+func (c *Collection) visible(f map[string]interface{}) map[string]interface{} {
+	if c.softDelete == "" {
+		return f
+	}
+	notDeleted := map[string]interface{}{"$or": primitive.A{
+		map[string]interface{}{c.softDelete: map[string]interface{}{"$exists": false}},
+		map[string]interface{}{c.softDelete: nil},
+	}}
+
+	v := make(map[string]interface{}, len(f)+1)
+	for k, cond := range f {
+		v[k] = cond
+	}
+	and, ok := f["$and"].(primitive.A)
+	if _, exists := f["$and"]; exists && !ok {
+		// Leave an invalid $and for the filter to report.
+		return map[string]interface{}{"$and": primitive.A{f, notDeleted}}
+	}
+	v["$and"] = append(append(primitive.A(nil), and...), notDeleted)
+	return v
+}
+
+// deleted reports whether the decoded document has been soft-deleted.
+func (c *Collection) deleted(doc map[string]interface{}) bool {
+	if c.softDelete == "" {
+		return false
+	}
+	v, ok := doc[c.softDelete]
+	if !ok && strings.Contains(c.softDelete, ".") {
+		v, _ = lookupPath(doc, c.softDelete)
+	}
+	return v != nil
+}
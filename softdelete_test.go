@@ -0,0 +1,222 @@
+package mingodb_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/korrbit/mingodb"
+	"github.com/korrbit/mingodb/testutil"
+)
+
+// softDeleted returns a collection with soft delete enabled holding
+// the documents with _ids 1 to 3, of which 2 has been deleted.
+func softDeleted(t *testing.T) *mingodb.Collection {
+	t.Helper()
+	db := testutil.NewTestDB(t)
+	c := db.CollectionMust("items")
+	c.EnableSoftDelete("deletedAt")
+	testutil.SeedCollection(t, c,
+		map[string]interface{}{"_id": 1, "group": "a"},
+		map[string]interface{}{"_id": 2, "group": "a"},
+		map[string]interface{}{"_id": 3, "group": "b"},
+	)
+	if _, err := c.DeleteOne(context.Background(), 2); err != nil {
+		t.Fatalf("DeleteOne: %v", err)
+	}
+	return c
+}
+
+// assertWithDeleted reports an error unless the collection holds
+// expected documents, including deleted ones.
+func assertWithDeleted(t *testing.T, c *mingodb.Collection, expected int) {
+	t.Helper()
+	res, err := c.FindWithDeleted(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("FindWithDeleted: %v", err)
+	}
+	if res.ResultCount != expected {
+		t.Errorf("FindWithDeleted returned %d documents, expected %d", res.ResultCount, expected)
+	}
+}
+
+func TestSoftDelete(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name string
+		run  func(t *testing.T, c *mingodb.Collection)
+	}{
+		{"Find", func(t *testing.T, c *mingodb.Collection) {
+			res, err := c.Find(ctx, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.ResultCount != 2 {
+				t.Errorf("got %d documents, expected 2", res.ResultCount)
+			}
+		}},
+		{"FindOne", func(t *testing.T, c *mingodb.Collection) {
+			res, err := c.FindOne(ctx, map[string]interface{}{"_id": 2})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := res.Decode(&map[string]interface{}{}); !errors.Is(err, mingodb.ErrNoDocuments) {
+				t.Errorf("got %v, expected ErrNoDocuments", err)
+			}
+		}},
+		{"GetByID", func(t *testing.T, c *mingodb.Collection) {
+			if _, err := c.GetByID(ctx, 2); !errors.Is(err, mingodb.ErrNoDocuments) {
+				t.Errorf("got %v, expected ErrNoDocuments", err)
+			}
+		}},
+		{"CountDocuments", func(t *testing.T, c *mingodb.Collection) {
+			testutil.AssertDocumentCount(t, c, map[string]interface{}{"group": "a"}, 1)
+		}},
+		{"Distinct", func(t *testing.T, c *mingodb.Collection) {
+			values, err := c.Distinct(ctx, "_id", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(values) != 2 {
+				t.Errorf("got %v, expected 2 values", values)
+			}
+		}},
+		{"DeleteAgain", func(t *testing.T, c *mingodb.Collection) {
+			res, err := c.DeleteOne(ctx, 2)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.DeleteCount != 0 {
+				t.Errorf("deleted %d documents, expected 0", res.DeleteCount)
+			}
+		}},
+		{"FindOneAndDelete", func(t *testing.T, c *mingodb.Collection) {
+			res, err := c.FindOneAndDelete(ctx, map[string]interface{}{"_id": 1})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := res.Decode(&map[string]interface{}{}); err != nil {
+				t.Fatal(err)
+			}
+			testutil.AssertDocumentCount(t, c, nil, 1)
+			assertWithDeleted(t, c, 3)
+		}},
+		{"UpdateOne", func(t *testing.T, c *mingodb.Collection) {
+			res, err := c.UpdateOne(ctx, map[string]interface{}{"_id": 2}, map[string]interface{}{"$set": map[string]interface{}{"x": 1}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.MatchedCount != 0 {
+				t.Errorf("matched %d documents, expected 0", res.MatchedCount)
+			}
+		}},
+		{"DeleteMany", func(t *testing.T, c *mingodb.Collection) {
+			res, err := c.DeleteMany(ctx, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.DeleteCount != 2 {
+				t.Errorf("deleted %d documents, expected 2", res.DeleteCount)
+			}
+			testutil.AssertDocumentCount(t, c, nil, 0)
+			assertWithDeleted(t, c, 3)
+		}},
+		{"HardDelete", func(t *testing.T, c *mingodb.Collection) {
+			res, err := c.HardDelete(ctx, map[string]interface{}{"_id": 2})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.DeleteCount != 1 {
+				t.Errorf("deleted %d documents, expected 1", res.DeleteCount)
+			}
+			assertWithDeleted(t, c, 2)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.run(t, softDeleted(t))
+		})
+	}
+}
+
+func TestSoftDeletePublishesDeleteEvents(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	db := testutil.NewTestDB(t)
+	c := db.CollectionMust("items")
+	c.EnableSoftDelete("deletedAt")
+	testutil.SeedCollection(t, c,
+		map[string]interface{}{"_id": 1},
+		map[string]interface{}{"_id": 2},
+	)
+	events, err := c.Watch(ctx, nil)
+	if err != nil {
+		t.Fatalf("Watch: %v", err)
+	}
+
+	if _, err := c.DeleteOne(ctx, 1); err != nil {
+		t.Fatalf("DeleteOne: %v", err)
+	}
+	if _, err := c.FindOneAndDelete(ctx, map[string]interface{}{"_id": 2}); err != nil {
+		t.Fatalf("FindOneAndDelete: %v", err)
+	}
+	for _, id := range []int32{1, 2} {
+		select {
+		case e := <-events:
+			if e.OperationType != "delete" || e.DocumentKey != id || e.FullDocument != nil {
+				t.Errorf("got %+v, expected a delete of _id %d", e, id)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("no event for _id %d", id)
+		}
+	}
+}
+
+func TestSoftDeleteSetsDeletedAt(t *testing.T) {
+	start := time.Now().Truncate(time.Millisecond)
+	c := softDeleted(t)
+	res, err := c.FindWithDeleted(context.Background(), map[string]interface{}{"_id": 2})
+	if err != nil {
+		t.Fatalf("FindWithDeleted: %v", err)
+	}
+	var doc struct {
+		DeletedAt time.Time `bson:"deletedAt"`
+	}
+	if !res.Next() {
+		t.Fatal("FindWithDeleted didn't return the deleted document")
+	}
+	if err := res.Decode(&doc); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if doc.DeletedAt.Before(start) || doc.DeletedAt.After(time.Now()) {
+		t.Errorf("got deletedAt %v, expected the time of the delete", doc.DeletedAt)
+	}
+}
+
+func TestSoftDeleteLookup(t *testing.T) {
+	c := softDeleted(t)
+	refs := c.Database().CollectionMust("refs")
+	testutil.SeedCollection(t, refs,
+		map[string]interface{}{"_id": 1, "item": 1},
+		map[string]interface{}{"_id": 2, "item": 2},
+	)
+	res, err := refs.Aggregate(context.Background(), mingodb.Pipeline{mingodb.LookupStage("items", "item", "_id", "items")})
+	if err != nil {
+		t.Fatalf("Aggregate: %v", err)
+	}
+	joined := map[int32]int{}
+	for res.Next() {
+		var doc struct {
+			ID    int32                    `bson:"_id"`
+			Items []map[string]interface{} `bson:"items"`
+		}
+		if err := res.Decode(&doc); err != nil {
+			t.Fatalf("Decode: %v", err)
+		}
+		joined[doc.ID] = len(doc.Items)
+	}
+	if joined[1] != 1 || joined[2] != 0 {
+		t.Errorf("joined %v items to each document, expected 1 to _id 1 and none to the deleted _id 2", joined)
+	}
+}
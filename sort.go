@@ -0,0 +1,52 @@
+package mingodb
+
+import "sort"
+
+// match is a document that matched a query.
+type match struct {
+	key  []byte                 // Key the document is stored under
+	data []byte                 // Raw BSON
+	doc  map[string]interface{} // Decoded document
+}
+
+// sortMatches sorts the matches by the given fields. Earlier
+// fields take precedence over later ones. Documents that are
+// missing a sort field are placed after those that have it,
+// regardless of the sort direction.
+func sortMatches(matches []match, fields []SortField) {
+	if len(fields) == 0 {
+		return
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return compareDocuments(matches[i].doc, matches[j].doc, fields) < 0
+	})
+}
+
+// compareDocuments compares two documents by the given fields.
+// Returns -1 if a sorts before b, 1 if a sorts after b and 0
+// if they are equal.
+func compareDocuments(a, b map[string]interface{}, fields []SortField) int {
+	for _, f := range fields {
+		av, aok := a[f.Field]
+		bv, bok := b[f.Field]
+
+		// Missing fields always sort last.
+		switch {
+		case !aok && !bok:
+			continue
+		case !aok:
+			return 1
+		case !bok:
+			return -1
+		}
+
+		c := compareValues(av, bv)
+		if f.Dir < 0 {
+			c = -c
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return 0
+}
@@ -0,0 +1,41 @@
+package mingodb_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/korrbit/mingodb"
+	"github.com/korrbit/mingodb/testutil"
+)
+
+func TestSort(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	c := db.CollectionMust("items")
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	testutil.SeedCollection(t, c,
+		map[string]interface{}{"_id": 1, "group": "b", "n": 2.5, "at": day.Add(time.Hour)},
+		map[string]interface{}{"_id": 2, "group": "a", "n": 3, "at": day},
+		map[string]interface{}{"_id": 3, "group": "b", "n": 1, "at": day.Add(2 * time.Hour)},
+		map[string]interface{}{"_id": 4, "group": "a"},
+	)
+
+	tests := []struct {
+		name     string
+		sort     []mingodb.SortField
+		expected []int32
+	}{
+		{"mixed numbers", []mingodb.SortField{{Field: "n", Dir: 1}}, []int32{3, 1, 2, 4}},
+		{"descending", []mingodb.SortField{{Field: "n", Dir: -1}}, []int32{2, 1, 3, 4}},
+		{"times", []mingodb.SortField{{Field: "at", Dir: 1}}, []int32{2, 1, 3, 4}},
+		{"several fields", []mingodb.SortField{{Field: "group", Dir: 1}, {Field: "n", Dir: -1}}, []int32{2, 4, 1, 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := findIDs(t, c, nil, mingodb.FindOptions{Sort: tt.sort})
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("got %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package mingodb
+
+import (
+	"sort"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// MatchStage returns a $match stage, which keeps the documents that
+// match the filter. The filter follows the same rules as Find.
+func MatchStage(filter interface{}) bson.D {
+	return bson.D{{Key: "$match", Value: filter}}
+}
+
+// SortStage returns a $sort stage, which sorts the documents by the
+// fields, with 1 for ascending order or -1 for descending order. As a
+// map has no order, multiple fields are sorted by in the order of
+// their names; use PipelineBuilder.Sort to choose the order.
+func SortStage(sort map[string]int) bson.D {
+	return bson.D{{Key: "$sort", Value: sortedFields(sort)}}
+}
+
+// sortedFields returns the map's fields as a bson.D, in the order of
+// their names.
+func sortedFields(m map[string]int) bson.D {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	d := make(bson.D, len(names))
+	for i, name := range names {
+		d[i] = bson.E{Key: name, Value: m[name]}
+	}
+	return d
+}
+
+// LimitStage returns a $limit stage, which keeps at most n documents.
+func LimitStage(n int) bson.D {
+	return bson.D{{Key: "$limit", Value: n}}
+}
+
+// SkipStage returns a $skip stage, which skips the first n documents.
+func SkipStage(n int) bson.D {
+	return bson.D{{Key: "$skip", Value: n}}
+}
+
+// SampleStage returns a $sample stage, which keeps n documents chosen
+// at random, in random order.
+func SampleStage(n int) bson.D {
+	return bson.D{{Key: "$sample", Value: bson.D{{Key: "size", Value: n}}}}
+}
+
+// CountStage returns a $count stage, which replaces the documents with
+// a single document whose field is the number of documents, or with no
+// document if there are none.
+func CountStage(field string) bson.D {
+	return bson.D{{Key: "$count", Value: field}}
+}
+
+// ProjectStage returns a $project stage, which selects the fields of
+// each document. The projection follows the same rules as
+// FindOptions.Projection, and can also compute fields (see
+// PipelineBuilder.Project).
+func ProjectStage(proj map[string]interface{}) bson.D {
+	return bson.D{{Key: "$project", Value: proj}}
+}
+
+// AddFieldsStage returns an $addFields stage, which adds the fields
+// computed with expressions to each document, keeping its other fields.
+func AddFieldsStage(fields map[string]interface{}) bson.D {
+	return bson.D{{Key: "$addFields", Value: fields}}
+}
+
+// ReplaceRootStage returns a $replaceRoot stage, which replaces each
+// document with the document that newRoot evaluates to, such as
+// "$address".
+func ReplaceRootStage(newRoot interface{}) bson.D {
+	return bson.D{{Key: "$replaceRoot", Value: bson.D{{Key: "newRoot", Value: newRoot}}}}
+}
+
+// GroupStage returns a $group stage, which groups the documents by the
+// id expression, such as "$region" or nil, and computes each of the
+// accumulators for every group, such as {"total": {"$sum": "$amount"}}.
+func GroupStage(id interface{}, accumulators map[string]interface{}) bson.D {
+	group := make(map[string]interface{}, len(accumulators)+1)
+	for k, v := range accumulators {
+		group[k] = v
+	}
+	group["_id"] = id
+	return bson.D{{Key: "$group", Value: group}}
+}
+
+// BucketStage returns a $bucket stage, which groups the documents into
+// buckets by the value of groupBy, such as "$price". Each bucket holds
+// the values from one boundary, inclusive, up to the next, exclusive,
+// so the boundaries must be sorted in ascending order.
+func BucketStage(groupBy interface{}, boundaries []interface{}, opts ...BucketOptions) bson.D {
+	o := mergeBucketOptions(opts)
+	bucket := bson.D{
+		{Key: "groupBy", Value: groupBy},
+		{Key: "boundaries", Value: boundaries},
+	}
+	if o.Default != nil {
+		bucket = append(bucket, bson.E{Key: "default", Value: o.Default})
+	}
+	if o.Output != nil {
+		bucket = append(bucket, bson.E{Key: "output", Value: o.Output})
+	}
+	return bson.D{{Key: "$bucket", Value: bucket}}
+}
+
+// FacetStage returns a $facet stage, which runs each of the pipelines
+// against the documents and outputs a single document with their
+// results under their names.
+func FacetStage(facets map[string]Pipeline) bson.D {
+	return bson.D{{Key: "$facet", Value: facets}}
+}
+
+// LookupStage returns a $lookup stage, which adds the documents in the
+// from collection whose foreignField equals the document's localField
+// to each document, as an array under the as field.
+func LookupStage(from, localField, foreignField, as string) bson.D {
+	return bson.D{{Key: "$lookup", Value: bson.D{
+		{Key: "from", Value: from},
+		{Key: "localField", Value: localField},
+		{Key: "foreignField", Value: foreignField},
+		{Key: "as", Value: as},
+	}}}
+}
+
+// UnwindStage returns an $unwind stage, which outputs a copy of each
+// document for every element of the array at path, such as "$tags".
+func UnwindStage(path string, opts ...UnwindOptions) bson.D {
+	o := mergeUnwindOptions(opts)
+	if !o.PreserveNullAndEmptyArrays {
+		return bson.D{{Key: "$unwind", Value: path}}
+	}
+	return bson.D{{Key: "$unwind", Value: bson.D{
+		{Key: "path", Value: path},
+		{Key: "preserveNullAndEmptyArrays", Value: true},
+	}}}
+}
@@ -0,0 +1,61 @@
+package mingodb_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/korrbit/mingodb"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestStages(t *testing.T) {
+	tests := []struct {
+		name     string
+		stage    bson.D
+		expected bson.D
+	}{
+		{"Match", mingodb.MatchStage(doc{"a": 1}), bson.D{{Key: "$match", Value: doc{"a": 1}}}},
+		{"Sort", mingodb.SortStage(map[string]int{"b": -1, "a": 1}), bson.D{{Key: "$sort", Value: bson.D{
+			{Key: "a", Value: 1},
+			{Key: "b", Value: -1},
+		}}}},
+		{"Limit", mingodb.LimitStage(5), bson.D{{Key: "$limit", Value: 5}}},
+		{"Skip", mingodb.SkipStage(5), bson.D{{Key: "$skip", Value: 5}}},
+		{"Project", mingodb.ProjectStage(doc{"a": 1}), bson.D{{Key: "$project", Value: doc{"a": 1}}}},
+		{"Group", mingodb.GroupStage("$city", doc{"n": doc{"$sum": 1}}), bson.D{{Key: "$group", Value: doc{
+			"_id": "$city",
+			"n":   doc{"$sum": 1},
+		}}}},
+		{"Lookup", mingodb.LookupStage("orders", "_id", "userId", "orders"), bson.D{{Key: "$lookup", Value: bson.D{
+			{Key: "from", Value: "orders"},
+			{Key: "localField", Value: "_id"},
+			{Key: "foreignField", Value: "userId"},
+			{Key: "as", Value: "orders"},
+		}}}},
+		{"Unwind", mingodb.UnwindStage("$tags"), bson.D{{Key: "$unwind", Value: "$tags"}}},
+		{"UnwindPreserve", mingodb.UnwindStage("$tags", mingodb.UnwindOptions{PreserveNullAndEmptyArrays: true}), bson.D{{Key: "$unwind", Value: bson.D{
+			{Key: "path", Value: "$tags"},
+			{Key: "preserveNullAndEmptyArrays", Value: true},
+		}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !reflect.DeepEqual(tt.stage, tt.expected) {
+				t.Errorf("got %v, expected %v", tt.stage, tt.expected)
+			}
+		})
+	}
+}
+
+func TestStagesAggregate(t *testing.T) {
+	c := people(t)
+	docs := aggregate(t, c, mingodb.Pipeline{
+		mingodb.GroupStage("$city", doc{"total": doc{"$sum": "$age"}}),
+		mingodb.SortStage(map[string]int{"total": -1}),
+		mingodb.SkipStage(0),
+		mingodb.LimitStage(1),
+	})
+	if expected := []map[string]interface{}{{"_id": "Paris", "total": int32(65)}}; !reflect.DeepEqual(docs, expected) {
+		t.Errorf("got %v, expected %v", docs, expected)
+	}
+}
@@ -0,0 +1,123 @@
+package mingodb
+
+import (
+	"context"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// CollectionStats describes the size of a collection. It's returned
+// by Collection.Stats.
+type CollectionStats struct {
+	DocumentCount    int  // Number of documents
+	StorageSizeBytes int  // Bytes allocated to the documents
+	IndexCount       int  // Number of indexes
+	IndexSizeBytes   int  // Bytes allocated to the indexes' entries
+	IsCapped         bool // Whether the collection is capped (see SetCap)
+	Cap              int  // Maximum number of documents, if capped
+}
+
+// Stats returns the size of the collection and its indexes, which is
+// read from the database's page statistics rather than by iterating
+// over the documents.
+//
+// Sizes are the bytes allocated to the collection's pages, which
+// may be more than its documents use. Within a Transaction, sizes
+// don't include the transaction's pending changes.
+func (c *Collection) Stats(ctx context.Context) (*CollectionStats, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	stats := &CollectionStats{}
+	err := c.read(func(tx *bolt.Tx) error {
+		b, err := c.bucket(tx)
+		if err != nil {
+			return err
+		}
+		stats.DocumentCount = countKeys(tx, b)
+		stats.StorageSizeBytes = bucketSize(b.Stats())
+		stats.Cap = collectionCap(tx, c.name)
+		stats.IsCapped = stats.Cap > 0
+
+		indexes, err := c.indexes(tx)
+		if err != nil {
+			return err
+		}
+		stats.IndexCount = len(indexes)
+		for _, idx := range indexes {
+			stats.IndexSizeBytes += bucketSize(idx.b.Stats())
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// countKeys returns the number of keys in b. The bucket's stats
+// don't include a Transaction's pending changes, so the keys are
+// counted instead in a read-write transaction.
+func countKeys(tx *bolt.Tx, b *bolt.Bucket) int {
+	if !tx.Writable() {
+		return b.Stats().KeyN
+	}
+	var n int
+	cur := b.Cursor()
+	for k, _ := cur.First(); k != nil; k, _ = cur.Next() {
+		n++
+	}
+	return n
+}
+
+// bucketSize returns the bytes allocated to a bucket. A small bucket
+// is stored inline in its parent's page rather than in its own pages,
+// so the bytes it uses there are counted instead.
+func bucketSize(s bolt.BucketStats) int {
+	return s.LeafAlloc + s.BranchAlloc + s.InlineBucketInuse
+}
+
+// DatabaseStats describes the size of a database. It's returned by
+// Database.Stats.
+type DatabaseStats struct {
+	CollectionCount  int // Number of collections
+	DocumentCount    int // Number of documents in every collection
+	StorageSizeBytes int // Bytes allocated to the documents
+	IndexCount       int // Number of indexes
+	IndexSizeBytes   int // Bytes allocated to the indexes' entries
+	FileSizeBytes    int // Size of the database file
+}
+
+// Stats returns the size of the database's collections and indexes,
+// which, like Collection.Stats, is read from the database's page
+// statistics.
+func (db *Database) Stats() (*DatabaseStats, error) {
+	stats := &DatabaseStats{}
+	err := db.view(func(tx *bolt.Tx) error {
+		stats.FileSizeBytes = int(tx.Size())
+		return tx.ForEach(func(name []byte, b *bolt.Bucket) error {
+			if isInternalBucket(string(name)) {
+				return nil
+			}
+			s := b.Stats()
+			stats.CollectionCount++
+			stats.DocumentCount += s.KeyN
+			stats.StorageSizeBytes += bucketSize(s)
+
+			indexes, err := loadIndexes(tx, string(name))
+			if err != nil {
+				return err
+			}
+			stats.IndexCount += len(indexes)
+			for _, idx := range indexes {
+				stats.IndexSizeBytes += bucketSize(idx.b.Stats())
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
@@ -0,0 +1,60 @@
+package mingodb_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/korrbit/mingodb/testutil"
+)
+
+func TestStats(t *testing.T) {
+	ctx := context.Background()
+	c := testutil.NewTestDB(t).CollectionMust("items")
+	empty, err := c.Stats(ctx)
+	if err != nil {
+		t.Fatalf("Stats: %v", err)
+	}
+	if empty.DocumentCount != 0 || empty.IndexCount != 0 || empty.IsCapped {
+		t.Errorf("got %+v for an empty collection", empty)
+	}
+
+	if _, err := c.CreateIndex(ctx, "n"); err != nil {
+		t.Fatalf("CreateIndex: %v", err)
+	}
+	docs := make([]interface{}, 100)
+	for i := range docs {
+		docs[i] = map[string]interface{}{"n": i}
+	}
+	testutil.SeedCollection(t, c, docs...)
+
+	stats, err := c.Stats(ctx)
+	if err != nil {
+		t.Fatalf("Stats: %v", err)
+	}
+	if stats.DocumentCount != 100 || stats.IndexCount != 1 {
+		t.Errorf("got %d documents and %d indexes, expected 100 and 1", stats.DocumentCount, stats.IndexCount)
+	}
+	if stats.StorageSizeBytes <= empty.StorageSizeBytes || stats.IndexSizeBytes == 0 {
+		t.Errorf("got %+v, expected the documents and index to take up space", stats)
+	}
+
+	// Within a transaction, pending documents are counted.
+	tx, err := c.Database().BeginTx(ctx)
+	if err != nil {
+		t.Fatalf("BeginTx: %v", err)
+	}
+	defer tx.Rollback()
+	txc, err := tx.Collection("items")
+	if err != nil {
+		t.Fatalf("Collection: %v", err)
+	}
+	if _, err := txc.InsertOne(ctx, map[string]interface{}{"n": 100}); err != nil {
+		t.Fatalf("InsertOne: %v", err)
+	}
+	if stats, err = txc.Stats(ctx); err != nil {
+		t.Fatalf("Stats: %v", err)
+	}
+	if stats.DocumentCount != 101 {
+		t.Errorf("got %d documents in the transaction, expected 101", stats.DocumentCount)
+	}
+}
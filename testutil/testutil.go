@@ -0,0 +1,74 @@
+// Package testutil provides helpers for tests of code that uses
+// MingoDB:
+//
+//	func TestActiveUsers(t *testing.T) {
+//		db := testutil.NewTestDB(t)
+//		users := db.CollectionMust("users")
+//		testutil.SeedCollection(t, users,
+//			map[string]interface{}{"name": "Alice", "active": true},
+//			map[string]interface{}{"name": "Bob", "active": false},
+//		)
+//		testutil.AssertDocumentCount(t, users, map[string]interface{}{"active": true}, 1)
+//	}
+package testutil
+
+import (
+	"context"
+	"testing"
+
+	"github.com/korrbit/mingodb"
+)
+
+// NewTestDB opens an in-memory database (see mingodb.OpenMemory),
+// which is closed when the test and its subtests finish. It fails the
+// test if the database can't be opened.
+func NewTestDB(t testing.TB) *mingodb.Database {
+	t.Helper()
+	db, err := mingodb.OpenMemory()
+	if err != nil {
+		t.Fatalf("opening database: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("closing database: %v", err)
+		}
+	})
+	return db
+}
+
+// SeedCollection inserts the documents into the collection with
+// InsertMany. It fails the test if they can't be inserted.
+func SeedCollection(t testing.TB, col *mingodb.Collection, docs ...interface{}) {
+	t.Helper()
+	if _, err := col.InsertMany(context.Background(), docs); err != nil {
+		t.Fatalf("seeding %s: %v", col.Name(), err)
+	}
+}
+
+// AssertDocumentExists reports an error if no document in the
+// collection matches the filter.
+func AssertDocumentExists(t testing.TB, col *mingodb.Collection, filter interface{}) {
+	t.Helper()
+	n, err := col.CountDocuments(context.Background(), filter)
+	if err != nil {
+		t.Errorf("counting documents in %s: %v", col.Name(), err)
+		return
+	}
+	if n == 0 {
+		t.Errorf("no document in %s matches %v", col.Name(), filter)
+	}
+}
+
+// AssertDocumentCount reports an error unless expected documents in
+// the collection match the filter.
+func AssertDocumentCount(t testing.TB, col *mingodb.Collection, filter interface{}, expected int) {
+	t.Helper()
+	n, err := col.CountDocuments(context.Background(), filter)
+	if err != nil {
+		t.Errorf("counting documents in %s: %v", col.Name(), err)
+		return
+	}
+	if n != expected {
+		t.Errorf("%d documents in %s match %v, expected %d", n, col.Name(), filter, expected)
+	}
+}
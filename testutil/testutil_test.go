@@ -0,0 +1,81 @@
+package testutil_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/korrbit/mingodb"
+	"github.com/korrbit/mingodb/testutil"
+)
+
+// recordingTB is a testing.TB that records its failures rather than
+// failing the test.
+type recordingTB struct {
+	testing.TB
+	failures []string
+}
+
+func (r *recordingTB) Errorf(format string, args ...interface{}) {
+	r.failures = append(r.failures, fmt.Sprintf(format, args...))
+}
+
+func (r *recordingTB) Fatalf(format string, args ...interface{}) {
+	r.failures = append(r.failures, fmt.Sprintf(format, args...))
+}
+
+func TestNewTestDB(t *testing.T) {
+	var db *mingodb.Database
+	t.Run("Open", func(t *testing.T) {
+		db = testutil.NewTestDB(t)
+		if _, err := db.CollectionMust("items").InsertOne(context.Background(), map[string]interface{}{"_id": 1}); err != nil {
+			t.Fatalf("InsertOne: %v", err)
+		}
+	})
+	// The database is closed once the test that opened it finishes.
+	if _, err := db.ListCollections(context.Background()); !errors.Is(err, mingodb.ErrDatabaseClosed) {
+		t.Errorf("ListCollections returned %v, expected ErrDatabaseClosed", err)
+	}
+}
+
+func TestAssertions(t *testing.T) {
+	c := testutil.NewTestDB(t).CollectionMust("items")
+	testutil.SeedCollection(t, c,
+		map[string]interface{}{"_id": 1, "n": 1},
+		map[string]interface{}{"_id": 2, "n": 1},
+	)
+	tests := []struct {
+		name   string
+		assert func(t testing.TB)
+		fails  bool
+	}{
+		{"Exists", func(t testing.TB) {
+			testutil.AssertDocumentExists(t, c, map[string]interface{}{"_id": 1})
+		}, false},
+		{"DoesNotExist", func(t testing.TB) {
+			testutil.AssertDocumentExists(t, c, map[string]interface{}{"_id": 3})
+		}, true},
+		{"Count", func(t testing.TB) {
+			testutil.AssertDocumentCount(t, c, map[string]interface{}{"n": 1}, 2)
+		}, false},
+		{"WrongCount", func(t testing.TB) {
+			testutil.AssertDocumentCount(t, c, nil, 1)
+		}, true},
+		{"InvalidFilter", func(t testing.TB) {
+			testutil.AssertDocumentCount(t, c, map[string]interface{}{"n": map[string]interface{}{"$bad": 1}}, 0)
+		}, true},
+		{"SeedDuplicate", func(t testing.TB) {
+			testutil.SeedCollection(t, c, map[string]interface{}{"_id": 1})
+		}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &recordingTB{TB: t}
+			tt.assert(r)
+			if failed := len(r.failures) > 0; failed != tt.fails {
+				t.Errorf("got failures %q, expected failure %v", r.failures, tt.fails)
+			}
+		})
+	}
+}
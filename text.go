@@ -0,0 +1,252 @@
+package mingodb
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	bolt "go.etcd.io/bbolt"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TextIndexOptions configures CreateTextIndex.
+type TextIndexOptions struct {
+	// Name is the name of the index. Defaults to each field followed
+	// by "_text", such as "title_text_body_text".
+	Name string
+
+	// DefaultLanguage selects how words are stemmed. "english" (the
+	// default) strips common English suffixes, so that "running" matches
+	// "run". "none" only lowercases words.
+	DefaultLanguage string
+}
+
+// CreateTextIndex creates a text index on string fields and returns
+// the index's name. The index maps each word in the fields to the
+// documents that contain it, so that documents can be searched with
+// the $text filter:
+//
+//	{"$text": {"$search": "coffee shop"}}
+//
+// A document matches if its indexed fields contain every word in the
+// search, and results are sorted by how often the words appear, unless
+// FindOptions sort them. $text is supported by Find, FindOne and
+// FindOneAndDelete. A collection can only have one text index.
+func (c *Collection) CreateTextIndex(ctx context.Context, fields []string, opts ...TextIndexOptions) (string, error) {
+	if len(fields) == 0 {
+		return "", fmt.Errorf("%w: no keys", ErrInvalidIndex)
+	}
+	info := IndexInfo{Text: true, DefaultLanguage: "english"}
+	var parts []string
+	for _, f := range fields {
+		if f == "" || strings.HasPrefix(f, "$") {
+			return "", fmt.Errorf("%w: invalid key %q", ErrInvalidIndex, f)
+		}
+		info.Keys = append(info.Keys, IndexKey{Field: f, Dir: 1})
+		parts = append(parts, f+"_text")
+	}
+	for _, opt := range opts {
+		if opt.Name != "" {
+			info.Name = opt.Name
+		}
+		if opt.DefaultLanguage != "" {
+			info.DefaultLanguage = opt.DefaultLanguage
+		}
+	}
+	switch info.DefaultLanguage {
+	case "english", "none":
+	default:
+		return "", fmt.Errorf("%w: unsupported language %q", ErrInvalidIndex, info.DefaultLanguage)
+	}
+	if info.Name == "" {
+		info.Name = strings.Join(parts, "_")
+	}
+	return c.buildIndex(ctx, info)
+}
+
+// textEntries returns the index keys of doc for a text index: each
+// distinct word in the indexed fields.
+func (idx *index) textEntries(doc map[string]interface{}) [][]byte {
+	var keys [][]byte
+	for term := range idx.termCounts(doc) {
+		keys = append(keys, []byte(term))
+	}
+	return keys
+}
+
+// termCounts returns the number of times each word appears in the
+// indexed fields of doc.
+func (idx *index) termCounts(doc map[string]interface{}) map[string]int {
+	counts := make(map[string]int)
+	for _, k := range idx.Keys {
+		v, _ := lookupPath(doc, k.Field)
+		values, ok := v.(primitive.A)
+		if !ok {
+			values = primitive.A{v}
+		}
+		for _, v := range values {
+			if s, ok := v.(string); ok {
+				for _, term := range tokenize(s, idx.DefaultLanguage) {
+					counts[term]++
+				}
+			}
+		}
+	}
+	return counts
+}
+
+// tokenize splits s into lowercase words, stemmed for the language.
+func tokenize(s, language string) []string {
+	words := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	for i, w := range words {
+		words[i] = stem(w, language)
+	}
+	return words
+}
+
+// stem strips common suffixes from an English word. It's far simpler
+// than a real stemmer, but since searches are stemmed the same way as
+// the indexed words, it only needs to be consistent.
+func stem(word, language string) string {
+	if language != "english" {
+		return word
+	}
+	n := len(word)
+	switch {
+	case strings.HasSuffix(word, "ing") && n >= 6:
+		word = word[:n-3]
+	case strings.HasSuffix(word, "ed") && n >= 5:
+		word = word[:n-2]
+	case strings.HasSuffix(word, "es") && n >= 5 && strings.ContainsAny(word[n-3:n-2], "sxz"),
+		strings.HasSuffix(word, "ches"), strings.HasSuffix(word, "shes"):
+		word = word[:n-2]
+	case strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss") && n >= 4:
+		word = word[:n-1]
+	}
+	// Collapse a doubled final consonant, so "running" becomes "run".
+	if n := len(word); n >= 4 && word[n-1] == word[n-2] && !strings.ContainsRune("aeiouls", rune(word[n-1])) {
+		word = word[:n-1]
+	}
+	return word
+}
+
+// textSearch returns the words of a $text filter's search, stemmed
+// for the text index.
+func textSearch(arg interface{}, idx *index) ([]string, error) {
+	m, ok := arg.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%w: $text requires a document", ErrInvalidFilter)
+	}
+	search, ok := m["$search"].(string)
+	if !ok {
+		return nil, fmt.Errorf("%w: $text requires a $search string", ErrInvalidFilter)
+	}
+	language := idx.DefaultLanguage
+	if l, ok := m["$language"].(string); ok {
+		language = l
+	}
+
+	var terms []string
+	seen := make(map[string]bool)
+	for _, t := range tokenize(search, language) {
+		if !seen[t] {
+			seen[t] = true
+			terms = append(terms, t)
+		}
+	}
+	return terms, nil
+}
+
+// textScore returns the number of times the terms appear in the
+// indexed fields of doc.
+func (idx *index) textScore(doc map[string]interface{}, terms []string) float64 {
+	counts := idx.termCounts(doc)
+	var score float64
+	for _, t := range terms {
+		score += float64(counts[t])
+	}
+	return score
+}
+
+// sortByScore sorts text search matches by their score, highest first.
+func sortByScore(matches []match, s *indexScan) {
+	scores := make(map[string]float64, len(matches))
+	for _, m := range matches {
+		scores[string(m.key)] = s.idx.textScore(m.doc, s.terms)
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return scores[string(matches[i].key)] > scores[string(matches[j].key)]
+	})
+}
+
+// scanText calls fn for each document in the bucket that contains
+// every term of the text index scan and matches the rest of the
+// filter, in the order of their primary keys.
+func scanText(ctx context.Context, b *bolt.Bucket, s *indexScan, filter map[string]interface{}, fn func(k, v []byte, doc map[string]interface{}) (bool, error)) error {
+	// Find the documents that contain every term. A search
+	// without any words doesn't match anything.
+	var pks [][]byte
+	for i, term := range s.terms {
+		found, err := s.idx.lookup([]byte(term))
+		if err != nil {
+			return err
+		}
+		if i == 0 {
+			pks = found
+			continue
+		}
+		has := make(map[string]bool, len(found))
+		for _, f := range found {
+			has[string(f)] = true
+		}
+		var both [][]byte
+		for _, pk := range pks {
+			if has[string(pk)] {
+				both = append(both, pk)
+			}
+		}
+		pks = both
+	}
+	sort.Slice(pks, func(i, j int) bool { return bytes.Compare(pks[i], pks[j]) < 0 })
+
+	// Match them against the rest of the filter.
+	rest := make(map[string]interface{}, len(filter))
+	for k, v := range filter {
+		if k != "$text" {
+			rest[k] = v
+		}
+	}
+	for _, k := range pks {
+		// Has the scan been cancelled?
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		v := b.Get(k)
+		if v == nil {
+			continue
+		}
+		countScanned(ctx)
+		data, doc, err := decodeDocument(b.Tx(), v)
+		if err != nil {
+			return err
+		}
+		ok, err := matchesFilter(doc, rest)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		more, err := fn(k, data, doc)
+		if err != nil || !more {
+			return err
+		}
+	}
+	return nil
+}
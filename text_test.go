@@ -0,0 +1,60 @@
+package mingodb_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/korrbit/mingodb"
+	"github.com/korrbit/mingodb/testutil"
+)
+
+func TestTextSearch(t *testing.T) {
+	ctx := context.Background()
+	c := testutil.NewTestDB(t).CollectionMust("posts")
+	testutil.SeedCollection(t, c,
+		map[string]interface{}{"_id": 1, "title": "Coffee", "body": "A coffee shop."},
+		map[string]interface{}{"_id": 2, "title": "Running", "body": "Running shoes for runners."},
+		map[string]interface{}{"_id": 3, "title": "Coffee, coffee!", "body": "The best coffee SHOP in town"},
+	)
+	search := func(s string) map[string]interface{} {
+		return map[string]interface{}{"$text": map[string]interface{}{"$search": s}}
+	}
+
+	if _, err := c.Find(ctx, search("coffee")); !errors.Is(err, mingodb.ErrIndexNotFound) {
+		t.Errorf("Find without a text index returned %v, expected ErrIndexNotFound", err)
+	}
+	name, err := c.CreateTextIndex(ctx, []string{"title", "body"})
+	if err != nil {
+		t.Fatalf("CreateTextIndex: %v", err)
+	}
+	if name != "title_text_body_text" {
+		t.Errorf("got index name %q, expected title_text_body_text", name)
+	}
+
+	tests := []struct {
+		name     string
+		search   string
+		expected []int32
+	}{
+		{"sorted by score", "coffee", []int32{3, 1}},
+		{"every word", "coffee shop", []int32{3, 1}},
+		{"case and punctuation", "COFFEE, town!", []int32{3}},
+		{"stemmed", "run", []int32{2}},
+		{"no match", "tea", []int32{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := findIDs(t, c, search(tt.search)); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("got %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+
+	// Later writes are indexed too.
+	testutil.SeedCollection(t, c, map[string]interface{}{"_id": 4, "title": "Tea", "body": "Not coffee"})
+	if got := findIDs(t, c, search("tea")); !reflect.DeepEqual(got, []int32{4}) {
+		t.Errorf("got %v, expected [4]", got)
+	}
+}
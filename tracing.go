@@ -0,0 +1,51 @@
+package mingodb
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// WithTracing returns CollectionOptions that register a
+// TracingMiddleware using tracer.
+func WithTracing(tracer trace.Tracer) CollectionOptions {
+	return CollectionOptions{Middleware: []Middleware{TracingMiddleware{Tracer: tracer}}}
+}
+
+// TracingMiddleware records an OpenTelemetry span for each of the
+// collection's operations (see OperationMiddleware). Spans are named
+// after the operation, such as "mingodb.find" or "mingodb.insertOne",
+// and have the attributes db.system ("mingodb"), db.name (the
+// database's path) and db.collection. Failed operations record their
+// error on the span.
+type TracingMiddleware struct {
+	BaseMiddleware
+
+	Tracer trace.Tracer
+}
+
+// StartOperation starts the operation's span.
+func (m TracingMiddleware) StartOperation(ctx context.Context, c *Collection, op string) context.Context {
+	ctx, _ = m.Tracer.Start(ctx, "mingodb."+op,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "mingodb"),
+			attribute.String("db.name", c.db.Path),
+			attribute.String("db.collection", c.name),
+		),
+	)
+	return ctx
+}
+
+// EndOperation ends the operation's span, recording err if it's not
+// nil.
+func (m TracingMiddleware) EndOperation(ctx context.Context, err error) {
+	span := trace.SpanFromContext(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,97 @@
+package mingodb_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/korrbit/mingodb"
+	"github.com/korrbit/mingodb/testutil"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// fakeTracer is a trace.Tracer that keeps the spans it starts.
+type fakeTracer struct {
+	mu    sync.Mutex
+	spans []*fakeSpan
+}
+
+func (t *fakeTracer) Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	config := trace.NewSpanStartConfig(opts...)
+	s := &fakeSpan{
+		Span:  trace.SpanFromContext(ctx),
+		name:  name,
+		attrs: config.Attributes(),
+	}
+	t.spans = append(t.spans, s)
+	return trace.ContextWithSpan(ctx, s), s
+}
+
+// fakeSpan records what's done to it. Its other methods are the
+// no-op span's.
+type fakeSpan struct {
+	trace.Span
+
+	name   string
+	attrs  []attribute.KeyValue
+	errs   []error
+	status codes.Code
+	ended  bool
+}
+
+func (s *fakeSpan) End(...trace.SpanEndOption)                    { s.ended = true }
+func (s *fakeSpan) RecordError(err error, _ ...trace.EventOption) { s.errs = append(s.errs, err) }
+func (s *fakeSpan) SetStatus(code codes.Code, _ string)           { s.status = code }
+
+func TestTracingMiddleware(t *testing.T) {
+	ctx := context.Background()
+	tracer := &fakeTracer{}
+	db := testutil.NewTestDB(t)
+	c := db.CollectionMust("items", mingodb.WithTracing(tracer))
+
+	if _, err := c.InsertOne(ctx, map[string]interface{}{"_id": 1}); err != nil {
+		t.Fatalf("InsertOne: %v", err)
+	}
+	if _, err := c.Find(ctx, nil); err != nil {
+		t.Fatalf("Find: %v", err)
+	}
+	_, dupErr := c.InsertOne(ctx, map[string]interface{}{"_id": 1})
+	if dupErr == nil {
+		t.Fatal("InsertOne of a duplicate _id succeeded")
+	}
+
+	var names []string
+	for _, s := range tracer.spans {
+		names = append(names, s.name)
+	}
+	if expected := []string{"mingodb.insertOne", "mingodb.find", "mingodb.insertOne"}; !reflect.DeepEqual(names, expected) {
+		t.Fatalf("got spans %v, expected %v", names, expected)
+	}
+	attrs := []attribute.KeyValue{
+		attribute.String("db.system", "mingodb"),
+		attribute.String("db.name", db.Path),
+		attribute.String("db.collection", "items"),
+	}
+	for i, s := range tracer.spans {
+		if !s.ended {
+			t.Errorf("span %d wasn't ended", i)
+		}
+		if !reflect.DeepEqual(s.attrs, attrs) {
+			t.Errorf("span %d has attributes %v, expected %v", i, s.attrs, attrs)
+		}
+	}
+	for i, s := range tracer.spans[:2] {
+		if len(s.errs) != 0 || s.status != codes.Unset {
+			t.Errorf("span %d recorded errors %v with status %v", i, s.errs, s.status)
+		}
+	}
+	if s := tracer.spans[2]; len(s.errs) != 1 || !errors.Is(s.errs[0], dupErr) || s.status != codes.Error {
+		t.Errorf("failed span recorded errors %v with status %v, expected %v", s.errs, s.status, dupErr)
+	}
+}
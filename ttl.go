@@ -0,0 +1,315 @@
+package mingodb
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// DefaultTTLInterval is how often the TTL worker started by Open
+// deletes expired documents.
+const DefaultTTLInterval = time.Minute
+
+// ttlWorker is a running TTL worker goroutine.
+type ttlWorker struct {
+	interval time.Duration
+	stop     chan struct{} // Closed to stop the worker
+	done     chan struct{} // Closed once the worker has stopped
+}
+
+// CreateTTLIndex creates a TTL index on a date field and returns the
+// index's name, which is the field followed by "_ttl".
+//
+// A document expires once expiry has passed since the date in the
+// field, or the earliest date if the field is an array of dates.
+// Documents without a date in the field never expire. Expired documents
+// are deleted by the database's TTL worker (see StartTTLWorker), so they
+// may still be returned by queries until the worker next runs.
+//
+// The worker deletes documents through the collection's handle (see
+// Database.Collection). Its middleware is called with an "expire"
+// operation, and deleted documents are kept in its history if it has
+// versioning enabled. Expired documents are deleted, not marked, even
+// if soft delete is enabled.
+func (c *Collection) CreateTTLIndex(ctx context.Context, field string, expiry time.Duration) (string, error) {
+	if field == "" || strings.HasPrefix(field, "$") {
+		return "", fmt.Errorf("%w: invalid key %q", ErrInvalidIndex, field)
+	}
+	if expiry < 0 {
+		return "", fmt.Errorf("%w: expiry cannot be negative", ErrInvalidIndex)
+	}
+	return c.buildIndex(ctx, IndexInfo{
+		Name:        field + "_ttl",
+		Keys:        []IndexKey{{Field: field, Dir: 1}},
+		TTL:         true,
+		ExpireAfter: expiry,
+	})
+}
+
+// ttlEntries returns the index keys of doc for a TTL index: the
+// time at which each date in the indexed field expires.
+func (idx *index) ttlEntries(doc map[string]interface{}) [][]byte {
+	v, _ := lookupPath(doc, idx.Keys[0].Field)
+	values, ok := v.(primitive.A)
+	if !ok {
+		values = primitive.A{v}
+	}
+
+	var keys [][]byte
+	for _, v := range values {
+		t, ok := toTime(v)
+		if !ok {
+			continue
+		}
+		key := ttlKey(t.Add(idx.ExpireAfter))
+		dup := false
+		for _, k := range keys {
+			dup = dup || bytes.Equal(k, key)
+		}
+		if !dup {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// ttlKey encodes t, to the millisecond, so that earlier times sort
+// before later ones.
+func ttlKey(t time.Time) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, uint64(t.UnixMilli())^(1<<63))
+	return key
+}
+
+// StartTTLWorker starts a goroutine that deletes expired documents from
+// collections with a TTL index every interval. Any worker that's already
+// running is stopped first, so this can be used to change the interval
+// of the worker started by Open. It does nothing if the database is
+// read-only. An interval that isn't positive is replaced by
+// DefaultTTLInterval.
+func (db *Database) StartTTLWorker(interval time.Duration) {
+	if db.readOnly {
+		return
+	}
+	if interval <= 0 {
+		interval = DefaultTTLInterval
+	}
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.stopTTLWorker()
+
+	w := &ttlWorker{interval: interval, stop: make(chan struct{}), done: make(chan struct{})}
+	db.ttl = w
+	go func() {
+		defer close(w.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-w.stop:
+				return
+			case now := <-ticker.C:
+				// There's no one to report errors to, so they're
+				// ignored and the documents are retried next time.
+				_, _ = db.expireDocuments(now)
+			}
+		}
+	}()
+}
+
+// StopTTLWorker stops the TTL worker, if it's running, and waits for
+// it to finish. It's called by Close.
+func (db *Database) StopTTLWorker() {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.stopTTLWorker()
+}
+
+// stopTTLWorker stops the TTL worker. db.mu must be held.
+func (db *Database) stopTTLWorker() {
+	if db.ttl == nil {
+		return
+	}
+	close(db.ttl.stop)
+	<-db.ttl.done
+	db.ttl = nil
+}
+
+// expireDocuments deletes every document that has expired by now
+// and returns the number of documents deleted.
+//
+// Each collection's documents are deleted in a transaction of their
+// own, through the collection's handle (see Database.Collection),
+// so that its middleware is called and, if versioning is enabled, the
+// deleted documents are kept in its history.
+func (db *Database) expireDocuments(now time.Time) (int, error) {
+	// Look for expired documents first so that the database is only
+	// written to if there are any.
+	var expired map[string][][]byte
+	err := db.view(func(tx *bolt.Tx) error {
+		var err error
+		expired, err = findExpired(tx, now)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	names := make([]string, 0, len(expired))
+	for name := range expired {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var n int
+	for _, name := range names {
+		m, err := db.handle(name).expire(expired[name], now)
+		n += m
+		if err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+// expire deletes the documents stored under keys that have expired by
+// now, as an "expire" operation whose filter matches their _ids.
+// Documents are deleted even if soft delete is enabled.
+//
+// The keys were found in an earlier transaction, so each document is
+// checked again before it's deleted, in case its date has since been
+// changed.
+func (c *Collection) expire(keys [][]byte, now time.Time) (n int, err error) {
+	var ids primitive.A
+	err = c.read(func(tx *bolt.Tx) error {
+		b, err := c.bucket(tx)
+		if err != nil {
+			return err
+		}
+		for _, k := range keys {
+			v := b.Get(k)
+			if v == nil {
+				continue
+			}
+			_, doc, err := decodeDocument(tx, v)
+			if err != nil {
+				return err
+			}
+			ids = append(ids, doc["_id"])
+		}
+		return nil
+	})
+	if errors.Is(err, ErrCollectionNotFound) {
+		return 0, nil
+	}
+	if err != nil || len(ids) == 0 {
+		return 0, err
+	}
+
+	filter := map[string]interface{}{"_id": map[string]interface{}{"$in": ids}}
+	ctx, end := c.startOperation(context.Background(), "expire", filter, nil)
+	defer func() { end(err) }()
+	if err := c.beforeDelete(ctx, filter); err != nil {
+		return 0, err
+	}
+
+	err = c.write(func(tx *bolt.Tx) error {
+		w, err := c.writeTx(ctx, tx)
+		if err != nil {
+			return err
+		}
+		limit := ttlKey(now)
+		for _, k := range keys {
+			v := w.b.Get(k)
+			if v == nil {
+				continue
+			}
+			_, doc, err := decodeDocument(tx, v)
+			if err != nil {
+				return err
+			}
+			if !hasExpired(w.indexes, doc, limit) {
+				continue
+			}
+			if err := w.delete(k); err != nil {
+				return err
+			}
+			n++
+		}
+		return nil
+	})
+	if errors.Is(err, ErrCollectionNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	c.afterDelete(ctx, &DeleteResult{DeleteCount: n})
+	return n, nil
+}
+
+// hasExpired reports whether doc has an entry at or before limit in any
+// of the TTL indexes.
+func hasExpired(indexes []*index, doc map[string]interface{}, limit []byte) bool {
+	for _, idx := range indexes {
+		if !idx.TTL {
+			continue
+		}
+		for _, k := range idx.ttlEntries(doc) {
+			if bytes.Compare(k, limit) <= 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// findExpired returns the primary keys of the documents that have
+// expired by now, by collection name.
+func findExpired(tx *bolt.Tx, now time.Time) (map[string][][]byte, error) {
+	root := tx.Bucket([]byte(indexesBucket))
+	if root == nil {
+		return nil, nil
+	}
+
+	expired := make(map[string][][]byte)
+	limit := ttlKey(now)
+	err := root.ForEach(func(name, v []byte) error {
+		if v != nil {
+			return nil // Not a collection's bucket.
+		}
+		indexes, err := loadIndexes(tx, string(name))
+		if err != nil {
+			return err
+		}
+		for _, idx := range indexes {
+			if !idx.TTL {
+				continue
+			}
+			c := idx.b.Cursor()
+			for k, v := c.First(); k != nil && bytes.Compare(k, limit) <= 0; k, v = c.Next() {
+				var e indexEntry
+				if err := bson.Unmarshal(v, &e); err != nil {
+					return err
+				}
+				for _, pk := range e.Keys {
+					expired[string(name)] = append(expired[string(name)], append([]byte(nil), pk...))
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return expired, nil
+}
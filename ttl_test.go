@@ -0,0 +1,143 @@
+package mingodb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// openTestDB opens an in-memory database that's closed when the
+// test finishes.
+func openTestDB(t *testing.T) *Database {
+	t.Helper()
+	db, err := OpenMemory()
+	if err != nil {
+		t.Fatalf("OpenMemory: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestExpireDocuments(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+	c := db.CollectionMust("sessions")
+	if _, err := c.CreateTTLIndex(ctx, "createdAt", time.Hour); err != nil {
+		t.Fatalf("CreateTTLIndex: %v", err)
+	}
+	now := time.Now()
+	for id, createdAt := range map[int]time.Time{1: now.Add(-2 * time.Hour), 2: now} {
+		if _, err := c.InsertOne(ctx, map[string]interface{}{"_id": id, "createdAt": createdAt}); err != nil {
+			t.Fatalf("InsertOne: %v", err)
+		}
+	}
+
+	n, err := db.expireDocuments(now)
+	if err != nil {
+		t.Fatalf("expireDocuments: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("expired %d documents, expected 1", n)
+	}
+	if _, err := c.GetByID(ctx, 2); err != nil {
+		t.Errorf("GetByID(2): %v", err)
+	}
+}
+
+func TestExpireRechecksDocuments(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+	c := db.CollectionMust("sessions")
+	if _, err := c.CreateTTLIndex(ctx, "createdAt", time.Hour); err != nil {
+		t.Fatalf("CreateTTLIndex: %v", err)
+	}
+	now := time.Now()
+	if _, err := c.InsertOne(ctx, map[string]interface{}{"_id": 1, "createdAt": now.Add(-2 * time.Hour)}); err != nil {
+		t.Fatalf("InsertOne: %v", err)
+	}
+
+	// The document is refreshed after it's found to have expired.
+	var expired map[string][][]byte
+	err := db.view(func(tx *bolt.Tx) error {
+		var err error
+		expired, err = findExpired(tx, now)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("findExpired: %v", err)
+	}
+	if len(expired["sessions"]) != 1 {
+		t.Fatalf("found %d expired documents, expected 1", len(expired["sessions"]))
+	}
+	update := map[string]interface{}{"$set": map[string]interface{}{"createdAt": now}}
+	if _, err := c.UpdateOne(ctx, map[string]interface{}{"_id": 1}, update); err != nil {
+		t.Fatalf("UpdateOne: %v", err)
+	}
+
+	n, err := c.expire(expired["sessions"], now)
+	if err != nil {
+		t.Fatalf("expire: %v", err)
+	}
+	if n != 0 {
+		t.Errorf("expired %d documents, expected 0", n)
+	}
+	if _, err := c.GetByID(ctx, 1); err != nil {
+		t.Errorf("GetByID: %v", err)
+	}
+}
+
+func TestStartTTLWorkerInvalidInterval(t *testing.T) {
+	db := openTestDB(t)
+	for _, interval := range []time.Duration{0, -time.Second} {
+		db.StartTTLWorker(interval)
+		if db.ttl.interval != DefaultTTLInterval {
+			t.Errorf("StartTTLWorker(%v) used interval %v, expected %v", interval, db.ttl.interval, DefaultTTLInterval)
+		}
+	}
+}
+
+func TestTTLWorker(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+	c := db.CollectionMust("sessions")
+	name, err := c.CreateTTLIndex(ctx, "createdAt", 0)
+	if err != nil {
+		t.Fatalf("CreateTTLIndex: %v", err)
+	}
+	if name != "createdAt_ttl" {
+		t.Errorf("got index name %q, expected createdAt_ttl", name)
+	}
+	if _, err := c.InsertOne(ctx, map[string]interface{}{"_id": 1, "createdAt": time.Now()}); err != nil {
+		t.Fatalf("InsertOne: %v", err)
+	}
+	if _, err := c.InsertOne(ctx, map[string]interface{}{"_id": 2}); err != nil {
+		t.Fatalf("InsertOne: %v", err)
+	}
+
+	db.StartTTLWorker(10 * time.Millisecond)
+	defer db.StopTTLWorker()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		n, err := c.CountDocuments(ctx, nil)
+		if err != nil {
+			t.Fatalf("CountDocuments: %v", err)
+		}
+		if n == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d documents left, expected the expired one to be deleted", n)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if _, err := c.GetByID(ctx, 2); err != nil {
+		t.Errorf("GetByID(2): %v", err)
+	}
+
+	db.StopTTLWorker()
+	if db.ttl != nil {
+		t.Error("StopTTLWorker left the worker running")
+	}
+}
@@ -0,0 +1,140 @@
+package mingodb
+
+import (
+	"context"
+	"fmt"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// txRunner runs functions in a long-lived bolt transaction. It's
+// implemented by Transaction and Snapshot.
+type txRunner interface {
+	// run runs fn in the transaction. write is true if fn
+	// modifies the database.
+	run(fn func(tx *bolt.Tx) error, write bool) error
+}
+
+// Transaction is a read-write transaction spanning any number of
+// operations on any number of collections. Its changes are only
+// visible to other transactions once it's committed, and are
+// discarded if it's rolled back.
+//
+// Only one read-write transaction can be open at a time, so other
+// writes block until the transaction is committed or rolled back.
+// A Transaction must not be used from multiple goroutines at once.
+type Transaction struct {
+	ctx  context.Context
+	db   *Database
+	tx   *bolt.Tx
+	err  error // Error returned by the first failed write, if any
+	done bool  // Whether the transaction has been committed or rolled back
+}
+
+// TxCollection is a collection that reads and writes through a
+// Transaction. It has the same methods and settings as the collection's
+// handle (see Transaction.Collection).
+type TxCollection struct {
+	*Collection
+}
+
+// BeginTx starts a read-write transaction. The transaction must be
+// ended with Commit or Rollback. If ctx is cancelled before Commit
+// is called, the transaction is rolled back.
+func (db *Database) BeginTx(ctx context.Context) (*Transaction, error) {
+	if db.readOnly {
+		return nil, ErrReadOnly
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	tx, err := db.db.Begin(true)
+	if err != nil {
+		return nil, boltError(err)
+	}
+	return &Transaction{ctx: ctx, db: db, tx: tx}, nil
+}
+
+// Collection returns the collection with the specified name within
+// the transaction. If the collection does not exist, it will be
+// created when the transaction is committed. Like Database.Collection,
+// it returns ErrInvalidCollectionName for reserved names.
+//
+// The returned collection reads and writes through the transaction,
+// and has the settings that the collection's handle (see
+// Database.Collection) has at the time: its IDGenerator, middleware,
+// validator, soft delete and versioning. Middleware is called around
+// each operation as it would be for the handle. Operations end before
+// the transaction is committed, so middleware such as
+// NewAuditMiddleware may see writes that are then rolled back.
+func (t *Transaction) Collection(name string) (*TxCollection, error) {
+	if name == "" {
+		return nil, ErrEmptyBucketName
+	}
+	if isInternalBucket(name) {
+		return nil, fmt.Errorf("%s: %w", name, ErrInvalidCollectionName)
+	}
+	c := *t.db.handle(name)
+	c.txn = t
+	err := t.run(func(tx *bolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists([]byte(name)); err != nil {
+			return err
+		}
+		return c.prepareSequence(tx)
+	}, true)
+	if err != nil {
+		return nil, err
+	}
+	return &TxCollection{&c}, nil
+}
+
+// Commit commits the transaction's changes to the database.
+//
+// If one of the transaction's writes failed, the transaction has been
+// aborted: it's rolled back instead and the error is returned.
+func (t *Transaction) Commit() error {
+	if t.done {
+		return ErrTxDone
+	}
+	t.done = true
+
+	if t.err != nil {
+		_ = t.tx.Rollback()
+		return fmt.Errorf("%w: %v", ErrTxAborted, t.err)
+	}
+	if err := t.ctx.Err(); err != nil {
+		_ = t.tx.Rollback()
+		return err
+	}
+	return boltError(t.tx.Commit())
+}
+
+// Rollback discards the transaction's changes.
+func (t *Transaction) Rollback() error {
+	if t.done {
+		return ErrTxDone
+	}
+	t.done = true
+	return boltError(t.tx.Rollback())
+}
+
+// run runs fn in the transaction. If write is true and fn fails, it
+// may have made some of its changes, so the transaction is aborted:
+// later operations fail and it can only be rolled back.
+func (t *Transaction) run(fn func(tx *bolt.Tx) error, write bool) error {
+	if t.done {
+		return ErrTxDone
+	}
+	if t.err != nil {
+		return fmt.Errorf("%w: %v", ErrTxAborted, t.err)
+	}
+	if err := t.ctx.Err(); err != nil {
+		return err
+	}
+
+	err := boltError(fn(t.tx))
+	if err != nil && write {
+		t.err = err
+	}
+	return err
+}
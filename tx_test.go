@@ -0,0 +1,124 @@
+package mingodb_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/korrbit/mingodb"
+	"github.com/korrbit/mingodb/testutil"
+)
+
+func TestTransactionCommit(t *testing.T) {
+	ctx := context.Background()
+	db := testutil.NewTestDB(t)
+	from := db.CollectionMust("from")
+	testutil.SeedCollection(t, from, map[string]interface{}{"_id": 1, "name": "Alice"})
+
+	tx, err := db.BeginTx(ctx)
+	if err != nil {
+		t.Fatalf("BeginTx: %v", err)
+	}
+	txFrom, err := tx.Collection("from")
+	if err != nil {
+		t.Fatalf("Collection: %v", err)
+	}
+	txTo, err := tx.Collection("to")
+	if err != nil {
+		t.Fatalf("Collection: %v", err)
+	}
+	if _, err := txTo.InsertOne(ctx, map[string]interface{}{"_id": 1, "name": "Alice"}); err != nil {
+		t.Fatalf("InsertOne: %v", err)
+	}
+	if _, err := txFrom.DeleteOne(ctx, 1); err != nil {
+		t.Fatalf("DeleteOne: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+
+	testutil.AssertDocumentCount(t, from, nil, 0)
+	testutil.AssertDocumentCount(t, db.CollectionMust("to"), nil, 1)
+	if err := tx.Commit(); !errors.Is(err, mingodb.ErrTxDone) {
+		t.Errorf("second Commit returned %v, expected ErrTxDone", err)
+	}
+}
+
+func TestTransactionRollback(t *testing.T) {
+	ctx := context.Background()
+	db := testutil.NewTestDB(t)
+
+	tx, err := db.BeginTx(ctx)
+	if err != nil {
+		t.Fatalf("BeginTx: %v", err)
+	}
+	c, err := tx.Collection("items")
+	if err != nil {
+		t.Fatalf("Collection: %v", err)
+	}
+	if _, err := c.InsertOne(ctx, map[string]interface{}{"_id": 1}); err != nil {
+		t.Fatalf("InsertOne: %v", err)
+	}
+	if err := tx.Rollback(); err != nil {
+		t.Fatalf("Rollback: %v", err)
+	}
+
+	exists, err := db.CollectionExists(ctx, "items")
+	if err != nil {
+		t.Fatalf("CollectionExists: %v", err)
+	}
+	if exists {
+		t.Error("collection created by a rolled back transaction exists")
+	}
+}
+
+func TestTransactionKeepsCollectionSettings(t *testing.T) {
+	ctx := context.Background()
+	db := testutil.NewTestDB(t)
+	c := db.CollectionMust("items")
+	c.EnableSoftDelete("deletedAt")
+	testutil.SeedCollection(t, c,
+		map[string]interface{}{"_id": 1},
+		map[string]interface{}{"_id": 2},
+	)
+	// Asking for the collection again mustn't reset its settings.
+	db.CollectionMust("items")
+
+	tx, err := db.BeginTx(ctx)
+	if err != nil {
+		t.Fatalf("BeginTx: %v", err)
+	}
+	defer tx.Rollback()
+	txc, err := tx.Collection("items")
+	if err != nil {
+		t.Fatalf("Collection: %v", err)
+	}
+	if _, err := txc.DeleteOne(ctx, 1); err != nil {
+		t.Fatalf("DeleteOne: %v", err)
+	}
+	n, err := txc.CountDocuments(ctx, nil)
+	if err != nil {
+		t.Fatalf("CountDocuments: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("counted %d documents, expected 1", n)
+	}
+	res, err := txc.FindWithDeleted(ctx, nil)
+	if err != nil {
+		t.Fatalf("FindWithDeleted: %v", err)
+	}
+	if res.ResultCount != 2 {
+		t.Errorf("FindWithDeleted returned %d documents, expected 2", res.ResultCount)
+	}
+}
+
+func TestCollectionReturnsHandle(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	c := db.CollectionMust("items")
+	if db.CollectionMust("items") != c {
+		t.Error("Collection returned a new handle for the same collection")
+	}
+	if db.CollectionMust("other") == c {
+		t.Error("Collection returned the same handle for another collection")
+	}
+}
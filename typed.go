@@ -0,0 +1,72 @@
+package mingodb
+
+import "context"
+
+// TypedCollection wraps a Collection so that documents are inserted
+// and returned as values of type T, rather than interface{} or maps.
+// T should be a struct type (or a pointer to one) that the BSON
+// encoder can unmarshal documents into.
+type TypedCollection[T any] struct {
+	inner *Collection
+}
+
+// NewTypedCollection returns a TypedCollection of T backed by c.
+func NewTypedCollection[T any](c *Collection) *TypedCollection[T] {
+	return &TypedCollection[T]{inner: c}
+}
+
+// Collection returns the untyped collection backing tc.
+func (tc *TypedCollection[T]) Collection() *Collection {
+	return tc.inner
+}
+
+// InsertOne inserts doc into the collection. See Collection.InsertOne.
+func (tc *TypedCollection[T]) InsertOne(ctx context.Context, doc T) (InsertID, error) {
+	return tc.inner.InsertOne(ctx, doc)
+}
+
+// FindOne returns the first document that matches filter. Returns
+// ErrNoDocuments if no document matches. See Collection.FindOne.
+func (tc *TypedCollection[T]) FindOne(ctx context.Context, filter interface{}, opts ...FindOptions) (T, error) {
+	var doc T
+	r, err := tc.inner.FindOne(ctx, filter, opts...)
+	if err != nil {
+		return doc, err
+	}
+	if err := r.Decode(&doc); err != nil {
+		return doc, err
+	}
+	return doc, nil
+}
+
+// Find returns every document that matches filter. See Collection.Find.
+func (tc *TypedCollection[T]) Find(ctx context.Context, filter interface{}, opts ...FindOptions) ([]T, error) {
+	r, err := tc.inner.Find(ctx, filter, opts...)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	docs := make([]T, 0, r.ResultCount)
+	for r.Next() {
+		var doc T
+		if err := r.Decode(&doc); err != nil {
+			return nil, err
+		}
+		docs = append(docs, doc)
+	}
+	return docs, nil
+}
+
+// UpdateOne updates the first document that matches filter. See
+// Collection.UpdateOne.
+func (tc *TypedCollection[T]) UpdateOne(ctx context.Context, filter, update interface{}) (*UpdateResult, error) {
+	return tc.inner.UpdateOne(ctx, filter, update)
+}
+
+// DeleteOne deletes the first document that matches filter. See
+// Collection.DeleteOne.
+func (tc *TypedCollection[T]) DeleteOne(ctx context.Context, filter interface{}) error {
+	_, err := tc.inner.DeleteOne(ctx, filter)
+	return err
+}
@@ -0,0 +1,53 @@
+package mingodb_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/korrbit/mingodb"
+	"github.com/korrbit/mingodb/testutil"
+)
+
+type user struct {
+	ID   int    `bson:"_id"`
+	Name string `bson:"name"`
+	Age  int    `bson:"age"`
+}
+
+func TestTypedCollection(t *testing.T) {
+	ctx := context.Background()
+	users := mingodb.NewTypedCollection[user](testutil.NewTestDB(t).CollectionMust("users"))
+	for _, u := range []user{{1, "Alice", 30}, {2, "Bob", 25}} {
+		if _, err := users.InsertOne(ctx, u); err != nil {
+			t.Fatalf("InsertOne: %v", err)
+		}
+	}
+
+	u, err := users.FindOne(ctx, map[string]interface{}{"name": "Bob"})
+	if err != nil {
+		t.Fatalf("FindOne: %v", err)
+	}
+	if expected := (user{2, "Bob", 25}); u != expected {
+		t.Errorf("got %+v, expected %+v", u, expected)
+	}
+
+	if _, err := users.UpdateOne(ctx, map[string]interface{}{"_id": 2}, map[string]interface{}{"$inc": map[string]interface{}{"age": 1}}); err != nil {
+		t.Fatalf("UpdateOne: %v", err)
+	}
+	all, err := users.Find(ctx, nil)
+	if err != nil {
+		t.Fatalf("Find: %v", err)
+	}
+	if expected := []user{{1, "Alice", 30}, {2, "Bob", 26}}; !reflect.DeepEqual(all, expected) {
+		t.Errorf("got %+v, expected %+v", all, expected)
+	}
+
+	if err := users.DeleteOne(ctx, map[string]interface{}{"_id": 1}); err != nil {
+		t.Fatalf("DeleteOne: %v", err)
+	}
+	if _, err := users.FindOne(ctx, map[string]interface{}{"_id": 1}); !errors.Is(err, mingodb.ErrNoDocuments) {
+		t.Errorf("FindOne returned %v, expected ErrNoDocuments", err)
+	}
+}
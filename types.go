@@ -0,0 +1,118 @@
+package mingodb
+
+import (
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// typeAliases maps the type names accepted by $type to their
+// BSON types. "number" is handled separately since it matches
+// several types.
+var typeAliases = map[string]bsontype.Type{
+	"double":              bsontype.Double,
+	"string":              bsontype.String,
+	"object":              bsontype.EmbeddedDocument,
+	"array":               bsontype.Array,
+	"binData":             bsontype.Binary,
+	"undefined":           bsontype.Undefined,
+	"objectId":            bsontype.ObjectID,
+	"bool":                bsontype.Boolean,
+	"date":                bsontype.DateTime,
+	"null":                bsontype.Null,
+	"regex":               bsontype.Regex,
+	"dbPointer":           bsontype.DBPointer,
+	"javascript":          bsontype.JavaScript,
+	"symbol":              bsontype.Symbol,
+	"javascriptWithScope": bsontype.CodeWithScope,
+	"int":                 bsontype.Int32,
+	"int32":               bsontype.Int32,
+	"timestamp":           bsontype.Timestamp,
+	"long":                bsontype.Int64,
+	"int64":               bsontype.Int64,
+	"decimal":             bsontype.Decimal128,
+	"minKey":              bsontype.MinKey,
+	"maxKey":              bsontype.MaxKey,
+}
+
+// bsonType returns the BSON type of a decoded value.
+func bsonType(v interface{}) bsontype.Type {
+	switch v.(type) {
+	case float64, float32:
+		return bsontype.Double
+	case string:
+		return bsontype.String
+	case map[string]interface{}, primitive.D, primitive.M:
+		return bsontype.EmbeddedDocument
+	case primitive.A:
+		return bsontype.Array
+	case primitive.Binary:
+		return bsontype.Binary
+	case primitive.Undefined:
+		return bsontype.Undefined
+	case primitive.ObjectID:
+		return bsontype.ObjectID
+	case bool:
+		return bsontype.Boolean
+	case primitive.DateTime:
+		return bsontype.DateTime
+	case nil, primitive.Null:
+		return bsontype.Null
+	case primitive.Regex:
+		return bsontype.Regex
+	case primitive.DBPointer:
+		return bsontype.DBPointer
+	case primitive.JavaScript:
+		return bsontype.JavaScript
+	case primitive.Symbol:
+		return bsontype.Symbol
+	case primitive.CodeWithScope:
+		return bsontype.CodeWithScope
+	case int32:
+		return bsontype.Int32
+	case primitive.Timestamp:
+		return bsontype.Timestamp
+	case int64:
+		return bsontype.Int64
+	case primitive.Decimal128:
+		return bsontype.Decimal128
+	case primitive.MinKey:
+		return bsontype.MinKey
+	case primitive.MaxKey:
+		return bsontype.MaxKey
+	}
+	return 0
+}
+
+// matchesType reports whether v has one of the BSON types given
+// by the $type operand. The operand can be a type name, a numeric
+// type code or an array of either.
+func matchesType(v interface{}, arg interface{}) (bool, error) {
+	if list, ok := arg.(primitive.A); ok {
+		for _, a := range list {
+			ok, err := matchesType(v, a)
+			if err != nil || ok {
+				return ok, err
+			}
+		}
+		return false, nil
+	}
+
+	t := bsonType(v)
+	if name, ok := arg.(string); ok {
+		if name == "number" {
+			return t == bsontype.Double || t == bsontype.Int32 ||
+				t == bsontype.Int64 || t == bsontype.Decimal128, nil
+		}
+		want, ok := typeAliases[name]
+		if !ok {
+			return false, fmt.Errorf("%w: unknown type %q", ErrInvalidFilter, name)
+		}
+		return t == want, nil
+	}
+	if code, ok := toFloat(arg); ok {
+		return t == bsontype.Type(int(code)), nil
+	}
+	return false, fmt.Errorf("%w: $type requires a type name or code", ErrInvalidFilter)
+}
@@ -0,0 +1,30 @@
+package mingodb_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/korrbit/mingodb"
+)
+
+func TestType(t *testing.T) {
+	runFilterTests(t, []filterTest{
+		{"double", map[string]interface{}{"score": map[string]interface{}{"$type": "double"}}, []int32{1}},
+		{"int", map[string]interface{}{"score": map[string]interface{}{"$type": "int"}}, []int32{2}},
+		{"string", map[string]interface{}{"score": map[string]interface{}{"$type": "string"}}, []int32{3}},
+		{"number", map[string]interface{}{"score": map[string]interface{}{"$type": "number"}}, []int32{1, 2}},
+		{"null", map[string]interface{}{"deletedAt": map[string]interface{}{"$type": "null"}}, []int32{2}},
+		{"date", map[string]interface{}{"at": map[string]interface{}{"$type": "date"}}, []int32{1, 2}},
+		{"code", map[string]interface{}{"score": map[string]interface{}{"$type": 1}}, []int32{1}},
+		{"list", map[string]interface{}{"score": map[string]interface{}{"$type": []interface{}{"double", "string"}}}, []int32{1, 3}},
+	})
+}
+
+func TestUnknownType(t *testing.T) {
+	c := people(t)
+	_, err := c.Find(context.Background(), map[string]interface{}{"age": map[string]interface{}{"$type": "integer"}})
+	if !errors.Is(err, mingodb.ErrInvalidFilter) {
+		t.Errorf("Find returned %v, expected ErrInvalidFilter", err)
+	}
+}
@@ -0,0 +1,80 @@
+package mingodb
+
+import (
+	"fmt"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// unwindStage outputs a copy of each document for every element of
+// an array field, with the field set to the element. The argument is
+// either the field path or a document of options:
+//
+//	{"$unwind": "$tags"}
+//	{"$unwind": {"path": "$tags", "preserveNullAndEmptyArrays": true}}
+//
+// Documents where the field is missing, null or an empty array are
+// dropped, unless preserveNullAndEmptyArrays is true, in which case
+// they're output unchanged. A field that isn't an array is treated
+// as an array with a single element.
+func unwindStage(docs []map[string]interface{}, arg interface{}) ([]map[string]interface{}, error) {
+	var path string
+	var preserve bool
+	if s, ok := arg.(string); ok {
+		path = s
+	} else {
+		spec, err := stageDocument(arg)
+		if err != nil {
+			return nil, err
+		}
+		path, _ = spec["path"].(string)
+		if p, ok := spec["preserveNullAndEmptyArrays"]; ok {
+			if preserve, ok = p.(bool); !ok {
+				return nil, fmt.Errorf("%w: $unwind preserveNullAndEmptyArrays must be a boolean", ErrInvalidPipeline)
+			}
+		}
+	}
+	if !strings.HasPrefix(path, "$") || len(path) < 2 {
+		return nil, fmt.Errorf("%w: $unwind path must be a field path starting with $", ErrInvalidPipeline)
+	}
+	path = path[1:]
+
+	var out []map[string]interface{}
+	for _, doc := range docs {
+		v, ok := lookupPath(doc, path)
+		arr, isArray := v.(primitive.A)
+		switch {
+		case !ok || v == nil || (isArray && len(arr) == 0):
+			if preserve {
+				out = append(out, doc)
+			}
+		case !isArray:
+			out = append(out, doc)
+		default:
+			for _, elem := range arr {
+				out = append(out, withPath(doc, path, elem))
+			}
+		}
+	}
+	return out, nil
+}
+
+// withPath returns a shallow copy of doc with the value at the
+// dot-separated path set to v. The maps along the path are copied
+// so that doc itself isn't modified. The path must exist.
+func withPath(doc map[string]interface{}, path string, v interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(doc))
+	for k, val := range doc {
+		out[k] = val
+	}
+
+	field, rest, nested := strings.Cut(path, ".")
+	if !nested {
+		out[field] = v
+		return out
+	}
+	sub, _ := doc[field].(map[string]interface{})
+	out[field] = withPath(sub, rest, v)
+	return out
+}
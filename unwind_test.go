@@ -0,0 +1,56 @@
+package mingodb_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/korrbit/mingodb"
+	"github.com/korrbit/mingodb/testutil"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestUnwind(t *testing.T) {
+	c := testutil.NewTestDB(t).CollectionMust("posts")
+	testutil.SeedCollection(t, c,
+		map[string]interface{}{"_id": 1, "tags": []interface{}{"a", "b"}},
+		map[string]interface{}{"_id": 2, "tags": []interface{}{}},
+		map[string]interface{}{"_id": 3},
+		map[string]interface{}{"_id": 4, "tags": "c"},
+	)
+	tests := []struct {
+		name     string
+		opts     []mingodb.UnwindOptions
+		expected []map[string]interface{}
+	}{
+		{
+			name: "default",
+			expected: []map[string]interface{}{
+				{"_id": int32(1), "tags": "a"},
+				{"_id": int32(1), "tags": "b"},
+				{"_id": int32(4), "tags": "c"},
+			},
+		},
+		{
+			name: "preserveNullAndEmptyArrays",
+			opts: []mingodb.UnwindOptions{{PreserveNullAndEmptyArrays: true}},
+			expected: []map[string]interface{}{
+				{"_id": int32(1), "tags": "a"},
+				{"_id": int32(1), "tags": "b"},
+				{"_id": int32(2), "tags": primitive.A{}},
+				{"_id": int32(3)},
+				{"_id": int32(4), "tags": "c"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := aggregate(t, c, mingodb.Pipeline{
+				mingodb.UnwindStage("$tags", tt.opts...),
+				mingodb.ProjectStage(map[string]interface{}{mingodb.VersionField: 0}),
+			})
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("got %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+}
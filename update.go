@@ -0,0 +1,521 @@
+package mingodb
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// parseUpdate converts an update document into a map of update
+// operators (e.g. {"$set": {"field": value}}) whose values have
+// been normalized.
+func parseUpdate(update interface{}) (map[string]interface{}, error) {
+	m, err := toDocument(update)
+	if err != nil {
+		return nil, err
+	}
+	u, err := normalizeDocument(m)
+	if err != nil {
+		return nil, err
+	}
+
+	// Every top-level key must be an operator whose argument
+	// is a document.
+	if len(u) == 0 {
+		return nil, ErrInvalidUpdate
+	}
+	for op, arg := range u {
+		if !strings.HasPrefix(op, "$") {
+			return nil, fmt.Errorf("%w: expected an update operator, got %q", ErrInvalidUpdate, op)
+		}
+		if _, ok := arg.(map[string]interface{}); !ok {
+			return nil, fmt.Errorf("%w: argument to %s must be a document", ErrInvalidUpdate, op)
+		}
+	}
+
+	// The operators are applied in no particular order, so no two of
+	// them may change the same field, or a field and one embedded in it.
+	var paths []string
+	for op, arg := range u {
+		for k, v := range arg.(map[string]interface{}) {
+			paths = append(paths, k)
+			if to, ok := v.(string); ok && op == "$rename" {
+				paths = append(paths, to)
+			}
+		}
+	}
+	for i, a := range paths {
+		for _, b := range paths[i+1:] {
+			if pathsOverlap(a, b) {
+				return nil, fmt.Errorf("%w: updating %q would conflict with updating %q", ErrInvalidUpdate, a, b)
+			}
+		}
+	}
+	return u, nil
+}
+
+// pathsOverlap reports whether two dot-separated paths are the same
+// field or one of them is embedded in the other.
+func pathsOverlap(a, b string) bool {
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	return a == b || strings.HasPrefix(b, a+".")
+}
+
+// applyUpdate applies the update operators to doc, modifying
+// it in place.
+func applyUpdate(doc, update map[string]interface{}) error {
+	for op, arg := range update {
+		fields := arg.(map[string]interface{})
+		switch op {
+		case "$set":
+			if err := applySet(doc, fields); err != nil {
+				return err
+			}
+		case "$unset":
+			if err := applyUnset(doc, fields); err != nil {
+				return err
+			}
+		case "$inc":
+			if err := applyInc(doc, fields); err != nil {
+				return err
+			}
+		case "$mul":
+			if err := applyMul(doc, fields); err != nil {
+				return err
+			}
+		case "$currentDate":
+			if err := applyCurrentDate(doc, fields); err != nil {
+				return err
+			}
+		case "$push":
+			if err := applyPush(doc, fields); err != nil {
+				return err
+			}
+		case "$pull":
+			if err := applyPull(doc, fields); err != nil {
+				return err
+			}
+		case "$addToSet":
+			if err := applyAddToSet(doc, fields); err != nil {
+				return err
+			}
+		case "$rename":
+			if err := applyRename(doc, fields); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("%w: unknown operator %s", ErrInvalidUpdate, op)
+		}
+	}
+	return nil
+}
+
+// applySet sets each of the fields in doc to its new value. A field
+// can use dot-notation to set a nested field, such as "address.city",
+// leaving the rest of the embedded document alone.
+func applySet(doc, fields map[string]interface{}) error {
+	for k, v := range fields {
+		// The _id is the document's key so it can't be changed.
+		if id, ok := doc["_id"]; ok && (k == "_id" || strings.HasPrefix(k, "_id.")) {
+			if k != "_id" || !valuesEqual(id, v) {
+				return fmt.Errorf("%w: _id cannot be modified", ErrInvalidUpdate)
+			}
+		}
+		if err := setPath(doc, k, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setPath sets the value at a dot-separated path in doc, creating
+// any missing embedded documents along the way. A numeric part of
+// the path indexes into an array, which is padded with nulls if it's
+// too short. Returns ErrPathTraversal if the path runs into a value
+// that's neither a document nor an array.
+func setPath(doc map[string]interface{}, path string, v interface{}) error {
+	parts := strings.Split(path, ".")
+	var cur interface{} = doc
+	for i, part := range parts {
+		last := i == len(parts)-1
+		switch c := cur.(type) {
+		case map[string]interface{}:
+			if last {
+				c[part] = v
+				return nil
+			}
+			next, ok := c[part]
+			if !ok || next == nil {
+				next = map[string]interface{}{}
+				c[part] = next
+			}
+			cur = next
+		case primitive.A:
+			n, err := strconv.Atoi(part)
+			if err != nil || n < 0 {
+				return fmt.Errorf("%w: %s is an array", ErrPathTraversal, strings.Join(parts[:i], "."))
+			}
+			// Pad the array, then replace it in its parent, as
+			// appending may have reallocated it.
+			if n >= len(c) {
+				padded := append(c, make(primitive.A, n+1-len(c))...)
+				if err := setPath(doc, strings.Join(parts[:i], "."), padded); err != nil {
+					return err
+				}
+				c = padded
+			}
+			if last {
+				c[n] = v
+				return nil
+			}
+			if c[n] == nil {
+				c[n] = map[string]interface{}{}
+			}
+			cur = c[n]
+		default:
+			return fmt.Errorf("%w: %s has type %T", ErrPathTraversal, strings.Join(parts[:i], "."), cur)
+		}
+	}
+	return nil
+}
+
+// applyUnset removes each of the fields from doc. The values
+// of fields are ignored.
+func applyUnset(doc, fields map[string]interface{}) error {
+	for k := range fields {
+		// The _id must always be present.
+		if k == "_id" {
+			return fmt.Errorf("%w: _id cannot be unset", ErrInvalidDocument)
+		}
+		delete(doc, k)
+	}
+	return nil
+}
+
+// applyInc adds each of the deltas to the corresponding field in
+// doc. Missing fields are set to the delta.
+func applyInc(doc, fields map[string]interface{}) error {
+	for k, delta := range fields {
+		if _, ok := toFloat(delta); !ok {
+			return fmt.Errorf("%w: cannot increment by non-numeric value of type %T", ErrInvalidUpdate, delta)
+		}
+		cur, ok := doc[k]
+		if !ok {
+			doc[k] = delta
+			continue
+		}
+		if _, ok := toFloat(cur); !ok {
+			return fmt.Errorf("%w: field %q has type %T, expected a number", ErrTypeMismatch, k, cur)
+		}
+		doc[k] = addNumbers(cur, delta)
+	}
+	return nil
+}
+
+// addNumbers adds two numeric values. The result is a float64 if
+// either value is a float, otherwise it's an int32 if both values
+// are int32s and the sum fits, or an int64.
+func addNumbers(a, b interface{}) interface{} {
+	ai, aok := toInt(a)
+	bi, bok := toInt(b)
+	if !aok || !bok {
+		fa, _ := toFloat(a)
+		fb, _ := toFloat(b)
+		return fa + fb
+	}
+
+	sum := ai + bi
+	_, a32 := a.(int32)
+	_, b32 := b.(int32)
+	if a32 && b32 && sum >= math.MinInt32 && sum <= math.MaxInt32 {
+		return int32(sum)
+	}
+	return sum
+}
+
+// applyMul multiplies each of the fields in doc by its factor.
+// Missing fields are set to zero, of the factor's type.
+func applyMul(doc, fields map[string]interface{}) error {
+	for k, factor := range fields {
+		if _, ok := toFloat(factor); !ok {
+			return fmt.Errorf("%w: cannot multiply by non-numeric value of type %T", ErrInvalidUpdate, factor)
+		}
+		cur, ok := doc[k]
+		if !ok {
+			cur = int32(0)
+		} else if _, ok := toFloat(cur); !ok {
+			return fmt.Errorf("%w: field %q has type %T, expected a number", ErrTypeMismatch, k, cur)
+		}
+		doc[k] = multiplyNumbers(cur, factor)
+	}
+	return nil
+}
+
+// multiplyNumbers multiplies two numeric values. Like addNumbers,
+// the result is a float64 if either value is a float, otherwise it's
+// an int32 if both values are int32s and the product fits, or an int64.
+func multiplyNumbers(a, b interface{}) interface{} {
+	ai, aok := toInt(a)
+	bi, bok := toInt(b)
+	if !aok || !bok {
+		fa, _ := toFloat(a)
+		fb, _ := toFloat(b)
+		return fa * fb
+	}
+
+	product := ai * bi
+	_, a32 := a.(int32)
+	_, b32 := b.(int32)
+	if a32 && b32 && product >= math.MinInt32 && product <= math.MaxInt32 {
+		return int32(product)
+	}
+	return product
+}
+
+// applyCurrentDate sets each of the fields in doc to the current
+// time. A field's value is true, or {"$type": "date"}, for a date, or
+// {"$type": "timestamp"} for a timestamp.
+func applyCurrentDate(doc, fields map[string]interface{}) error {
+	now := time.Now()
+	for k, v := range fields {
+		var val interface{}
+		switch v := v.(type) {
+		case bool:
+			val = primitive.NewDateTimeFromTime(now)
+		case map[string]interface{}:
+			switch v["$type"] {
+			case "date":
+				val = primitive.NewDateTimeFromTime(now)
+			case "timestamp":
+				val = primitive.Timestamp{T: uint32(now.Unix())}
+			default:
+				return fmt.Errorf("%w: $type for %q must be \"date\" or \"timestamp\"", ErrInvalidUpdate, k)
+			}
+		default:
+			return fmt.Errorf("%w: value for %q must be a boolean or a $type document", ErrInvalidUpdate, k)
+		}
+		if err := applySet(doc, map[string]interface{}{k: val}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyPush appends each of the values to the corresponding
+// array field in doc. Missing fields are set to a new array.
+//
+// A value can use the $each modifier to append multiple values,
+// optionally with $slice to limit the array's length afterwards:
+//
+//	{"$push": {"field": {"$each": [1, 2], "$slice": -5}}}
+func applyPush(doc, fields map[string]interface{}) error {
+	for k, v := range fields {
+		arr, err := arrayField(doc, k)
+		if err != nil {
+			return err
+		}
+		values, mods, err := eachValues(v)
+		if err != nil {
+			return err
+		}
+		arr = append(arr, values...)
+
+		// Trim the array, if requested. A positive $slice keeps
+		// the first elements, a negative one the last.
+		if sv, ok := mods["$slice"]; ok {
+			n, ok := toInt(sv)
+			if !ok {
+				return fmt.Errorf("%w: $slice must be an integer", ErrInvalidUpdate)
+			}
+			switch {
+			case n >= 0 && int(n) < len(arr):
+				arr = arr[:n]
+			case n < 0 && int(-n) < len(arr):
+				arr = arr[len(arr)+int(n):]
+			}
+		}
+		doc[k] = arr
+	}
+	return nil
+}
+
+// applyRename renames each of the fields in doc to its new name,
+// overwriting any existing field with that name. Missing fields
+// are ignored.
+func applyRename(doc, fields map[string]interface{}) error {
+	for from, v := range fields {
+		to, ok := v.(string)
+		if !ok || to == "" {
+			return fmt.Errorf("%w: new name for %q must be a non-empty string", ErrInvalidUpdate, from)
+		}
+		// The _id can't be renamed or replaced.
+		if from == "_id" || to == "_id" {
+			return fmt.Errorf("%w: _id cannot be renamed", ErrInvalidDocument)
+		}
+		val, ok := doc[from]
+		if !ok {
+			continue
+		}
+		delete(doc, from)
+		doc[to] = val
+	}
+	return nil
+}
+
+// applyAddToSet appends each of the values to the corresponding
+// array field in doc, unless the array already contains it. Missing
+// fields are set to a new array. A value can use the $each modifier
+// to add multiple values.
+func applyAddToSet(doc, fields map[string]interface{}) error {
+	for k, v := range fields {
+		arr, err := arrayField(doc, k)
+		if err != nil {
+			return err
+		}
+		values, _, err := eachValues(v)
+		if err != nil {
+			return err
+		}
+
+	values:
+		for _, val := range values {
+			for _, elem := range arr {
+				if reflect.DeepEqual(elem, val) {
+					continue values
+				}
+			}
+			arr = append(arr, val)
+		}
+		doc[k] = arr
+	}
+	return nil
+}
+
+// applyPull removes every element of the corresponding array field
+// in doc that matches the condition. Missing fields are ignored.
+//
+// The condition can be a value, which removes equal elements, or a
+// document of operators such as {"$lt": 50}. A document without
+// operators removes the embedded documents that match it as a filter.
+func applyPull(doc, fields map[string]interface{}) error {
+	for k, cond := range fields {
+		if _, ok := doc[k]; !ok {
+			continue
+		}
+		arr, err := arrayField(doc, k)
+		if err != nil {
+			return err
+		}
+
+		kept := primitive.A{}
+		for _, elem := range arr {
+			matched, err := matchesPullCondition(elem, cond)
+			if err != nil {
+				return err
+			}
+			if !matched {
+				kept = append(kept, elem)
+			}
+		}
+		doc[k] = kept
+	}
+	return nil
+}
+
+// matchesPullCondition reports whether an array element matches
+// a $pull condition.
+func matchesPullCondition(elem, cond interface{}) (bool, error) {
+	if isOperatorDocument(cond) {
+		ok, err := matchesOperators(elem, true, cond.(map[string]interface{}))
+		if err != nil {
+			return false, fmt.Errorf("%w: %v", ErrInvalidUpdate, err)
+		}
+		return ok, nil
+	}
+
+	// Embedded documents match if they contain the condition's fields.
+	c, cok := cond.(map[string]interface{})
+	e, eok := elem.(map[string]interface{})
+	if cok && eok {
+		ok, err := matchesFilter(e, c)
+		if err != nil {
+			return false, fmt.Errorf("%w: %v", ErrInvalidUpdate, err)
+		}
+		return ok, nil
+	}
+	return valuesEqual(elem, cond), nil
+}
+
+// arrayField returns the value of an array field in doc. Returns
+// an empty array if the field is missing, or ErrTypeMismatch if it
+// isn't an array.
+func arrayField(doc map[string]interface{}, k string) (primitive.A, error) {
+	v, ok := doc[k]
+	if !ok {
+		return primitive.A{}, nil
+	}
+	arr, ok := v.(primitive.A)
+	if !ok {
+		return nil, fmt.Errorf("%w: field %q has type %T, expected an array", ErrTypeMismatch, k, v)
+	}
+	return arr, nil
+}
+
+// eachValues returns the values to add to an array. If v uses the
+// $each modifier, its values are returned along with any other
+// modifiers. Otherwise, v itself is the only value.
+func eachValues(v interface{}) (primitive.A, map[string]interface{}, error) {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return primitive.A{v}, nil, nil
+	}
+	each, ok := m["$each"]
+	if !ok {
+		return primitive.A{v}, nil, nil
+	}
+	values, ok := each.(primitive.A)
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: $each must be an array", ErrInvalidUpdate)
+	}
+	return values, m, nil
+}
+
+// isModified reports whether doc differs from the original
+// document's raw BSON. Unlike valuesEqual, values must have
+// the same type to be equal.
+func isModified(orig []byte, doc map[string]interface{}) (bool, error) {
+	var o map[string]interface{}
+	if err := bson.Unmarshal(orig, &o); err != nil {
+		return false, err
+	}
+	return !reflect.DeepEqual(o, doc), nil
+}
+
+// updateMatch applies the update to a matched document and, if
+// the document was modified, stores it under its original key.
+// Returns the document's new raw BSON.
+func updateMatch(w *writeTx, m match, update map[string]interface{}) ([]byte, bool, error) {
+	if err := applyUpdate(m.doc, update); err != nil {
+		return nil, false, err
+	}
+	modified, err := isModified(m.data, m.doc)
+	if err != nil || !modified {
+		return m.data, false, err
+	}
+	bdoc, err := bson.Marshal(m.doc)
+	if err != nil {
+		return nil, false, err
+	}
+	if bdoc, err = w.update(m.key, bdoc); err != nil {
+		return nil, false, err
+	}
+	return bdoc, true, nil
+}
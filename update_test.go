@@ -0,0 +1,320 @@
+package mingodb_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/korrbit/mingodb"
+	"github.com/korrbit/mingodb/testutil"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// updateTest is a test of an update operator. The update is applied
+// to doc, which has the _id 1, and the result compared to expected
+// unless err is set. The document's VersionField is left out of the
+// comparison (see optimistic_test.go).
+type updateTest struct {
+	name     string
+	doc      map[string]interface{}
+	update   map[string]interface{}
+	expected map[string]interface{}
+	err      error
+}
+
+// runUpdateTests runs each of the tests against a new collection.
+func runUpdateTests(t *testing.T, tests []updateTest) {
+	t.Helper()
+	ctx := context.Background()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := testutil.NewTestDB(t).CollectionMust("items")
+			testutil.SeedCollection(t, c, tt.doc)
+
+			_, err := c.UpdateOne(ctx, map[string]interface{}{"_id": 1}, tt.update)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Errorf("UpdateOne returned %v, expected %v", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("UpdateOne: %v", err)
+			}
+			var doc map[string]interface{}
+			if err := c.GetByIDInto(ctx, 1, &doc); err != nil {
+				t.Fatalf("GetByIDInto: %v", err)
+			}
+			delete(doc, mingodb.VersionField)
+			if !reflect.DeepEqual(doc, tt.expected) {
+				t.Errorf("got %v, expected %v", doc, tt.expected)
+			}
+		})
+	}
+}
+
+func TestUnset(t *testing.T) {
+	runUpdateTests(t, []updateTest{
+		{
+			name:     "field",
+			doc:      map[string]interface{}{"_id": 1, "a": 1, "b": 2},
+			update:   map[string]interface{}{"$unset": map[string]interface{}{"a": ""}},
+			expected: map[string]interface{}{"_id": int32(1), "b": int32(2)},
+		},
+		{
+			name:     "missing field",
+			doc:      map[string]interface{}{"_id": 1, "b": 2},
+			update:   map[string]interface{}{"$unset": map[string]interface{}{"a": ""}},
+			expected: map[string]interface{}{"_id": int32(1), "b": int32(2)},
+		},
+		{
+			name:   "_id",
+			doc:    map[string]interface{}{"_id": 1},
+			update: map[string]interface{}{"$unset": map[string]interface{}{"_id": ""}},
+			err:    mingodb.ErrInvalidDocument,
+		},
+	})
+}
+
+func TestConflictingOperators(t *testing.T) {
+	runUpdateTests(t, []updateTest{
+		{
+			name: "same field",
+			doc:  map[string]interface{}{"_id": 1, "a": 1},
+			update: map[string]interface{}{
+				"$set":   map[string]interface{}{"a": 2},
+				"$unset": map[string]interface{}{"a": ""},
+			},
+			err: mingodb.ErrInvalidUpdate,
+		},
+		{
+			name: "embedded field",
+			doc:  map[string]interface{}{"_id": 1, "a": map[string]interface{}{"b": 1}},
+			update: map[string]interface{}{
+				"$set":   map[string]interface{}{"a.b": 2},
+				"$unset": map[string]interface{}{"a": ""},
+			},
+			err: mingodb.ErrInvalidUpdate,
+		},
+		{
+			name:   "same operator",
+			doc:    map[string]interface{}{"_id": 1},
+			update: map[string]interface{}{"$set": map[string]interface{}{"a": 1, "a.b": 2}},
+			err:    mingodb.ErrInvalidUpdate,
+		},
+		{
+			name: "sibling fields",
+			doc:  map[string]interface{}{"_id": 1, "a": 1, "ab": 1},
+			update: map[string]interface{}{
+				"$set":   map[string]interface{}{"a": 2},
+				"$unset": map[string]interface{}{"ab": ""},
+			},
+			expected: map[string]interface{}{"_id": int32(1), "a": int32(2)},
+		},
+	})
+}
+
+func TestInc(t *testing.T) {
+	runUpdateTests(t, []updateTest{
+		{
+			name:     "integers",
+			doc:      map[string]interface{}{"_id": 1, "n": 1},
+			update:   map[string]interface{}{"$inc": map[string]interface{}{"n": 2}},
+			expected: map[string]interface{}{"_id": int32(1), "n": int32(3)},
+		},
+		{
+			name:     "floats",
+			doc:      map[string]interface{}{"_id": 1, "n": 1, "score": 1.5},
+			update:   map[string]interface{}{"$inc": map[string]interface{}{"n": 0.5, "score": -0.5}},
+			expected: map[string]interface{}{"_id": int32(1), "n": 1.5, "score": 1.0},
+		},
+		{
+			name:     "missing field",
+			doc:      map[string]interface{}{"_id": 1},
+			update:   map[string]interface{}{"$inc": map[string]interface{}{"n": 5}},
+			expected: map[string]interface{}{"_id": int32(1), "n": int32(5)},
+		},
+		{
+			name:   "non-numeric field",
+			doc:    map[string]interface{}{"_id": 1, "n": "one"},
+			update: map[string]interface{}{"$inc": map[string]interface{}{"n": 1}},
+			err:    mingodb.ErrTypeMismatch,
+		},
+	})
+}
+
+func TestPush(t *testing.T) {
+	runUpdateTests(t, []updateTest{
+		{
+			name:     "value",
+			doc:      map[string]interface{}{"_id": 1, "tags": []interface{}{"a"}},
+			update:   map[string]interface{}{"$push": map[string]interface{}{"tags": "b"}},
+			expected: map[string]interface{}{"_id": int32(1), "tags": primitive.A{"a", "b"}},
+		},
+		{
+			name:     "missing field",
+			doc:      map[string]interface{}{"_id": 1},
+			update:   map[string]interface{}{"$push": map[string]interface{}{"tags": "a"}},
+			expected: map[string]interface{}{"_id": int32(1), "tags": primitive.A{"a"}},
+		},
+		{
+			name: "each",
+			doc:  map[string]interface{}{"_id": 1, "tags": []interface{}{"a"}},
+			update: map[string]interface{}{"$push": map[string]interface{}{
+				"tags": map[string]interface{}{"$each": []interface{}{"b", "c"}},
+			}},
+			expected: map[string]interface{}{"_id": int32(1), "tags": primitive.A{"a", "b", "c"}},
+		},
+		{
+			name: "slice",
+			doc:  map[string]interface{}{"_id": 1, "tags": []interface{}{"a"}},
+			update: map[string]interface{}{"$push": map[string]interface{}{
+				"tags": map[string]interface{}{"$each": []interface{}{"b", "c"}, "$slice": -2},
+			}},
+			expected: map[string]interface{}{"_id": int32(1), "tags": primitive.A{"b", "c"}},
+		},
+		{
+			name:   "non-array field",
+			doc:    map[string]interface{}{"_id": 1, "tags": "a"},
+			update: map[string]interface{}{"$push": map[string]interface{}{"tags": "b"}},
+			err:    mingodb.ErrTypeMismatch,
+		},
+	})
+}
+
+func TestPull(t *testing.T) {
+	runUpdateTests(t, []updateTest{
+		{
+			name:     "value",
+			doc:      map[string]interface{}{"_id": 1, "tags": []interface{}{"a", "b", "a"}},
+			update:   map[string]interface{}{"$pull": map[string]interface{}{"tags": "a"}},
+			expected: map[string]interface{}{"_id": int32(1), "tags": primitive.A{"b"}},
+		},
+		{
+			name:     "condition",
+			doc:      map[string]interface{}{"_id": 1, "scores": []interface{}{40, 80, 20}},
+			update:   map[string]interface{}{"$pull": map[string]interface{}{"scores": map[string]interface{}{"$lt": 50}}},
+			expected: map[string]interface{}{"_id": int32(1), "scores": primitive.A{int32(80)}},
+		},
+		{
+			name:     "missing field",
+			doc:      map[string]interface{}{"_id": 1},
+			update:   map[string]interface{}{"$pull": map[string]interface{}{"tags": "a"}},
+			expected: map[string]interface{}{"_id": int32(1)},
+		},
+		{
+			name:   "non-array field",
+			doc:    map[string]interface{}{"_id": 1, "tags": "a"},
+			update: map[string]interface{}{"$pull": map[string]interface{}{"tags": "a"}},
+			err:    mingodb.ErrTypeMismatch,
+		},
+	})
+}
+
+func TestAddToSet(t *testing.T) {
+	runUpdateTests(t, []updateTest{
+		{
+			name:     "new value",
+			doc:      map[string]interface{}{"_id": 1, "roles": []interface{}{"user"}},
+			update:   map[string]interface{}{"$addToSet": map[string]interface{}{"roles": "admin"}},
+			expected: map[string]interface{}{"_id": int32(1), "roles": primitive.A{"user", "admin"}},
+		},
+		{
+			name: "each",
+			doc:  map[string]interface{}{"_id": 1, "roles": []interface{}{"user"}},
+			update: map[string]interface{}{"$addToSet": map[string]interface{}{
+				"roles": map[string]interface{}{"$each": []interface{}{"user", "admin", "admin"}},
+			}},
+			expected: map[string]interface{}{"_id": int32(1), "roles": primitive.A{"user", "admin"}},
+		},
+		{
+			name:   "non-array field",
+			doc:    map[string]interface{}{"_id": 1, "roles": "user"},
+			update: map[string]interface{}{"$addToSet": map[string]interface{}{"roles": "admin"}},
+			err:    mingodb.ErrTypeMismatch,
+		},
+	})
+}
+
+func TestAddToSetExistingValue(t *testing.T) {
+	c := testutil.NewTestDB(t).CollectionMust("items")
+	testutil.SeedCollection(t, c, map[string]interface{}{"_id": 1, "roles": []interface{}{"user"}})
+	res, err := c.UpdateOne(context.Background(), map[string]interface{}{"_id": 1}, map[string]interface{}{
+		"$addToSet": map[string]interface{}{"roles": "user"},
+	})
+	if err != nil {
+		t.Fatalf("UpdateOne: %v", err)
+	}
+	if res.MatchedCount != 1 || res.UpdateCount != 0 {
+		t.Errorf("got %+v, expected one document matched and none updated", res)
+	}
+}
+
+func TestRename(t *testing.T) {
+	runUpdateTests(t, []updateTest{
+		{
+			name:     "field",
+			doc:      map[string]interface{}{"_id": 1, "old": "x"},
+			update:   map[string]interface{}{"$rename": map[string]interface{}{"old": "new"}},
+			expected: map[string]interface{}{"_id": int32(1), "new": "x"},
+		},
+		{
+			name:     "onto an existing field",
+			doc:      map[string]interface{}{"_id": 1, "old": "x", "new": "y"},
+			update:   map[string]interface{}{"$rename": map[string]interface{}{"old": "new"}},
+			expected: map[string]interface{}{"_id": int32(1), "new": "x"},
+		},
+		{
+			name:     "missing field",
+			doc:      map[string]interface{}{"_id": 1, "new": "y"},
+			update:   map[string]interface{}{"$rename": map[string]interface{}{"old": "new"}},
+			expected: map[string]interface{}{"_id": int32(1), "new": "y"},
+		},
+		{
+			name:   "_id",
+			doc:    map[string]interface{}{"_id": 1},
+			update: map[string]interface{}{"$rename": map[string]interface{}{"_id": "id"}},
+			err:    mingodb.ErrInvalidDocument,
+		},
+	})
+}
+
+func TestSetDotNotation(t *testing.T) {
+	runUpdateTests(t, []updateTest{
+		{
+			name:   "nested field",
+			doc:    map[string]interface{}{"_id": 1, "address": map[string]interface{}{"city": "London", "zip": "N1"}},
+			update: map[string]interface{}{"$set": map[string]interface{}{"address.city": "Paris"}},
+			expected: map[string]interface{}{"_id": int32(1), "address": map[string]interface{}{
+				"city": "Paris", "zip": "N1",
+			}},
+		},
+		{
+			name:   "missing documents",
+			doc:    map[string]interface{}{"_id": 1},
+			update: map[string]interface{}{"$set": map[string]interface{}{"a.b.c": 1}},
+			expected: map[string]interface{}{"_id": int32(1), "a": map[string]interface{}{
+				"b": map[string]interface{}{"c": int32(1)},
+			}},
+		},
+		{
+			name:     "array index",
+			doc:      map[string]interface{}{"_id": 1, "scores": []interface{}{1, 2}},
+			update:   map[string]interface{}{"$set": map[string]interface{}{"scores.1": 5, "scores.3": 7}},
+			expected: map[string]interface{}{"_id": int32(1), "scores": primitive.A{int32(1), int32(5), nil, int32(7)}},
+		},
+		{
+			name:   "through a non-document",
+			doc:    map[string]interface{}{"_id": 1, "address": "unknown"},
+			update: map[string]interface{}{"$set": map[string]interface{}{"address.city": "Paris"}},
+			err:    mingodb.ErrPathTraversal,
+		},
+		{
+			name:   "_id",
+			doc:    map[string]interface{}{"_id": 1},
+			update: map[string]interface{}{"$set": map[string]interface{}{"_id": 2}},
+			err:    mingodb.ErrInvalidUpdate,
+		},
+	})
+}
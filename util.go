@@ -1 +1,268 @@
 package mingodb
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// toDocument converts doc into a map[string]interface{}.
+//
+// Expects doc to be either a struct or a map[string]interface{}, or
+// a pointer to one. Note that if doc is a struct, only exported fields
+// will be kept (see structToDocument).
+func toDocument(doc interface{}) (map[string]interface{}, error) {
+	// Validate the document. Is it a struct or a map?
+	if doc == nil {
+		return nil, ErrInvalidType
+	}
+	v := reflect.ValueOf(doc)
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil, ErrInvalidType
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct && v.Kind() != reflect.Map {
+		return nil, ErrInvalidType
+	}
+
+	// If it's a struct, convert it to a map.
+	if v.Kind() == reflect.Struct {
+		return structToDocument(v), nil
+	}
+
+	// Can the map be converted to a map[string]interface{}?
+	m, ok := v.Interface().(map[string]interface{})
+	if !ok {
+		return nil, ErrInvalidType
+	}
+
+	// Convert any structs or pointers it contains.
+	out := make(map[string]interface{}, len(m))
+	for k, val := range m {
+		out[k] = convertValue(reflect.ValueOf(val))
+	}
+	return out, nil
+}
+
+// structToDocument converts a struct into a map. Each exported field
+// is stored under the name given by its bson tag or, if it doesn't
+// have one, under its Go field name. The tag's options are supported:
+//
+//	Field string `bson:"-"`              // Not stored
+//	Field string `bson:"name,omitempty"` // Not stored if it's a zero value
+//	Field Nested `bson:",inline"`        // Nested's fields are stored in the parent
+//
+// Nested structs are converted following the same rules.
+func structToDocument(v reflect.Value) map[string]interface{} {
+	m := make(map[string]interface{})
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue // Unexported.
+		}
+
+		// Parse the field's tag.
+		name := f.Name
+		var omitEmpty, inline bool
+		if tag, ok := f.Tag.Lookup("bson"); ok {
+			parts := strings.Split(tag, ",")
+			if parts[0] == "-" {
+				continue
+			}
+			if parts[0] != "" {
+				name = parts[0]
+			}
+			for _, opt := range parts[1:] {
+				switch opt {
+				case "omitempty":
+					omitEmpty = true
+				case "inline":
+					inline = true
+				}
+			}
+		}
+
+		fv := v.Field(i)
+		if omitEmpty && fv.IsZero() {
+			continue
+		}
+		if inline && isConvertibleStruct(fv.Type()) {
+			for k, val := range structToDocument(fv) {
+				m[k] = val
+			}
+			continue
+		}
+		m[name] = convertValue(fv)
+	}
+	return m
+}
+
+// convertValue converts the structs within v into maps, following
+// the same rules as structToDocument. Pointers are dereferenced, with
+// nil pointers becoming nil. Other values are returned as is.
+func convertValue(v reflect.Value) interface{} {
+	if !v.IsValid() {
+		return nil
+	}
+
+	// Leave custom marshalers to the BSON encoder.
+	if v.Type().Implements(marshalerType) || v.Type().Implements(valueMarshalerType) {
+		return v.Interface()
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return convertValue(v.Elem())
+	case reflect.Struct:
+		if isConvertibleStruct(v.Type()) {
+			return structToDocument(v)
+		}
+	case reflect.Map:
+		if v.Type().Key().Kind() == reflect.String && needsConversion(v.Type().Elem()) {
+			if v.IsNil() {
+				return nil
+			}
+			m := make(map[string]interface{}, v.Len())
+			iter := v.MapRange()
+			for iter.Next() {
+				m[iter.Key().String()] = convertValue(iter.Value())
+			}
+			return m
+		}
+	case reflect.Slice, reflect.Array:
+		if needsConversion(v.Type().Elem()) {
+			if v.Kind() == reflect.Slice && v.IsNil() {
+				return nil
+			}
+			a := make([]interface{}, v.Len())
+			for i := range a {
+				a[i] = convertValue(v.Index(i))
+			}
+			return a
+		}
+	}
+	return v.Interface()
+}
+
+// needsConversion reports whether values of type t may contain
+// structs or pointers that convertValue would convert.
+func needsConversion(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice, reflect.Array:
+		return true
+	}
+	return isConvertibleStruct(t)
+}
+
+// isConvertibleStruct reports whether t is a struct that should be
+// converted into a map. Types that are encoded specially, such as
+// time.Time, BSON primitives and custom marshalers, are left as is.
+func isConvertibleStruct(t reflect.Type) bool {
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+	switch t.PkgPath() {
+	case "time", "go.mongodb.org/mongo-driver/bson/primitive":
+		return false
+	}
+	if t.Implements(marshalerType) || t.Implements(valueMarshalerType) {
+		return false
+	}
+	return true
+}
+
+var (
+	marshalerType      = reflect.TypeOf((*bson.Marshaler)(nil)).Elem()
+	valueMarshalerType = reflect.TypeOf((*bson.ValueMarshaler)(nil)).Elem()
+)
+
+// prepareDocument converts doc into a map, assigns it an _id (using
+// newID) and a version if it doesn't already have them and marshals
+// the _id (using idKey) and the document into bytes, ready to be
+// stored.
+func prepareDocument(doc interface{}, newID func() (interface{}, error), idKey func(interface{}) ([]byte, error)) (id interface{}, key []byte, data []byte, err error) {
+	m, err := toDocument(doc)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("%w: %v", ErrInvalidDocument, err)
+	}
+
+	// Check if doc has an _id field.
+	// If not, generate one and add it to the doc.
+	id, ok := m["_id"]
+	if !ok {
+		if id, err = newID(); err != nil {
+			return nil, nil, nil, err
+		}
+		m["_id"] = id
+	}
+
+	// Start the document's version (see VersionField).
+	if _, ok := m[VersionField]; !ok {
+		m[VersionField] = int64(1)
+	}
+
+	// Validate the id and marshal it into bytes.
+	key, err = idKey(id)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("%w: invalid _id: %v", ErrInvalidDocument, err)
+	}
+
+	// Marshal the document into bytes.
+	data, err = bson.Marshal(m)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("%w: %v", ErrInvalidDocument, err)
+	}
+
+	return id, key, data, nil
+}
+
+// normalizeDocument round-trips m through BSON so that its values
+// have the same Go types as the values of a decoded document
+// (e.g. an int becomes an int32 and a slice becomes a primitive.A).
+func normalizeDocument(m map[string]interface{}) (map[string]interface{}, error) {
+	b, err := bson.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	var n map[string]interface{}
+	if err := bson.Unmarshal(b, &n); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+// lookupPath returns the value at a dot-separated path in doc, such
+// as "address.city". A numeric part of the path indexes into an array,
+// so "scores.0" is the first element of scores. The second return
+// value is false if the path doesn't exist.
+func lookupPath(doc map[string]interface{}, path string) (interface{}, bool) {
+	var cur interface{} = doc
+	for _, part := range strings.Split(path, ".") {
+		switch v := cur.(type) {
+		case map[string]interface{}:
+			var ok bool
+			if cur, ok = v[part]; !ok {
+				return nil, false
+			}
+		case primitive.A:
+			i, err := strconv.Atoi(part)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil, false
+			}
+			cur = v[i]
+		default:
+			return nil, false
+		}
+	}
+	return cur, true
+}
@@ -0,0 +1,93 @@
+package mingodb_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/korrbit/mingodb"
+	"github.com/korrbit/mingodb/testutil"
+)
+
+// stored inserts doc and returns it as stored in the database.
+func stored(t *testing.T, doc interface{}) map[string]interface{} {
+	t.Helper()
+	ctx := context.Background()
+	c := testutil.NewTestDB(t).CollectionMust("items")
+	id, err := c.InsertOne(ctx, doc)
+	if err != nil {
+		t.Fatalf("InsertOne: %v", err)
+	}
+	var m map[string]interface{}
+	if err := c.GetByIDInto(ctx, id, &m); err != nil {
+		t.Fatalf("GetByIDInto: %v", err)
+	}
+	return m
+}
+
+func TestStructTags(t *testing.T) {
+	type address struct {
+		City string `bson:"city"`
+	}
+	type meta struct {
+		Source string `bson:"source"`
+	}
+	type user struct {
+		ID      int    `bson:"_id"`
+		Name    string `bson:"name"`
+		Email   string `bson:"email,omitempty"`
+		Secret  string `bson:"-"`
+		Plain   string
+		Address address `bson:"address"`
+		Meta    meta    `bson:",inline"`
+		private string
+	}
+	got := stored(t, user{ID: 1, Name: "Alice", Secret: "x", Plain: "p", Address: address{City: "Paris"}, Meta: meta{Source: "web"}, private: "y"})
+	expected := map[string]interface{}{
+		"_id":                int32(1),
+		"name":               "Alice",
+		"Plain":              "p",
+		"address":            map[string]interface{}{"city": "Paris"},
+		"source":             "web",
+		mingodb.VersionField: int64(1),
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %v, expected %v", got, expected)
+	}
+}
+
+func TestPointerFields(t *testing.T) {
+	type scores struct {
+		Best *int `bson:"best"`
+	}
+	type doc struct {
+		ID     int     `bson:"_id"`
+		Name   *string `bson:"name"`
+		Score  *int    `bson:"score"`
+		Scores *scores `bson:"scores"`
+	}
+	name, best := "Alice", 10
+	got := stored(t, &doc{ID: 1, Name: &name, Scores: &scores{Best: &best}})
+	expected := map[string]interface{}{
+		"_id":                int32(1),
+		"name":               "Alice",
+		"score":              nil,
+		"scores":             map[string]interface{}{"best": int32(10)},
+		mingodb.VersionField: int64(1),
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %v, expected %v", got, expected)
+	}
+
+	// The same goes for the values in an update.
+	ctx := context.Background()
+	c := testutil.NewTestDB(t).CollectionMust("items")
+	testutil.SeedCollection(t, c, map[string]interface{}{"_id": 1})
+	_, err := c.UpdateOne(ctx, map[string]interface{}{"_id": 1}, map[string]interface{}{
+		"$set": map[string]interface{}{"scores": &scores{Best: &best}, "none": (*int)(nil)},
+	})
+	if err != nil {
+		t.Fatalf("UpdateOne: %v", err)
+	}
+	testutil.AssertDocumentExists(t, c, map[string]interface{}{"_id": 1, "scores.best": 10, "none": nil})
+}
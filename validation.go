@@ -0,0 +1,51 @@
+package mingodb
+
+import (
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// SetValidator sets a function that validates every document stored
+// in the collection: inserted documents, replacements and documents
+// after an update has been applied. If fn returns an error, the write
+// is rolled back and returns the error wrapped in ErrValidationFailed.
+// A nil fn removes the validator.
+//
+// fn is passed the document as it will be stored, with the same Go
+// types as a decoded document (e.g. int32 and primitive.A). Like Use,
+// the validator applies wherever the collection is used, and
+// SetValidator should be called before the collection is used by
+// multiple goroutines.
+func (c *Collection) SetValidator(fn func(doc map[string]interface{}) error) {
+	c.validator = fn
+}
+
+// validate checks the document against the collection's validator
+// and JSON Schema.
+func (w *writeTx) validate(data []byte) error {
+	schema, err := w.c.jsonSchema(w.tx)
+	if err != nil {
+		return err
+	}
+	if w.c.validator == nil && schema == nil {
+		return nil
+	}
+
+	var doc map[string]interface{}
+	if err := bson.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	if w.c.validator != nil {
+		if err := w.c.validator(doc); err != nil {
+			return fmt.Errorf("%w: %v", ErrValidationFailed, err)
+		}
+	}
+	if schema != nil {
+		// The version is kept by the collection, not the caller, so
+		// schemas don't need to allow for it.
+		delete(doc, VersionField)
+		return validateSchema(schema, doc)
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package mingodb_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/korrbit/mingodb"
+	"github.com/korrbit/mingodb/testutil"
+)
+
+// validated returns a collection holding {_id: 1, n: 1} whose
+// validator rejects negative values of n.
+func validated(t *testing.T) *mingodb.Collection {
+	t.Helper()
+	c := testutil.NewTestDB(t).CollectionMust("items")
+	c.SetValidator(func(doc map[string]interface{}) error {
+		if n, ok := doc["n"].(int32); ok && n < 0 {
+			return errors.New("n is negative")
+		}
+		return nil
+	})
+	testutil.SeedCollection(t, c, map[string]interface{}{"_id": 1, "n": 1})
+	return c
+}
+
+func TestValidator(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name  string
+		write func(c *mingodb.Collection) error
+	}{
+		{"InsertOne", func(c *mingodb.Collection) error {
+			_, err := c.InsertOne(ctx, map[string]interface{}{"_id": 2, "n": -1})
+			return err
+		}},
+		{"InsertMany", func(c *mingodb.Collection) error {
+			_, err := c.InsertMany(ctx, []interface{}{
+				map[string]interface{}{"_id": 2, "n": 2},
+				map[string]interface{}{"_id": 3, "n": -1},
+			})
+			return err
+		}},
+		{"UpdateOne", func(c *mingodb.Collection) error {
+			_, err := c.UpdateOne(ctx, map[string]interface{}{"_id": 1}, map[string]interface{}{"$inc": map[string]interface{}{"n": -2}})
+			return err
+		}},
+		{"UpdateMany", func(c *mingodb.Collection) error {
+			_, err := c.UpdateMany(ctx, nil, map[string]interface{}{"$set": map[string]interface{}{"n": -1}})
+			return err
+		}},
+		{"ReplaceOne", func(c *mingodb.Collection) error {
+			_, err := c.ReplaceOne(ctx, map[string]interface{}{"_id": 1}, map[string]interface{}{"n": -1})
+			return err
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := validated(t)
+			if err := tt.write(c); !errors.Is(err, mingodb.ErrValidationFailed) {
+				t.Errorf("got %v, expected ErrValidationFailed", err)
+			}
+			// The write was rolled back.
+			testutil.AssertDocumentCount(t, c, nil, 1)
+			testutil.AssertDocumentExists(t, c, map[string]interface{}{"_id": 1, "n": 1})
+		})
+	}
+}
+
+func TestValidatorAllowsValidWrites(t *testing.T) {
+	ctx := context.Background()
+	c := validated(t)
+	if _, err := c.InsertOne(ctx, map[string]interface{}{"_id": 2, "n": 2}); err != nil {
+		t.Fatalf("InsertOne: %v", err)
+	}
+	if _, err := c.UpdateOne(ctx, map[string]interface{}{"_id": 1}, map[string]interface{}{"$inc": map[string]interface{}{"n": 1}}); err != nil {
+		t.Fatalf("UpdateOne: %v", err)
+	}
+	testutil.AssertDocumentExists(t, c, map[string]interface{}{"_id": 1, "n": 2})
+
+	// A nil validator removes it.
+	c.SetValidator(nil)
+	if _, err := c.InsertOne(ctx, map[string]interface{}{"_id": 3, "n": -1}); err != nil {
+		t.Fatalf("InsertOne: %v", err)
+	}
+	testutil.AssertDocumentCount(t, c, nil, 3)
+}
@@ -0,0 +1,297 @@
+package mingodb
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+
+	bolt "go.etcd.io/bbolt"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// ChangeEvent describes a change to a document in a watched collection.
+type ChangeEvent struct {
+	// OperationType is "insert", "update", "replace" or "delete".
+	OperationType string
+
+	// DocumentKey is the _id of the changed document.
+	DocumentKey InsertID
+
+	// FullDocument is the document after the change, or nil for
+	// deletes.
+	FullDocument interface{}
+
+	// UpdateDescription lists the fields changed by an update. It's
+	// nil for other operations.
+	UpdateDescription *UpdateDescription
+}
+
+// UpdateDescription lists the top-level fields changed by an update.
+type UpdateDescription struct {
+	UpdatedFields map[string]interface{} // New values of added or changed fields
+	RemovedFields []string               // Names of removed fields
+}
+
+// watchBufferSize is the number of events a watcher's channel holds
+// (see Watch).
+const watchBufferSize = 64
+
+// watcher is a channel returned by Watch.
+type watcher struct {
+	ch      chan ChangeEvent
+	filters []map[string]interface{} // $match stages of the pipeline
+	stop    chan struct{}            // Closed when the watcher is closed
+	once    sync.Once
+	mu      sync.Mutex // Guards ch and closed
+	closed  bool
+}
+
+// change is a committed write to a document, from which each
+// watcher's ChangeEvent is built.
+type change struct {
+	op        string
+	old, data []byte // Document before and after the write
+}
+
+// Watch returns a channel that receives an event for every insert,
+// update, replace and delete in the collection, once the write has
+// been committed. Each call returns its own channel, which is closed
+// when ctx is cancelled or the database is closed.
+//
+// The pipeline filters the events with $match stages, which match
+// against the event's fields as a document: operationType,
+// documentKey._id, fullDocument, updateDescription.updatedFields and
+// updateDescription.removedFields. For example:
+//
+//	[]interface{}{bson.M{"$match": bson.M{"operationType": "insert"}}}
+//
+// Soft deletes (see EnableSoftDelete) are delivered as "delete"
+// events, without a FullDocument.
+//
+// Events are delivered in the order they're committed. Writes don't
+// wait for watchers: the channel holds 64 events, and if a receiver
+// falls so far behind that it's full, the channel is closed rather
+// than the event dropped. Call Watch again to resume watching.
+func (c *Collection) Watch(ctx context.Context, pipeline []interface{}) (<-chan ChangeEvent, error) {
+	w := &watcher{ch: make(chan ChangeEvent, watchBufferSize), stop: make(chan struct{})}
+	for i, stage := range pipeline {
+		d, err := stageFields(stage)
+		if err != nil {
+			return nil, fmt.Errorf("stage %d: %w", i, err)
+		}
+		if len(d) != 1 {
+			return nil, fmt.Errorf("%w: stage %d must have exactly one key", ErrInvalidPipeline, i)
+		}
+		if d[0].Key != "$match" {
+			return nil, fmt.Errorf("%w: stage %d (%s): only $match is supported by Watch", ErrInvalidPipeline, i, d[0].Key)
+		}
+		filter, err := stageDocument(d[0].Value)
+		if err != nil {
+			return nil, fmt.Errorf("stage %d ($match): %w", i, err)
+		}
+		w.filters = append(w.filters, filter)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// Does the collection exist?
+	err := c.read(func(tx *bolt.Tx) error {
+		_, err := c.bucket(tx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	db := c.db
+	db.watchMu.Lock()
+	if db.watchers == nil {
+		db.watchers = make(map[string]map[*watcher]struct{})
+	}
+	if db.watchers[c.name] == nil {
+		db.watchers[c.name] = make(map[*watcher]struct{})
+	}
+	db.watchers[c.name][w] = struct{}{}
+	db.watchMu.Unlock()
+
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-w.stop:
+		}
+		db.watchMu.Lock()
+		delete(db.watchers[c.name], w)
+		if len(db.watchers[c.name]) == 0 {
+			delete(db.watchers, c.name)
+		}
+		db.watchMu.Unlock()
+		w.close()
+	}()
+	return w.ch, nil
+}
+
+// publish arranges for the collection's watchers to receive an op
+// event once tx is committed. old and data are the document before
+// and after the write.
+func (w *writeTx) publish(op string, old, data []byte) {
+	db, name := w.c.db, w.c.name
+	db.watchMu.Lock()
+	watched := len(db.watchers[name]) > 0
+	db.watchMu.Unlock()
+	if !watched {
+		return
+	}
+
+	// Convert the old document to BSON and copy it, as the bucket's
+	// values are only valid during the transaction.
+	if old != nil {
+		var err error
+		if old, _, err = decodeDocument(w.tx, old); err != nil {
+			return
+		}
+	}
+	ch := change{op: op, old: append([]byte(nil), old...), data: data}
+	w.tx.OnCommit(func() {
+		db.watchMu.Lock()
+		watchers := make([]*watcher, 0, len(db.watchers[name]))
+		for wt := range db.watchers[name] {
+			watchers = append(watchers, wt)
+		}
+		db.watchMu.Unlock()
+
+		for _, wt := range watchers {
+			// There's no one to report a corrupt document to, and
+			// the write has already been committed, so skip it.
+			e, err := ch.event()
+			if err != nil {
+				continue
+			}
+			if ok, err := wt.matches(e); err == nil && ok {
+				wt.send(e)
+			}
+		}
+	})
+}
+
+// event builds a ChangeEvent for the change. It's built afresh for
+// each watcher so that they can't modify each other's documents.
+func (ch change) event() (ChangeEvent, error) {
+	e := ChangeEvent{OperationType: ch.op}
+	var old, doc map[string]interface{}
+	if ch.old != nil {
+		if err := bson.Unmarshal(ch.old, &old); err != nil {
+			return e, err
+		}
+		e.DocumentKey = old["_id"]
+	}
+	// A soft delete stores the marked document, but it's reported
+	// like any other delete.
+	if ch.data == nil || ch.op == "delete" {
+		return e, nil
+	}
+	if err := bson.Unmarshal(ch.data, &doc); err != nil {
+		return e, err
+	}
+	e.DocumentKey = doc["_id"]
+	e.FullDocument = doc
+
+	if ch.op == "update" {
+		desc := &UpdateDescription{UpdatedFields: map[string]interface{}{}, RemovedFields: []string{}}
+		for k, v := range doc {
+			if ov, ok := old[k]; !ok || !reflect.DeepEqual(ov, v) {
+				desc.UpdatedFields[k] = v
+			}
+		}
+		for k := range old {
+			if _, ok := doc[k]; !ok {
+				desc.RemovedFields = append(desc.RemovedFields, k)
+			}
+		}
+		sort.Strings(desc.RemovedFields)
+		e.UpdateDescription = desc
+	}
+	return e, nil
+}
+
+// matches reports whether the event matches the watcher's pipeline.
+func (w *watcher) matches(e ChangeEvent) (bool, error) {
+	if len(w.filters) == 0 {
+		return true, nil
+	}
+	doc := map[string]interface{}{
+		"operationType": e.OperationType,
+		"documentKey":   map[string]interface{}{"_id": e.DocumentKey},
+	}
+	if e.FullDocument != nil {
+		doc["fullDocument"] = e.FullDocument
+	}
+	if d := e.UpdateDescription; d != nil {
+		removed := make([]interface{}, len(d.RemovedFields))
+		for i, f := range d.RemovedFields {
+			removed[i] = f
+		}
+		doc["updateDescription"] = map[string]interface{}{
+			"updatedFields": d.UpdatedFields,
+			"removedFields": removed,
+		}
+	}
+
+	for _, f := range w.filters {
+		ok, err := matchesFilter(doc, f)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// send delivers the event without waiting for the receiver. If the
+// channel is full, the watcher is closed instead, so that it doesn't
+// hold up the write or silently miss the event.
+func (w *watcher) send(e ChangeEvent) {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return
+	}
+	select {
+	case w.ch <- e:
+		w.mu.Unlock()
+		return
+	default:
+	}
+	w.mu.Unlock()
+	w.close()
+}
+
+// close closes the watcher's channel. It's safe to call more than once.
+func (w *watcher) close() {
+	w.once.Do(func() {
+		// Closing stop unregisters the watcher (see Watch).
+		close(w.stop)
+		w.mu.Lock()
+		w.closed = true
+		close(w.ch)
+		w.mu.Unlock()
+	})
+}
+
+// closeWatchers closes every watcher's channel. It's called by Close.
+func (db *Database) closeWatchers() {
+	db.watchMu.Lock()
+	var watchers []*watcher
+	for _, ws := range db.watchers {
+		for w := range ws {
+			watchers = append(watchers, w)
+		}
+	}
+	db.watchMu.Unlock()
+
+	for _, w := range watchers {
+		w.close()
+	}
+}
@@ -0,0 +1,152 @@
+package mingodb_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/korrbit/mingodb"
+	"github.com/korrbit/mingodb/testutil"
+)
+
+// nextEvent returns the next event on events, failing the test if
+// there isn't one within a second.
+func nextEvent(t *testing.T, events <-chan mingodb.ChangeEvent) mingodb.ChangeEvent {
+	t.Helper()
+	select {
+	case e, ok := <-events:
+		if !ok {
+			t.Fatal("events channel closed")
+		}
+		return e
+	case <-time.After(time.Second):
+		t.Fatal("no event received")
+	}
+	return mingodb.ChangeEvent{}
+}
+
+func TestWatch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c := testutil.NewTestDB(t).CollectionMust("items")
+	all, err := c.Watch(ctx, nil)
+	if err != nil {
+		t.Fatalf("Watch: %v", err)
+	}
+	deletes, err := c.Watch(ctx, []interface{}{
+		map[string]interface{}{"$match": map[string]interface{}{"operationType": "delete"}},
+	})
+	if err != nil {
+		t.Fatalf("Watch: %v", err)
+	}
+
+	testutil.SeedCollection(t, c, map[string]interface{}{"_id": 1, "a": 1, "b": 2})
+	if _, err := c.UpdateOne(ctx, map[string]interface{}{"_id": 1}, map[string]interface{}{
+		"$set":   map[string]interface{}{"a": 5},
+		"$unset": map[string]interface{}{"b": ""},
+	}); err != nil {
+		t.Fatalf("UpdateOne: %v", err)
+	}
+	if _, err := c.ReplaceOne(ctx, map[string]interface{}{"_id": 1}, map[string]interface{}{"c": 3}); err != nil {
+		t.Fatalf("ReplaceOne: %v", err)
+	}
+	if _, err := c.DeleteOne(ctx, 1); err != nil {
+		t.Fatalf("DeleteOne: %v", err)
+	}
+
+	expected := []mingodb.ChangeEvent{
+		{OperationType: "insert", DocumentKey: int32(1),
+			FullDocument: map[string]interface{}{"_id": int32(1), "a": int32(1), "b": int32(2), mingodb.VersionField: int64(1)}},
+		{OperationType: "update", DocumentKey: int32(1),
+			FullDocument: map[string]interface{}{"_id": int32(1), "a": int32(5), mingodb.VersionField: int64(2)},
+			UpdateDescription: &mingodb.UpdateDescription{
+				UpdatedFields: map[string]interface{}{"a": int32(5), mingodb.VersionField: int64(2)},
+				RemovedFields: []string{"b"},
+			}},
+		{OperationType: "replace", DocumentKey: int32(1),
+			FullDocument: map[string]interface{}{"_id": int32(1), "c": int32(3), mingodb.VersionField: int64(3)}},
+		{OperationType: "delete", DocumentKey: int32(1)},
+	}
+	for _, want := range expected {
+		if e := nextEvent(t, all); !reflect.DeepEqual(e, want) {
+			t.Errorf("got %+v, expected %+v", e, want)
+		}
+	}
+	if e := nextEvent(t, deletes); e.OperationType != "delete" {
+		t.Errorf("filtered watcher got a %s event, expected delete", e.OperationType)
+	}
+
+	// Cancelling the context closes the channel.
+	cancel()
+	select {
+	case _, ok := <-all:
+		if ok {
+			t.Error("received an event after cancelling, expected the channel to be closed")
+		}
+	case <-time.After(time.Second):
+		t.Error("channel not closed after cancelling")
+	}
+}
+
+func TestWatchIgnoresRolledBackWrites(t *testing.T) {
+	ctx := context.Background()
+	db := testutil.NewTestDB(t)
+	c := db.CollectionMust("items")
+	events, err := c.Watch(ctx, nil)
+	if err != nil {
+		t.Fatalf("Watch: %v", err)
+	}
+
+	tx, err := db.BeginTx(ctx)
+	if err != nil {
+		t.Fatalf("BeginTx: %v", err)
+	}
+	txc, err := tx.Collection("items")
+	if err != nil {
+		t.Fatalf("Collection: %v", err)
+	}
+	if _, err := txc.InsertOne(ctx, map[string]interface{}{"_id": 1}); err != nil {
+		t.Fatalf("InsertOne: %v", err)
+	}
+	if err := tx.Rollback(); err != nil {
+		t.Fatalf("Rollback: %v", err)
+	}
+	testutil.SeedCollection(t, c, map[string]interface{}{"_id": 2})
+
+	if e := nextEvent(t, events); e.DocumentKey != int32(2) {
+		t.Errorf("got an event for _id %v, expected 2", e.DocumentKey)
+	}
+}
+
+func TestWatchClosesSlowWatchers(t *testing.T) {
+	ctx := context.Background()
+	c := testutil.NewTestDB(t).CollectionMust("items")
+	slow, err := c.Watch(ctx, nil)
+	if err != nil {
+		t.Fatalf("Watch: %v", err)
+	}
+	fast, err := c.Watch(ctx, nil)
+	if err != nil {
+		t.Fatalf("Watch: %v", err)
+	}
+
+	// Nothing reads from slow, so once its channel is full it's closed
+	// rather than holding up the writes.
+	const n = 100
+	for i := 1; i <= n; i++ {
+		if _, err := c.InsertOne(ctx, map[string]interface{}{"_id": i}); err != nil {
+			t.Fatalf("InsertOne: %v", err)
+		}
+		if e := nextEvent(t, fast); e.DocumentKey != int32(i) {
+			t.Fatalf("got an event for _id %v, expected %d", e.DocumentKey, i)
+		}
+	}
+	received := 0
+	for range slow {
+		received++
+	}
+	if received == 0 || received >= n {
+		t.Errorf("slow watcher received %d events before being closed, expected between 1 and %d", received, n-1)
+	}
+}